@@ -5,6 +5,7 @@ import "time"
 // Cache interface
 type Cache interface {
 	Get(key string) interface{}
+	// Set timeout 为 0 时永不过期
 	Set(key string, val interface{}, timeout time.Duration) error
 	IsExist(key string) bool
 	Delete(key string) error
@@ -14,7 +14,11 @@ type Memory struct {
 
 type data struct {
 	Data    interface{}
-	Expired time.Time
+	Expired time.Time // 零值表示永不过期
+}
+
+func (d *data) expired() bool {
+	return !d.Expired.IsZero() && d.Expired.Before(time.Now())
 }
 
 // NewMemory create new memcache
@@ -27,7 +31,7 @@ func NewMemory() *Memory {
 // Get return cached value
 func (mem *Memory) Get(key string) interface{} {
 	if ret, ok := mem.data[key]; ok {
-		if ret.Expired.Before(time.Now()) {
+		if ret.expired() {
 			mem.deleteKey(key)
 			return nil
 		}
@@ -39,7 +43,7 @@ func (mem *Memory) Get(key string) interface{} {
 // IsExist check value exists in memcache.
 func (mem *Memory) IsExist(key string) bool {
 	if ret, ok := mem.data[key]; ok {
-		if ret.Expired.Before(time.Now()) {
+		if ret.expired() {
 			mem.deleteKey(key)
 			return false
 		}
@@ -53,10 +57,11 @@ func (mem *Memory) Set(key string, val interface{}, timeout time.Duration) (err
 	mem.Lock()
 	defer mem.Unlock()
 
-	mem.data[key] = &data{
-		Data:    val,
-		Expired: time.Now().Add(timeout),
+	d := &data{Data: val}
+	if timeout > 0 {
+		d.Expired = time.Now().Add(timeout)
 	}
+	mem.data[key] = d
 	return nil
 }
 
@@ -4,7 +4,6 @@ go 1.19
 
 require (
 	github.com/bradfitz/gomemcache v0.0.0-20190913173617-a41fca850d0b
-	github.com/fatih/structs v1.1.0
 	github.com/gomodule/redigo v1.8.5
 	github.com/kr/text v0.2.0 // indirect
 	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
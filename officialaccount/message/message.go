@@ -75,6 +75,8 @@ const (
 	EventWxaMediaCheck EventType = "wxa_media_check"
 	// EventSubscribeMsgPopupEvent 订阅通知事件推送
 	EventSubscribeMsgPopupEvent EventType = "subscribe_msg_popup_event"
+	// EventWxaNicknameAudit 第三方平台代小程序设置名称的审核结果推送
+	EventWxaNicknameAudit EventType = "wxa_nickname_audit"
 )
 
 const (
@@ -90,6 +92,8 @@ const (
 	InfoTypeUpdateAuthorized InfoType = "updateauthorized"
 	// InfoTypeNotifyThirdFasterRegister 注册审核事件推送
 	InfoTypeNotifyThirdFasterRegister InfoType = "notify_third_fasteregister"
+	// InfoTypeNotifyThirdFastRegisterBetaApp 试用小程序注册审核事件推送
+	InfoTypeNotifyThirdFastRegisterBetaApp InfoType = "notify_third_fastregisterbetaapp"
 )
 
 // MixMessage 存放所有微信发送过来的消息和事件
@@ -168,6 +172,11 @@ type MixMessage struct {
 		ComponentPhone     string `xml:"component_phone"`
 	} `xml:"info"`
 
+	// 小程序名称审核结果，ret 为 2 时审核失败，3 时审核成功
+	Ret      int    `xml:"ret"`
+	NickName string `xml:"nickname"`
+	Reason   string `xml:"reason"`
+
 	// 卡券相关
 	CardID              string `xml:"CardId"`
 	RefuseReason        string `xml:"RefuseReason"`
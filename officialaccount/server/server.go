@@ -27,7 +27,8 @@ type Server struct {
 
 	openID string
 
-	messageHandler func(*message.MixMessage) *message.Reply
+	messageHandler     func(*message.MixMessage) *message.Reply
+	messageInterceptor func(*message.MixMessage)
 
 	RequestRawXMLMsg  []byte
 	RequestMsg        *message.MixMessage
@@ -110,6 +111,9 @@ func (srv *Server) handleRequest() (reply *message.Reply, err error) {
 		err = errors.New("消息类型转换失败")
 	}
 	srv.RequestMsg = mixMessage
+	if srv.messageInterceptor != nil {
+		srv.messageInterceptor(mixMessage)
+	}
 	reply = srv.messageHandler(mixMessage)
 	return
 }
@@ -171,6 +175,11 @@ func (srv *Server) SetMessageHandler(handler func(*message.MixMessage) *message.
 	srv.messageHandler = handler
 }
 
+// SetMessageInterceptor 设置在回调方法之前执行的方法，如开放平台用于保存 component_verify_ticket
+func (srv *Server) SetMessageInterceptor(interceptor func(*message.MixMessage)) {
+	srv.messageInterceptor = interceptor
+}
+
 func (srv *Server) buildResponse(reply *message.Reply) (err error) {
 	defer func() {
 		if e := recover(); e != nil {
@@ -1,9 +1,22 @@
 package account
 
-import "github.com/kuro-liang/wechat-go/openplatform/context"
+import (
+	"errors"
 
-// Account 开放平台张哈管理
-// TODO 实现方法
+	"github.com/kuro-liang/wechat-go/openplatform/context"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+const (
+	createOpenURL = "https://api.weixin.qq.com/cgi-bin/open/create?access_token=%s"
+	bindOpenURL   = "https://api.weixin.qq.com/cgi-bin/open/bind?access_token=%s"
+	unbindOpenURL = "https://api.weixin.qq.com/cgi-bin/open/unbind?access_token=%s"
+	getOpenURL    = "https://api.weixin.qq.com/cgi-bin/open/get?access_token=%s"
+	haveOpenURL   = "https://api.weixin.qq.com/cgi-bin/open/have?access_token=%s"
+)
+
+// Account 开放平台帐号管理，使用授权方的 authorizer_access_token 调用
+// https://developers.weixin.qq.com/doc/oplatform/Third-party_Platforms/2.0/api/account/create.html
 type Account struct {
 	*context.Context
 }
@@ -13,22 +26,60 @@ func NewAccount(ctx *context.Context) *Account {
 	return &Account{ctx}
 }
 
-// Create 创建开放平台帐号并绑定公众号/小程序
+// Create 创建开放平台帐号并绑定公众号/小程序，返回开放平台帐号 appid
 func (account *Account) Create(appID string) (string, error) {
-	return "", nil
+	var ret struct {
+		util.CommonError
+		OpenAppID string `json:"open_appid"`
+	}
+	if err := account.post(createOpenURL, appID, map[string]string{"appid": appID}, &ret, "open/create"); err != nil {
+		return "", err
+	}
+	return ret.OpenAppID, nil
 }
 
 // Bind 将公众号/小程序绑定到开放平台帐号下
-func (account *Account) Bind(appID string) error {
-	return nil
+func (account *Account) Bind(appID, openAppID string) error {
+	var ret util.CommonError
+	return account.post(bindOpenURL, appID, map[string]string{"appid": appID, "open_appid": openAppID}, &ret, "open/bind")
 }
 
 // Unbind 将公众号/小程序从开放平台帐号下解绑
-func (account *Account) Unbind(appID string, openAppID string) error {
-	return nil
+func (account *Account) Unbind(appID, openAppID string) error {
+	var ret util.CommonError
+	return account.post(unbindOpenURL, appID, map[string]string{"appid": appID, "open_appid": openAppID}, &ret, "open/unbind")
 }
 
-// Get 获取公众号/小程序所绑定的开放平台帐号
+// Get 获取公众号/小程序所绑定的开放平台帐号，未绑定时返回 ErrNotBound
 func (account *Account) Get(appID string) (string, error) {
-	return "", nil
+	var ret struct {
+		util.CommonError
+		OpenAppID string `json:"open_appid"`
+	}
+	if err := account.post(getOpenURL, appID, map[string]string{"appid": appID}, &ret, "open/get"); err != nil {
+		return "", err
+	}
+	return ret.OpenAppID, nil
+}
+
+// Have 查询公众号/小程序是否绑定了开放平台帐号
+func (account *Account) Have(appID string) (bool, error) {
+	var ret struct {
+		util.CommonError
+		HaveOpen bool `json:"have_open"`
+	}
+	if err := account.post(haveOpenURL, appID, map[string]string{}, &ret, "open/have"); err != nil {
+		return false, err
+	}
+	return ret.HaveOpen, nil
+}
+
+// post 使用授权方的 access_token 调用接口并将返回解析到 ret，错误码转换为 Error
+func (account *Account) post(urlFormat, appID string, req interface{}, ret interface{}, apiName string) error {
+	err := account.AuthrPost(appID, urlFormat, req, ret, apiName)
+	var apiErr *context.APIError
+	if errors.As(err, &apiErr) {
+		return newError(apiErr.CommonError, apiName)
+	}
+	return err
 }
@@ -0,0 +1,68 @@
+package account
+
+import (
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/openplatform/optest"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func newTestAccount() *Account {
+	return NewAccount(optest.NewContext(map[string]string{"wx-authorizer": "authr-token"}))
+}
+
+func TestAccount(t *testing.T) {
+	defer gock.Off()
+	account := newTestAccount()
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/open/create").
+		MatchParam("access_token", "authr-token").
+		JSON(map[string]string{"appid": "wx-authorizer"}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "open_appid": "wx-open"})
+	openAppID, err := account.Create("wx-authorizer")
+	assert.Nil(t, err)
+	assert.Equal(t, "wx-open", openAppID)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/open/bind").
+		JSON(map[string]string{"appid": "wx-authorizer", "open_appid": "wx-open"}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 89000, "errmsg": "account has bound open"})
+	assert.Equal(t, ErrAlreadyBound, account.Bind("wx-authorizer", "wx-open"))
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/open/unbind").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+	assert.Nil(t, account.Unbind("wx-authorizer", "wx-open"))
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/open/get").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 89002, "errmsg": "open not exists"})
+	_, err = account.Get("wx-authorizer")
+	assert.Equal(t, ErrNotBound, err)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/open/have").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "have_open": true})
+	have, err := account.Have("wx-authorizer")
+	assert.Nil(t, err)
+	assert.True(t, have)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/open/have").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 40013, "errmsg": "invalid appid"})
+	_, err = account.Have("wx-authorizer")
+	assert.EqualError(t, err, "open/have Error , errcode=40013 , errmsg=invalid appid")
+	assert.True(t, gock.IsDone())
+
+	// 未授权的公众号
+	_, err = account.Get("wx-unknown")
+	assert.Error(t, err)
+}
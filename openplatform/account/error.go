@@ -0,0 +1,47 @@
+package account
+
+import (
+	"fmt"
+
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// Error 开放平台帐号管理接口的错误
+type Error string
+
+const (
+	// ErrAlreadyBound 错误码：89000
+	ErrAlreadyBound Error = "该公众号/小程序已经绑定了开放平台帐号"
+	// ErrPrincipalMismatch 错误码：89001
+	ErrPrincipalMismatch Error = "授权方与开放平台帐号主体不相同"
+	// ErrNotBound 错误码：89002
+	ErrNotBound Error = "该公众号/小程序未绑定微信开放平台帐号"
+	// ErrNotCreatedByAPI 错误码：89003
+	ErrNotCreatedByAPI Error = "该开放平台帐号并非通过 api 创建，不允许操作"
+	// ErrBindLimitReached 错误码：89004
+	ErrBindLimitReached Error = "该开放平台帐号所绑定的公众号/小程序已达上限"
+)
+
+// Error 输出错误信息
+func (e Error) Error() string {
+	return string(e)
+}
+
+var codeDic = map[int64]Error{
+	89000: ErrAlreadyBound,
+	89001: ErrPrincipalMismatch,
+	89002: ErrNotBound,
+	89003: ErrNotCreatedByAPI,
+	89004: ErrBindLimitReached,
+}
+
+// newError 将微信返回的错误码转换为 Error，未知错误码保留原始的 errcode 与 errmsg
+func newError(commErr util.CommonError, apiName string) error {
+	if commErr.ErrCode == 0 {
+		return nil
+	}
+	if err, ok := codeDic[commErr.ErrCode]; ok {
+		return err
+	}
+	return fmt.Errorf("%s Error , errcode=%d , errmsg=%s", apiName, commErr.ErrCode, commErr.ErrMsg)
+}
@@ -18,30 +18,82 @@ const (
 	getComponentInfoURL     = "https://api.weixin.qq.com/cgi-bin/component/api_get_authorizer_info?component_access_token=%s"
 	componentLoginURL       = "https://mp.weixin.qq.com/cgi-bin/componentloginpage?component_appid=%s&pre_auth_code=%s&redirect_uri=%s&auth_type=%d&biz_appid=%s"
 	bindComponentURL        = "https://mp.weixin.qq.com/safe/bindcomponent?action=bindcomponent&auth_type=%d&no_scan=1&component_appid=%s&pre_auth_code=%s&redirect_uri=%s&biz_appid=%s#wechat_redirect"
-	// TODO 获取授权方选项信息
-	// getComponentConfigURL = "https://api.weixin.qq.com/cgi-bin/component/api_get_authorizer_option?component_access_token=%s"
-	// TODO 获取已授权的账号信息
-	// getuthorizerListURL = "POST https://api.weixin.qq.com/cgi-bin/component/api_get_authorizer_list?component_access_token=%s"
 )
 
+// verifyTicketExpires component_verify_ticket 的有效期，微信每 10 分钟推送一次
+const verifyTicketExpires = 12 * time.Hour
+
 // ComponentAccessToken 第三方平台
 type ComponentAccessToken struct {
 	AccessToken string `json:"component_access_token"`
 	ExpiresIn   int64  `json:"expires_in"`
 }
 
-// GetComponentAccessToken 获取 ComponentAccessToken
+func (ctx *Context) componentAccessTokenCacheKey() string {
+	return fmt.Sprintf("component_access_token_%s", ctx.AppID)
+}
+
+func (ctx *Context) verifyTicketCacheKey() string {
+	return fmt.Sprintf("component_verify_ticket_%s", ctx.AppID)
+}
+
+// SetComponentVerifyTicket 保存微信推送的 component_verify_ticket，用于获取 component_access_token
+func (ctx *Context) SetComponentVerifyTicket(verifyTicket string) error {
+	if verifyTicket == "" {
+		return fmt.Errorf("component_verify_ticket is empty")
+	}
+	return ctx.Cache.Set(ctx.verifyTicketCacheKey(), verifyTicket, verifyTicketExpires)
+}
+
+// GetComponentVerifyTicket 获取最近一次推送的 component_verify_ticket
+func (ctx *Context) GetComponentVerifyTicket() (string, error) {
+	if val, ok := ctx.Cache.Get(ctx.verifyTicketCacheKey()).(string); ok && val != "" {
+		return val, nil
+	}
+	return "", fmt.Errorf("cannot get component verify ticket, waiting for wechat to push it")
+}
+
+// GetComponentAccessToken 获取 ComponentAccessToken，缓存失效时使用保存的 component_verify_ticket 重新获取
 func (ctx *Context) GetComponentAccessToken() (string, error) {
-	accessTokenCacheKey := fmt.Sprintf("component_access_token_%s", ctx.AppID)
-	val := ctx.Cache.Get(accessTokenCacheKey)
-	if val == nil {
-		return "", fmt.Errorf("cann't get component access token")
+	accessTokenCacheKey := ctx.componentAccessTokenCacheKey()
+	if val, ok := ctx.Cache.Get(accessTokenCacheKey).(string); ok && val != "" {
+		return val, nil
+	}
+
+	// 加上lock，是为了防止在并发获取token时，cache刚好失效，导致从微信服务器上获取到不同token
+	ctx.componentAccessTokenLock.Lock()
+	defer ctx.componentAccessTokenLock.Unlock()
+
+	// 双检，防止重复从微信服务器获取
+	if val, ok := ctx.Cache.Get(accessTokenCacheKey).(string); ok && val != "" {
+		return val, nil
+	}
+
+	verifyTicket, err := ctx.GetComponentVerifyTicket()
+	if err != nil {
+		return "", err
 	}
-	return val.(string), nil
+	at, err := ctx.fetchComponentAccessToken(verifyTicket)
+	if err != nil {
+		return "", err
+	}
+	return at.AccessToken, nil
 }
 
 // SetComponentAccessToken 通过component_verify_ticket 获取 ComponentAccessToken
+// 同时保存 component_verify_ticket，之后 GetComponentAccessToken 可自行刷新
 func (ctx *Context) SetComponentAccessToken(verifyTicket string) (*ComponentAccessToken, error) {
+	if err := ctx.SetComponentVerifyTicket(verifyTicket); err != nil {
+		return nil, err
+	}
+
+	ctx.componentAccessTokenLock.Lock()
+	defer ctx.componentAccessTokenLock.Unlock()
+	return ctx.fetchComponentAccessToken(verifyTicket)
+}
+
+// fetchComponentAccessToken 从微信服务器获取 ComponentAccessToken 并写入缓存
+func (ctx *Context) fetchComponentAccessToken(verifyTicket string) (*ComponentAccessToken, error) {
 	body := map[string]string{
 		"component_appid":         ctx.AppID,
 		"component_appsecret":     ctx.AppSecret,
@@ -52,17 +104,22 @@ func (ctx *Context) SetComponentAccessToken(verifyTicket string) (*ComponentAcce
 		return nil, err
 	}
 
-	at := &ComponentAccessToken{}
-	if err := json.Unmarshal(respBody, at); err != nil {
+	var ret struct {
+		util.CommonError
+		ComponentAccessToken
+	}
+	if err := json.Unmarshal(respBody, &ret); err != nil {
 		return nil, err
 	}
+	if ret.ErrCode != 0 {
+		return nil, fmt.Errorf("get component access token error : errcode=%v , errmsg=%v", ret.ErrCode, ret.ErrMsg)
+	}
 
-	accessTokenCacheKey := fmt.Sprintf("component_access_token_%s", ctx.AppID)
-	expires := at.ExpiresIn - 1500
-	if err := ctx.Cache.Set(accessTokenCacheKey, at.AccessToken, time.Duration(expires)*time.Second); err != nil {
-		return nil, nil
+	expires := ret.ExpiresIn - 1500
+	if err := ctx.Cache.Set(ctx.componentAccessTokenCacheKey(), ret.AccessToken, time.Duration(expires)*time.Second); err != nil {
+		return nil, err
 	}
-	return at, nil
+	return &ret.ComponentAccessToken, nil
 }
 
 // GetPreCode 获取预授权码
@@ -121,7 +178,15 @@ type AuthBaseInfo struct {
 
 // AuthFuncInfo 授权的接口内容
 type AuthFuncInfo struct {
-	FuncscopeCategory ID `json:"funcscope_category"`
+	FuncscopeCategory ID          `json:"funcscope_category"`
+	ConfirmInfo       ConfirmInfo `json:"confirm_info"`
+}
+
+// ConfirmInfo 权限集的确认情况，部分权限集需要授权方在公众平台确认后才生效
+type ConfirmInfo struct {
+	NeedConfirm    int `json:"need_confirm"`
+	AlreadyConfirm int `json:"already_confirm"`
+	CanConfirm     int `json:"can_confirm"`
 }
 
 // AuthrAccessToken 授权方AccessToken
@@ -133,6 +198,7 @@ type AuthrAccessToken struct {
 }
 
 // QueryAuthCode 使用授权码换取公众号或小程序的接口调用凭据和授权信息
+// 换取的令牌会写入 AuthorizerTokenStore，之后 GetAuthrAccessToken 可自动刷新
 func (ctx *Context) QueryAuthCode(authCode string) (*AuthBaseInfo, error) {
 	cat, err := ctx.GetComponentAccessToken()
 	if err != nil {
@@ -161,10 +227,17 @@ func (ctx *Context) QueryAuthCode(authCode string) (*AuthBaseInfo, error) {
 		err = fmt.Errorf("QueryAuthCode error : errcode=%v , errmsg=%v", ret.ErrCode, ret.ErrMsg)
 		return nil, err
 	}
+	if ret.Info == nil {
+		return nil, fmt.Errorf("QueryAuthCode error : authorization_info is empty")
+	}
+	if err := ctx.saveAuthrToken(&ret.Info.AuthrAccessToken); err != nil {
+		return nil, err
+	}
 	return ret.Info, nil
 }
 
 // RefreshAuthrToken 获取（刷新）授权公众号或小程序的接口调用凭据（令牌）
+// 新的 authorizer_access_token 及 authorizer_refresh_token 会写入 AuthorizerTokenStore
 func (ctx *Context) RefreshAuthrToken(appid, refreshToken string) (*AuthrAccessToken, error) {
 	cat, err := ctx.GetComponentAccessToken()
 	if err != nil {
@@ -182,45 +255,128 @@ func (ctx *Context) RefreshAuthrToken(appid, refreshToken string) (*AuthrAccessT
 		return nil, err
 	}
 
-	ret := &AuthrAccessToken{}
-	if err := json.Unmarshal(body, ret); err != nil {
+	var ret struct {
+		util.CommonError
+		AuthrAccessToken
+	}
+	if err := json.Unmarshal(body, &ret); err != nil {
 		return nil, err
 	}
+	if ret.ErrCode != 0 {
+		return nil, fmt.Errorf("RefreshAuthrToken error : errcode=%v , errmsg=%v", ret.ErrCode, ret.ErrMsg)
+	}
 
-	authrTokenKey := "authorizer_access_token_" + appid
-	if err := ctx.Cache.Set(authrTokenKey, ret.AccessToken, time.Minute*80); err != nil {
+	ret.Appid = appid
+	if ret.RefreshToken == "" {
+		ret.RefreshToken = refreshToken
+	}
+	if err := ctx.saveAuthrToken(&ret.AuthrAccessToken); err != nil {
 		return nil, err
 	}
-	return ret, nil
+	return &ret.AuthrAccessToken, nil
+}
+
+// saveAuthrToken 保存授权方令牌，access_token 比微信的有效期提前 10 分钟过期
+func (ctx *Context) saveAuthrToken(token *AuthrAccessToken) error {
+	store := ctx.GetAuthorizerTokenStore()
+	if token.RefreshToken != "" {
+		if err := store.SetRefreshToken(token.Appid, token.RefreshToken); err != nil {
+			return err
+		}
+	}
+	expires := time.Duration(token.ExpiresIn-600) * time.Second
+	if expires <= 0 {
+		return nil
+	}
+	return store.SetAccessToken(token.Appid, token.AccessToken, expires)
 }
 
 // GetAuthrAccessToken 获取授权方AccessToken
+// 存储中的 access_token 失效时，使用保存的 authorizer_refresh_token 自动刷新
 func (ctx *Context) GetAuthrAccessToken(appid string) (string, error) {
-	authrTokenKey := "authorizer_access_token_" + appid
-	val := ctx.Cache.Get(authrTokenKey)
-	if val == nil {
-		return "", fmt.Errorf("cannot get authorizer %s access token", appid)
+	store := ctx.GetAuthorizerTokenStore()
+	if val, err := store.GetAccessToken(appid); err != nil || val != "" {
+		return val, err
+	}
+
+	lock := ctx.authorizerTokenLock(appid)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// 双检，防止重复从微信服务器获取
+	if val, err := store.GetAccessToken(appid); err != nil || val != "" {
+		return val, err
+	}
+
+	refreshToken, err := store.GetRefreshToken(appid)
+	if err != nil {
+		return "", err
 	}
-	return val.(string), nil
+	if refreshToken == "" {
+		return "", fmt.Errorf("cannot get authorizer %s access token, refresh token not found", appid)
+	}
+	token, err := ctx.RefreshAuthrToken(appid, refreshToken)
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
 }
 
 // AuthorizerInfo 授权方详细信息
 type AuthorizerInfo struct {
-	NickName        string `json:"nick_name"`
-	HeadImg         string `json:"head_img"`
-	ServiceTypeInfo ID     `json:"service_type_info"`
-	VerifyTypeInfo  ID     `json:"verify_type_info"`
-	UserName        string `json:"user_name"`
-	PrincipalName   string `json:"principal_name"`
-	BusinessInfo    struct {
-		OpenStore string `json:"open_store"`
-		OpenScan  string `json:"open_scan"`
-		OpenPay   string `json:"open_pay"`
-		OpenCard  string `json:"open_card"`
-		OpenShake string `json:"open_shake"`
-	}
-	Alias     string `json:"alias"`
-	QrcodeURL string `json:"qrcode_url"`
+	NickName        string       `json:"nick_name"`
+	HeadImg         string       `json:"head_img"`
+	ServiceTypeInfo ID           `json:"service_type_info"`
+	VerifyTypeInfo  ID           `json:"verify_type_info"`
+	UserName        string       `json:"user_name"`
+	PrincipalName   string       `json:"principal_name"`
+	BusinessInfo    BusinessInfo `json:"business_info"`
+	Alias           string       `json:"alias"`
+	QrcodeURL       string       `json:"qrcode_url"`
+	Signature       string       `json:"signature"`
+	AccountStatus   int          `json:"account_status"` // 帐号状态，1 正常，14 已注销，16 已封禁，18 已告警，19 已冻结
+	RegisterType    int          `json:"register_type"`  // 小程序注册方式
+	BasicConfig     *BasicConfig `json:"basic_config,omitempty"`
+
+	// MiniProgramInfo 授权方为小程序时返回，可据此区分公众号与小程序
+	MiniProgramInfo *MiniProgramInfo `json:"MiniProgramInfo,omitempty"`
+}
+
+// BusinessInfo 授权方功能的开通状况，0 未开通，1 已开通
+type BusinessInfo struct {
+	OpenStore int `json:"open_store"`
+	OpenScan  int `json:"open_scan"`
+	OpenPay   int `json:"open_pay"`
+	OpenCard  int `json:"open_card"`
+	OpenShake int `json:"open_shake"`
+}
+
+// BasicConfig 小程序基础配置信息
+type BasicConfig struct {
+	IsPhoneConfigured bool `json:"is_phone_configured"`
+	IsEmailConfigured bool `json:"is_email_configured"`
+}
+
+// MiniProgramInfo 小程序配置的服务器域名、类目等信息
+type MiniProgramInfo struct {
+	Network struct {
+		RequestDomain   []string `json:"RequestDomain"`
+		WsRequestDomain []string `json:"WsRequestDomain"`
+		UploadDomain    []string `json:"UploadDomain"`
+		DownloadDomain  []string `json:"DownloadDomain"`
+		BizDomain       []string `json:"BizDomain"`
+		UDPDomain       []string `json:"UDPDomain"`
+	} `json:"network"`
+	Categories []struct {
+		First  string `json:"first"`
+		Second string `json:"second"`
+	} `json:"categories"`
+	VisitStatus int `json:"visit_status"`
+}
+
+// IsMiniProgram 授权方是否为小程序
+func (info *AuthorizerInfo) IsMiniProgram() bool {
+	return info.MiniProgramInfo != nil
 }
 
 // GetAuthrInfo 获取授权方的帐号基本信息
@@ -242,10 +398,11 @@ func (ctx *Context) GetAuthrInfo(appid string) (*AuthorizerInfo, *AuthBaseInfo,
 	}
 
 	var ret struct {
+		util.CommonError
 		AuthorizerInfo    *AuthorizerInfo `json:"authorizer_info"`
 		AuthorizationInfo *AuthBaseInfo   `json:"authorization_info"`
 	}
-	if err := json.Unmarshal(body, &ret); err != nil {
+	if err := util.DecodeWithError(body, &ret, "GetAuthrInfo"); err != nil {
 		return nil, nil, err
 	}
 
@@ -0,0 +1,147 @@
+package context
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/cache"
+	"github.com/kuro-liang/wechat-go/openplatform/config"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func newTestContext() *Context {
+	return &Context{Config: &config.Config{AppID: "wx-component", AppSecret: "secret", Cache: cache.NewMemory()}}
+}
+
+func TestGetComponentAccessToken(t *testing.T) {
+	defer gock.Off()
+	ctx := newTestContext()
+
+	// 尚未收到 component_verify_ticket
+	_, err := ctx.GetComponentAccessToken()
+	assert.Error(t, err)
+
+	assert.Nil(t, ctx.SetComponentVerifyTicket("ticket@@@1"))
+	gock.New(componentAccessTokenURL).
+		MatchType("json").
+		JSON(map[string]string{
+			"component_appid":         "wx-component",
+			"component_appsecret":     "secret",
+			"component_verify_ticket": "ticket@@@1",
+		}).
+		Times(1).
+		Reply(200).
+		JSON(map[string]interface{}{"component_access_token": "cat-1", "expires_in": 7200})
+
+	token, err := ctx.GetComponentAccessToken()
+	assert.Nil(t, err)
+	assert.Equal(t, "cat-1", token)
+
+	// 第二次从缓存读取
+	token, err = ctx.GetComponentAccessToken()
+	assert.Nil(t, err)
+	assert.Equal(t, "cat-1", token)
+	assert.True(t, gock.IsDone())
+}
+
+func TestSetComponentAccessTokenError(t *testing.T) {
+	defer gock.Off()
+	ctx := newTestContext()
+
+	gock.New(componentAccessTokenURL).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 61006, "errmsg": "component ticket is invalid"})
+
+	at, err := ctx.SetComponentAccessToken("expired-ticket")
+	assert.Nil(t, at)
+	assert.EqualError(t, err, "get component access token error : errcode=61006 , errmsg=component ticket is invalid")
+
+	// ticket 仍会被保存，等待下一次推送覆盖
+	ticket, err := ctx.GetComponentVerifyTicket()
+	assert.Nil(t, err)
+	assert.Equal(t, "expired-ticket", ticket)
+}
+
+func TestGetAuthrAccessToken(t *testing.T) {
+	defer gock.Off()
+	ctx := newTestContext()
+	assert.Nil(t, ctx.Cache.Set(ctx.componentAccessTokenCacheKey(), "cat-1", time.Hour))
+
+	// 未授权或 refresh token 丢失
+	_, err := ctx.GetAuthrAccessToken("wx-authorizer")
+	assert.Error(t, err)
+
+	store := ctx.GetAuthorizerTokenStore()
+	assert.Nil(t, store.SetRefreshToken("wx-authorizer", "refresh-1"))
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/component/api_authorizer_token").
+		MatchParam("component_access_token", "cat-1").
+		MatchType("json").
+		JSON(map[string]string{
+			"component_appid":          "wx-component",
+			"authorizer_appid":         "wx-authorizer",
+			"authorizer_refresh_token": "refresh-1",
+		}).
+		Times(1).
+		Reply(200).
+		JSON(map[string]interface{}{
+			"authorizer_access_token":  "authr-1",
+			"expires_in":               7200,
+			"authorizer_refresh_token": "refresh-2",
+		})
+
+	token, err := ctx.GetAuthrAccessToken("wx-authorizer")
+	assert.Nil(t, err)
+	assert.Equal(t, "authr-1", token)
+	token, err = ctx.GetAuthrAccessToken("wx-authorizer")
+	assert.Nil(t, err)
+	assert.Equal(t, "authr-1", token)
+	assert.True(t, gock.IsDone())
+
+	refreshToken, err := store.GetRefreshToken("wx-authorizer")
+	assert.Nil(t, err)
+	assert.Equal(t, "refresh-2", refreshToken)
+
+	// refresh token 不设置过期时间
+	assert.True(t, ctx.Cache.IsExist(refreshTokenCacheKey("wx-authorizer")))
+
+	// 取消授权后不再能获取
+	assert.Nil(t, ctx.DeleteAuthorizer("wx-authorizer"))
+	_, err = ctx.GetAuthrAccessToken("wx-authorizer")
+	assert.Error(t, err)
+}
+
+func TestDeleteAuthorizerDuringRefresh(t *testing.T) {
+	defer gock.Off()
+	ctx := newTestContext()
+	assert.Nil(t, ctx.Cache.Set(ctx.componentAccessTokenCacheKey(), "cat-1", time.Hour))
+	store := ctx.GetAuthorizerTokenStore()
+	assert.Nil(t, store.SetRefreshToken("wx-authorizer", "refresh-1"))
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/component/api_authorizer_token").
+		Reply(200).
+		Delay(50 * time.Millisecond).
+		JSON(map[string]interface{}{
+			"authorizer_access_token":  "authr-1",
+			"expires_in":               7200,
+			"authorizer_refresh_token": "refresh-2",
+		})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = ctx.GetAuthrAccessToken("wx-authorizer")
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// 刷新进行中取消授权，刷新结果不能在删除后写回
+	assert.Nil(t, ctx.DeleteAuthorizer("wx-authorizer"))
+	<-done
+	accessToken, err := store.GetAccessToken("wx-authorizer")
+	assert.Nil(t, err)
+	assert.Empty(t, accessToken)
+	refreshToken, err := store.GetRefreshToken("wx-authorizer")
+	assert.Nil(t, err)
+	assert.Empty(t, refreshToken)
+}
@@ -0,0 +1,188 @@
+package context
+
+import (
+	"fmt"
+
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+const (
+	getAuthorizerListURL   = "https://api.weixin.qq.com/cgi-bin/component/api_get_authorizer_list?component_access_token=%s"
+	getAuthorizerOptionURL = "https://api.weixin.qq.com/cgi-bin/component/api_get_authorizer_option?component_access_token=%s"
+	setAuthorizerOptionURL = "https://api.weixin.qq.com/cgi-bin/component/api_set_authorizer_option?component_access_token=%s"
+)
+
+// MaxAuthorizerListCount 拉取授权方列表时每页的最大数量
+const MaxAuthorizerListCount = 500
+
+// 授权方选项名称
+const (
+	OptionLocationReport  = "location_report"  // 地理位置上报，0 无上报，1 进入会话时上报，2 每 5s 上报
+	OptionVoiceRecognize  = "voice_recognize"  // 语音识别，0 关闭，1 开启
+	OptionCustomerService = "customer_service" // 多客服，0 关闭，1 开启
+)
+
+// AuthorizerListItem 已授权的帐号
+type AuthorizerListItem struct {
+	AuthorizerAppID string `json:"authorizer_appid"`
+	RefreshToken    string `json:"refresh_token"`
+	AuthTime        int64  `json:"auth_time"`
+}
+
+// AuthorizerList 已授权的帐号列表
+type AuthorizerList struct {
+	TotalCount int                  `json:"total_count"`
+	List       []AuthorizerListItem `json:"list"`
+}
+
+// GetAuthorizerList 拉取已授权的帐号列表，count 最大为 500
+func (ctx *Context) GetAuthorizerList(offset, count int) (*AuthorizerList, error) {
+	if count <= 0 || count > MaxAuthorizerListCount {
+		count = MaxAuthorizerListCount
+	}
+	cat, err := ctx.GetComponentAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	req := map[string]interface{}{
+		"component_appid": ctx.AppID,
+		"offset":          offset,
+		"count":           count,
+	}
+	body, err := util.PostJSON(fmt.Sprintf(getAuthorizerListURL, cat), req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret struct {
+		util.CommonError
+		AuthorizerList
+	}
+	if err := util.DecodeWithError(body, &ret, "GetAuthorizerList"); err != nil {
+		return nil, err
+	}
+	return &ret.AuthorizerList, nil
+}
+
+// AuthorizerIterator 分页遍历全部已授权的帐号
+//
+//	it := ctx.NewAuthorizerIterator(0)
+//	for it.Next() {
+//		fmt.Println(it.Authorizer().AuthorizerAppID)
+//	}
+//	err := it.Err()
+type AuthorizerIterator struct {
+	ctx      *Context
+	pageSize int
+
+	offset int
+	total  int
+	page   []AuthorizerListItem
+	index  int
+	done   bool
+	err    error
+}
+
+// NewAuthorizerIterator 创建授权方列表的遍历，pageSize 为 0 时每页拉取 500 个
+func (ctx *Context) NewAuthorizerIterator(pageSize int) *AuthorizerIterator {
+	if pageSize <= 0 || pageSize > MaxAuthorizerListCount {
+		pageSize = MaxAuthorizerListCount
+	}
+	return &AuthorizerIterator{ctx: ctx, pageSize: pageSize, index: -1}
+}
+
+// Next 移动到下一个授权方，没有更多或出错时返回 false
+func (it *AuthorizerIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.index+1 < len(it.page) {
+		it.index++
+		return true
+	}
+	if it.done {
+		return false
+	}
+
+	list, err := it.ctx.GetAuthorizerList(it.offset, it.pageSize)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.total = list.TotalCount
+	it.page = list.List
+	it.index = 0
+	it.offset += len(list.List)
+	if len(list.List) < it.pageSize || it.offset >= it.total {
+		it.done = true
+	}
+	return len(it.page) > 0
+}
+
+// Authorizer 当前的授权方
+func (it *AuthorizerIterator) Authorizer() *AuthorizerListItem {
+	if it.index < 0 || it.index >= len(it.page) {
+		return nil
+	}
+	return &it.page[it.index]
+}
+
+// TotalCount 最近一次拉取时返回的授权方总数
+func (it *AuthorizerIterator) TotalCount() int {
+	return it.total
+}
+
+// Err 遍历过程中发生的错误
+func (it *AuthorizerIterator) Err() error {
+	return it.err
+}
+
+// GetAuthorizerOption 获取授权方选项信息
+func (ctx *Context) GetAuthorizerOption(appid, optionName string) (string, error) {
+	cat, err := ctx.GetComponentAccessToken()
+	if err != nil {
+		return "", err
+	}
+
+	req := map[string]string{
+		"component_appid":  ctx.AppID,
+		"authorizer_appid": appid,
+		"option_name":      optionName,
+	}
+	body, err := util.PostJSON(fmt.Sprintf(getAuthorizerOptionURL, cat), req)
+	if err != nil {
+		return "", err
+	}
+
+	var ret struct {
+		util.CommonError
+		AuthorizerAppID string `json:"authorizer_appid"`
+		OptionName      string `json:"option_name"`
+		OptionValue     string `json:"option_value"`
+	}
+	if err := util.DecodeWithError(body, &ret, "GetAuthorizerOption"); err != nil {
+		return "", err
+	}
+	return ret.OptionValue, nil
+}
+
+// SetAuthorizerOption 设置授权方选项信息
+func (ctx *Context) SetAuthorizerOption(appid, optionName, optionValue string) error {
+	cat, err := ctx.GetComponentAccessToken()
+	if err != nil {
+		return err
+	}
+
+	req := map[string]string{
+		"component_appid":  ctx.AppID,
+		"authorizer_appid": appid,
+		"option_name":      optionName,
+		"option_value":     optionValue,
+	}
+	body, err := util.PostJSON(fmt.Sprintf(setAuthorizerOptionURL, cat), req)
+	if err != nil {
+		return err
+	}
+	return util.DecodeWithCommonError(body, "SetAuthorizerOption")
+}
@@ -0,0 +1,73 @@
+package context
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/cache"
+)
+
+// AuthorizerTokenStore 授权方令牌存储
+// authorizer_refresh_token 只在授权时下发一次，丢失后只能由授权方重新授权，生产环境应持久化保存（如数据库）
+// authorizer_access_token 有效期 2 小时，可以保存在缓存中
+type AuthorizerTokenStore interface {
+	// GetRefreshToken 获取授权方的 authorizer_refresh_token，不存在时返回空字符串
+	GetRefreshToken(appID string) (string, error)
+	// SetRefreshToken 保存授权方的 authorizer_refresh_token
+	SetRefreshToken(appID, refreshToken string) error
+	// GetAccessToken 获取授权方的 authorizer_access_token，不存在或已过期时返回空字符串
+	GetAccessToken(appID string) (string, error)
+	// SetAccessToken 保存授权方的 authorizer_access_token
+	SetAccessToken(appID, accessToken string, expires time.Duration) error
+	// Delete 授权方取消授权时删除保存的令牌
+	Delete(appID string) error
+}
+
+// CacheAuthorizerTokenStore 使用 cache.Cache 保存授权方令牌，未设置 AuthorizerTokenStore 时默认使用
+type CacheAuthorizerTokenStore struct {
+	cache cache.Cache
+}
+
+// NewCacheAuthorizerTokenStore new CacheAuthorizerTokenStore
+func NewCacheAuthorizerTokenStore(cache cache.Cache) *CacheAuthorizerTokenStore {
+	return &CacheAuthorizerTokenStore{cache: cache}
+}
+
+func refreshTokenCacheKey(appID string) string {
+	return fmt.Sprintf("authorizer_refresh_token_%s", appID)
+}
+
+func authrAccessTokenCacheKey(appID string) string {
+	return fmt.Sprintf("authorizer_access_token_%s", appID)
+}
+
+// GetRefreshToken 获取授权方的 authorizer_refresh_token
+func (store *CacheAuthorizerTokenStore) GetRefreshToken(appID string) (string, error) {
+	val, _ := store.cache.Get(refreshTokenCacheKey(appID)).(string)
+	return val, nil
+}
+
+// SetRefreshToken 保存授权方的 authorizer_refresh_token
+// authorizer_refresh_token 在授权方取消授权前一直有效，保存时不设置过期时间，取消授权时由 Delete 删除
+func (store *CacheAuthorizerTokenStore) SetRefreshToken(appID, refreshToken string) error {
+	return store.cache.Set(refreshTokenCacheKey(appID), refreshToken, 0)
+}
+
+// GetAccessToken 获取授权方的 authorizer_access_token
+func (store *CacheAuthorizerTokenStore) GetAccessToken(appID string) (string, error) {
+	val, _ := store.cache.Get(authrAccessTokenCacheKey(appID)).(string)
+	return val, nil
+}
+
+// SetAccessToken 保存授权方的 authorizer_access_token
+func (store *CacheAuthorizerTokenStore) SetAccessToken(appID, accessToken string, expires time.Duration) error {
+	return store.cache.Set(authrAccessTokenCacheKey(appID), accessToken, expires)
+}
+
+// Delete 删除授权方的令牌
+func (store *CacheAuthorizerTokenStore) Delete(appID string) error {
+	if err := store.cache.Delete(authrAccessTokenCacheKey(appID)); err != nil {
+		return err
+	}
+	return store.cache.Delete(refreshTokenCacheKey(appID))
+}
@@ -0,0 +1,114 @@
+package context
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func newAuthorizedTestContext() *Context {
+	ctx := newTestContext()
+	_ = ctx.Cache.Set(ctx.componentAccessTokenCacheKey(), "cat-1", time.Hour)
+	return ctx
+}
+
+func TestAuthorizerIterator(t *testing.T) {
+	defer gock.Off()
+	ctx := newAuthorizedTestContext()
+
+	pages := []map[string]interface{}{
+		{"offset": 0, "list": []string{"wx-a", "wx-b"}},
+		{"offset": 2, "list": []string{"wx-c"}},
+	}
+	for _, page := range pages {
+		list := make([]map[string]interface{}, 0)
+		for _, appID := range page["list"].([]string) {
+			list = append(list, map[string]interface{}{"authorizer_appid": appID, "refresh_token": "rt-" + appID, "auth_time": 1558000607})
+		}
+		gock.New("https://api.weixin.qq.com").
+			Post("/cgi-bin/component/api_get_authorizer_list").
+			MatchParam("component_access_token", "cat-1").
+			JSON(map[string]interface{}{"component_appid": "wx-component", "offset": page["offset"], "count": 2}).
+			Reply(200).
+			JSON(map[string]interface{}{"total_count": 3, "list": list})
+	}
+
+	var appIDs []string
+	it := ctx.NewAuthorizerIterator(2)
+	for it.Next() {
+		appIDs = append(appIDs, it.Authorizer().AuthorizerAppID)
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, []string{"wx-a", "wx-b", "wx-c"}, appIDs)
+	assert.Equal(t, 3, it.TotalCount())
+	assert.True(t, gock.IsDone())
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/component/api_get_authorizer_list").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 61003, "errmsg": "component is not authorized by this account"})
+	it = ctx.NewAuthorizerIterator(0)
+	assert.False(t, it.Next())
+	assert.Error(t, it.Err())
+}
+
+func TestAuthorizerOption(t *testing.T) {
+	defer gock.Off()
+	ctx := newAuthorizedTestContext()
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/component/api_get_authorizer_option").
+		JSON(map[string]string{"component_appid": "wx-component", "authorizer_appid": "wx-a", "option_name": OptionVoiceRecognize}).
+		Reply(200).
+		JSON(map[string]interface{}{"authorizer_appid": "wx-a", "option_name": OptionVoiceRecognize, "option_value": "1"})
+	value, err := ctx.GetAuthorizerOption("wx-a", OptionVoiceRecognize)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", value)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/component/api_set_authorizer_option").
+		JSON(map[string]string{"component_appid": "wx-component", "authorizer_appid": "wx-a", "option_name": OptionLocationReport, "option_value": "2"}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+	assert.Nil(t, ctx.SetAuthorizerOption("wx-a", OptionLocationReport, "2"))
+	assert.True(t, gock.IsDone())
+}
+
+func TestGetAuthrInfoMiniProgram(t *testing.T) {
+	defer gock.Off()
+	ctx := newAuthorizedTestContext()
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/component/api_get_authorizer_info").
+		Reply(200).
+		BodyString(`{
+			"authorizer_info": {
+				"nick_name": "小程序",
+				"service_type_info": {"id": 0},
+				"verify_type_info": {"id": -1},
+				"user_name": "gh_eb5e3a772040",
+				"business_info": {"open_store": 0, "open_scan": 0, "open_pay": 1, "open_card": 0, "open_shake": 0},
+				"MiniProgramInfo": {
+					"network": {"RequestDomain": ["https://www.qq.com"], "WsRequestDomain": [], "UploadDomain": [], "DownloadDomain": [], "BizDomain": [], "UDPDomain": []},
+					"categories": [{"first": "生活服务", "second": "丽人服务"}],
+					"visit_status": 0
+				},
+				"basic_config": {"is_phone_configured": true, "is_email_configured": true}
+			},
+			"authorization_info": {
+				"authorizer_appid": "wx-a",
+				"authorizer_refresh_token": "refresh-a",
+				"func_info": [{"funcscope_category": {"id": 17}, "confirm_info": {"need_confirm": 0, "already_confirm": 0, "can_confirm": 0}}]
+			}
+		}`)
+	info, authInfo, err := ctx.GetAuthrInfo("wx-a")
+	assert.Nil(t, err)
+	assert.True(t, info.IsMiniProgram())
+	assert.Equal(t, 1, info.BusinessInfo.OpenPay)
+	assert.Equal(t, []string{"https://www.qq.com"}, info.MiniProgramInfo.Network.RequestDomain)
+	assert.Equal(t, "丽人服务", info.MiniProgramInfo.Categories[0].Second)
+	assert.Equal(t, "refresh-a", authInfo.RefreshToken)
+	assert.Equal(t, 17, authInfo.FuncInfo[0].FuncscopeCategory.ID)
+}
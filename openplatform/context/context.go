@@ -1,10 +1,46 @@
 package context
 
 import (
+	"sync"
+
 	"github.com/kuro-liang/wechat-go/openplatform/config"
 )
 
 // Context struct
 type Context struct {
 	*config.Config
+
+	// componentAccessTokenLock 防止并发获取 component_access_token 时重复请求微信服务器
+	componentAccessTokenLock sync.Mutex
+
+	authorizerTokenStore AuthorizerTokenStore
+	// authorizerTokenLocks 按授权方 appid 保存的 *sync.Mutex，防止并发刷新同一授权方的令牌
+	authorizerTokenLocks sync.Map
+}
+
+// SetAuthorizerTokenStore 设置授权方令牌存储，未设置时使用 Cache 保存
+func (ctx *Context) SetAuthorizerTokenStore(store AuthorizerTokenStore) {
+	ctx.authorizerTokenStore = store
+}
+
+// GetAuthorizerTokenStore 获取授权方令牌存储
+func (ctx *Context) GetAuthorizerTokenStore() AuthorizerTokenStore {
+	if ctx.authorizerTokenStore == nil {
+		return NewCacheAuthorizerTokenStore(ctx.Cache)
+	}
+	return ctx.authorizerTokenStore
+}
+
+// DeleteAuthorizer 授权方取消授权时删除保存的令牌
+// 持有刷新令牌使用的锁，等待正在进行的刷新完成后再删除，以免刷新结果在删除后写回
+func (ctx *Context) DeleteAuthorizer(appID string) error {
+	lock := ctx.authorizerTokenLock(appID)
+	lock.Lock()
+	defer lock.Unlock()
+	return ctx.GetAuthorizerTokenStore().Delete(appID)
+}
+
+func (ctx *Context) authorizerTokenLock(appID string) *sync.Mutex {
+	lock, _ := ctx.authorizerTokenLocks.LoadOrStore(appID, new(sync.Mutex))
+	return lock.(*sync.Mutex)
 }
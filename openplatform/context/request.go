@@ -0,0 +1,77 @@
+package context
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// APIError 接口返回了非 0 的错误码，可使用 errors.As 取出 errcode 转换为具体的错误
+type APIError struct {
+	util.CommonError
+	APIName string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s Error , errcode=%d , errmsg=%s", e.APIName, e.ErrCode, e.ErrMsg)
+}
+
+// AuthrPost 代授权方调用 POST 接口，urlFormat 中的 %s 替换为授权方的 authorizer_access_token，请求体为 req 的 JSON
+// ret 为 *util.CommonError 或内嵌 util.CommonError 的结构体指针，错误码非 0 时返回 *APIError
+func (ctx *Context) AuthrPost(appID, urlFormat string, req interface{}, ret interface{}, apiName string) error {
+	ak, err := ctx.GetAuthrAccessToken(appID)
+	if err != nil {
+		return err
+	}
+	data, err := util.PostJSON(fmt.Sprintf(urlFormat, ak), req)
+	if err != nil {
+		return err
+	}
+	return decode(data, ret, apiName)
+}
+
+// AuthrGet 代授权方调用 GET 接口，其他同 AuthrPost
+func (ctx *Context) AuthrGet(appID, urlFormat string, ret interface{}, apiName string) error {
+	ak, err := ctx.GetAuthrAccessToken(appID)
+	if err != nil {
+		return err
+	}
+	data, err := util.HTTPGet(fmt.Sprintf(urlFormat, ak))
+	if err != nil {
+		return err
+	}
+	return decode(data, ret, apiName)
+}
+
+func decode(data []byte, ret interface{}, apiName string) error {
+	if err := json.Unmarshal(data, ret); err != nil {
+		return fmt.Errorf("json Unmarshal Error, err=%v", err)
+	}
+	commErr, err := commonError(ret)
+	if err != nil {
+		return err
+	}
+	if commErr.ErrCode != 0 {
+		return &APIError{CommonError: commErr, APIName: apiName}
+	}
+	return nil
+}
+
+// commonError 取出返回值中的错误码
+func commonError(ret interface{}) (util.CommonError, error) {
+	if commErr, ok := ret.(*util.CommonError); ok {
+		return *commErr, nil
+	}
+	v := reflect.ValueOf(ret)
+	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+		if field := v.Elem().FieldByName("CommonError"); field.IsValid() {
+			if commErr, ok := field.Interface().(util.CommonError); ok {
+				return commErr, nil
+			}
+		}
+	}
+	return util.CommonError{}, errors.New("commonError is invalid or not struct")
+}
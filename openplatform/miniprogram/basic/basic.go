@@ -8,7 +8,19 @@ import (
 )
 
 const (
-	getAccountBasicInfoURL = "https://api.weixin.qq.com/cgi-bin/account/getaccountbasicinfo"
+	getAccountBasicInfoURL   = "https://api.weixin.qq.com/cgi-bin/account/getaccountbasicinfo"
+	setNicknameURL           = "https://api.weixin.qq.com/wxa/setnickname?access_token=%s"
+	queryNicknameURL         = "https://api.weixin.qq.com/wxa/api_wxa_querynickname?access_token=%s"
+	checkWxVerifyNicknameURL = "https://api.weixin.qq.com/cgi-bin/wxverify/checkwxverifynickname?access_token=%s"
+	modifyHeadImageURL       = "https://api.weixin.qq.com/cgi-bin/account/modifyheadimage?access_token=%s"
+	modifySignatureURL       = "https://api.weixin.qq.com/cgi-bin/account/modifysignature?access_token=%s"
+)
+
+// 名称审核状态
+const (
+	NicknameAuditing = 1 // 审核中
+	NicknameRejected = 2 // 审核失败
+	NicknameApproved = 3 // 审核成功
 )
 
 // Basic 基础信息设置
@@ -22,16 +34,49 @@ func NewBasic(opContext *openContext.Context, appID string) *Basic {
 	return &Basic{Context: opContext, appID: appID}
 }
 
+// ModifyInfo 名称、头像或简介的修改额度
+type ModifyInfo struct {
+	ModifyUsedCount int `json:"modify_used_count"`
+	ModifyQuota     int `json:"modify_quota"`
+}
+
 // AccountBasicInfo 基础信息
 type AccountBasicInfo struct {
 	util.CommonError
+	AppID          string `json:"appid"`
+	AccountType    int    `json:"account_type"`   // 帐号类型，1 订阅号，2 服务号，3 小程序
+	PrincipalType  int    `json:"principal_type"` // 主体类型
+	PrincipalName  string `json:"principal_name"`
+	Credential     string `json:"credential"`
+	RealnameStatus int    `json:"realname_status"` // 实名验证状态，1 实名验证成功，2 实名验证中，3 实名验证失败
+	WxVerifyInfo   struct {
+		QualificationVerify   bool  `json:"qualification_verify"`
+		NamingVerify          bool  `json:"naming_verify"`
+		AnnualReview          bool  `json:"annual_review"`
+		AnnualReviewBeginTime int64 `json:"annual_review_begin_time"`
+		AnnualReviewEndTime   int64 `json:"annual_review_end_time"`
+	} `json:"wx_verify_info"`
+	SignatureInfo struct {
+		ModifyInfo
+		Signature string `json:"signature"`
+	} `json:"signature_info"`
+	HeadImageInfo struct {
+		ModifyInfo
+		HeadImageURL string `json:"head_image_url"`
+	} `json:"head_image_info"`
+	NicknameInfo struct {
+		ModifyInfo
+		Nickname string `json:"nickname"`
+	} `json:"nickname_info"`
+	RegisteredCountry int    `json:"registered_country"`
+	Nickname          string `json:"nickname"`
 }
 
 // GetAccountBasicInfo 获取小程序基础信息
 //
 //reference:https://developers.weixin.qq.com/doc/oplatform/Third-party_Platforms/Mini_Programs/Mini_Program_Information_Settings.html
 func (basic *Basic) GetAccountBasicInfo() (*AccountBasicInfo, error) {
-	ak, err := basic.GetAuthrAccessToken(basic.AppID)
+	ak, err := basic.GetAuthrAccessToken(basic.appID)
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +92,90 @@ func (basic *Basic) GetAccountBasicInfo() (*AccountBasicInfo, error) {
 	return result, nil
 }
 
-// modify_domain设置服务器域名
-// TODO
-// func (encryptor *Basic) modifyDomain() {
-// }
+// SetNicknameParam 设置名称参数，证明材料均为临时素材的 media_id
+type SetNicknameParam struct {
+	NickName          string `json:"nick_name"`
+	IDCard            string `json:"id_card,omitempty"` // 身份证照片，个人号必填
+	License           string `json:"license,omitempty"` // 组织机构代码证或营业执照，组织号必填
+	NamingOtherStuff1 string `json:"naming_other_stuff_1,omitempty"`
+	NamingOtherStuff2 string `json:"naming_other_stuff_2,omitempty"`
+	NamingOtherStuff3 string `json:"naming_other_stuff_3,omitempty"`
+	NamingOtherStuff4 string `json:"naming_other_stuff_4,omitempty"`
+	NamingOtherStuff5 string `json:"naming_other_stuff_5,omitempty"`
+}
+
+// SetNicknameResult 设置名称结果
+type SetNicknameResult struct {
+	Wording string `json:"wording"`  // 材料说明
+	AuditID int64  `json:"audit_id"` // 需要审核时返回审核单 id，为 0 时表示名称已直接设置成功
+}
+
+// SetNickname 设置小程序名称，需要审核时结果通过 wxa_nickname_audit 事件推送，也可使用 QueryNickname 查询
+func (basic *Basic) SetNickname(param *SetNicknameParam) (*SetNicknameResult, error) {
+	var ret struct {
+		util.CommonError
+		SetNicknameResult
+	}
+	if err := basic.AuthrPost(basic.appID, setNicknameURL, param, &ret, "wxa/setnickname"); err != nil {
+		return nil, err
+	}
+	return &ret.SetNicknameResult, nil
+}
+
+// NicknameAudit 名称审核结果
+type NicknameAudit struct {
+	Nickname   string `json:"nickname"`
+	AuditStat  int    `json:"audit_stat"`
+	FailReason string `json:"fail_reason"`
+	CreateTime int64  `json:"create_time"`
+	AuditTime  int64  `json:"audit_time"`
+}
+
+// QueryNickname 查询名称的审核状态
+func (basic *Basic) QueryNickname(auditID int64) (*NicknameAudit, error) {
+	var ret struct {
+		util.CommonError
+		NicknameAudit
+	}
+	if err := basic.AuthrPost(basic.appID, queryNicknameURL, map[string]int64{"audit_id": auditID}, &ret, "wxa/api_wxa_querynickname"); err != nil {
+		return nil, err
+	}
+	return &ret.NicknameAudit, nil
+}
+
+// CheckNicknameResult 名称检测结果
+type CheckNicknameResult struct {
+	HitCondition bool   `json:"hit_condition"` // 是否命中关键字策略，命中时需要提交证明材料
+	Wording      string `json:"wording"`
+}
+
+// CheckWxVerifyNickname 微信认证名称检测
+func (basic *Basic) CheckWxVerifyNickname(nickname string) (*CheckNicknameResult, error) {
+	var ret struct {
+		util.CommonError
+		CheckNicknameResult
+	}
+	if err := basic.AuthrPost(basic.appID, checkWxVerifyNicknameURL, map[string]string{"nick_name": nickname}, &ret, "wxverify/checkwxverifynickname"); err != nil {
+		return nil, err
+	}
+	return &ret.CheckNicknameResult, nil
+}
+
+// ModifyHeadImageParam 修改头像参数，x1、y1、x2、y2 为裁剪框的左上角及右下角坐标，取值 0 到 1
+type ModifyHeadImageParam struct {
+	HeadImgMediaID string  `json:"head_img_media_id"`
+	X1             float64 `json:"x1"`
+	Y1             float64 `json:"y1"`
+	X2             float64 `json:"x2"`
+	Y2             float64 `json:"y2"`
+}
+
+// ModifyHeadImage 修改头像
+func (basic *Basic) ModifyHeadImage(param *ModifyHeadImageParam) error {
+	return basic.AuthrPost(basic.appID, modifyHeadImageURL, param, &util.CommonError{}, "account/modifyheadimage")
+}
+
+// ModifySignature 修改功能介绍
+func (basic *Basic) ModifySignature(signature string) error {
+	return basic.AuthrPost(basic.appID, modifySignatureURL, map[string]string{"signature": signature}, &util.CommonError{}, "account/modifysignature")
+}
@@ -0,0 +1,111 @@
+package basic
+
+import (
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/openplatform/optest"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func newTestBasic() *Basic {
+	return NewBasic(optest.NewContext(map[string]string{"wx-mini": "authr-1"}), "wx-mini")
+}
+
+func TestNickname(t *testing.T) {
+	defer gock.Off()
+	basic := newTestBasic()
+
+	gock.New("https://api.weixin.qq.com").
+		Get("/cgi-bin/account/getaccountbasicinfo").
+		MatchParam("access_token", "authr-1").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "appid": "wx-mini", "account_type": 3, "nickname_info": map[string]interface{}{"nickname": "旧名称", "modify_used_count": 1, "modify_quota": 2}})
+	info, err := basic.GetAccountBasicInfo()
+	assert.Nil(t, err)
+	assert.Equal(t, "旧名称", info.NicknameInfo.Nickname)
+	assert.Equal(t, 2, info.NicknameInfo.ModifyQuota)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/setnickname").
+		JSON(map[string]string{"nick_name": "新名称", "license": "media-1"}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "wording": "", "audit_id": 8888})
+	result, err := basic.SetNickname(&SetNicknameParam{NickName: "新名称", License: "media-1"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(8888), result.AuditID)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/api_wxa_querynickname").
+		JSON(map[string]int64{"audit_id": 8888}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "nickname": "新名称", "audit_stat": 2, "fail_reason": "材料不清晰"})
+	audit, err := basic.QueryNickname(result.AuditID)
+	assert.Nil(t, err)
+	assert.Equal(t, NicknameRejected, audit.AuditStat)
+	assert.Equal(t, "材料不清晰", audit.FailReason)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/account/modifysignature").
+		JSON(map[string]string{"signature": "功能介绍"}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 53200, "errmsg": "本月功能介绍修改次数已用完"})
+	assert.EqualError(t, basic.ModifySignature("功能介绍"), "account/modifysignature Error , errcode=53200 , errmsg=本月功能介绍修改次数已用完")
+	assert.True(t, gock.IsDone())
+}
+
+func TestCategoryAndTester(t *testing.T) {
+	defer gock.Off()
+	basic := newTestBasic()
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/wxopen/getcategoriesbytype").
+		JSON(map[string]int{"verify_type": 0}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "categories_list": map[string]interface{}{"categories": []map[string]interface{}{{"id": 1, "name": "快递业与邮政", "level": 1, "children": []int{2}}}}})
+	categories, err := basic.GetCategoriesByType(0)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{2}, categories[0].Children)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/wxopen/addcategory").
+		JSON(map[string]interface{}{"categories": []map[string]interface{}{{"first": 1, "second": 2, "certicates": []map[string]string{{"key": "营业执照", "value": "media-1"}}}}}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+	assert.Nil(t, basic.AddCategory([]AddCategoryItem{{First: 1, Second: 2, Certicates: []Certificate{{Key: "营业执照", Value: "media-1"}}}}))
+
+	gock.New("https://api.weixin.qq.com").
+		Get("/cgi-bin/wxopen/getcategory").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "categories": []map[string]interface{}{{"first": 1, "second": 2, "audit_status": 1}}, "limit": 5, "quota": 4, "category_limit": 5})
+	set, err := basic.GetCategory()
+	assert.Nil(t, err)
+	assert.Equal(t, CategoryAuditing, set.Categories[0].AuditStatus)
+	assert.Equal(t, 4, set.Quota)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/bind_tester").
+		JSON(map[string]string{"wechatid": "tester"}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "userstr": "xxxxxxxxx"})
+	userStr, err := basic.BindTester("tester")
+	assert.Nil(t, err)
+	assert.Equal(t, "xxxxxxxxx", userStr)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/memberauth").
+		JSON(map[string]string{"action": "get_experiencer"}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "members": []map[string]string{{"userstr": "xxxxxxxxx"}}})
+	testers, err := basic.GetTesters()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"xxxxxxxxx"}, testers)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/unbind_tester").
+		JSON(map[string]string{"userstr": "xxxxxxxxx"}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+	assert.Nil(t, basic.UnbindTester("", "xxxxxxxxx"))
+	assert.True(t, gock.IsDone())
+}
@@ -0,0 +1,119 @@
+package basic
+
+import (
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+const (
+	getAllCategoriesURL    = "https://api.weixin.qq.com/cgi-bin/wxopen/getallcategories?access_token=%s"
+	getCategoriesByTypeURL = "https://api.weixin.qq.com/cgi-bin/wxopen/getcategoriesbytype?access_token=%s"
+	addCategoryURL         = "https://api.weixin.qq.com/cgi-bin/wxopen/addcategory?access_token=%s"
+	deleteCategoryURL      = "https://api.weixin.qq.com/cgi-bin/wxopen/deletecategory?access_token=%s"
+	getCategoryURL         = "https://api.weixin.qq.com/cgi-bin/wxopen/getcategory?access_token=%s"
+)
+
+// 类目的审核状态
+const (
+	CategoryAuditing = 1 // 审核中
+	CategoryRejected = 2 // 审核不通过
+	CategoryApproved = 3 // 审核通过
+)
+
+// Category 可设置的类目
+type Category struct {
+	ID            int64   `json:"id"`
+	Name          string  `json:"name"`
+	Level         int     `json:"level"`
+	Father        int64   `json:"father"`
+	Children      []int64 `json:"children"`
+	SensitiveType int     `json:"sensitive_type"` // 为 1 时需要提交资质
+	Qualify       struct {
+		ExterList []struct {
+			InnerList []struct {
+				Name string `json:"name"`
+				URL  string `json:"url"`
+			} `json:"inner_list"`
+		} `json:"exter_list"`
+		Remark string `json:"remark"`
+	} `json:"qualify"`
+}
+
+type categoriesResult struct {
+	util.CommonError
+	CategoriesList struct {
+		Categories []Category `json:"categories"`
+	} `json:"categories_list"`
+}
+
+// GetAllCategories 获取可以设置的所有类目
+func (basic *Basic) GetAllCategories() ([]Category, error) {
+	var ret categoriesResult
+	if err := basic.AuthrGet(basic.appID, getAllCategoriesURL, &ret, "wxopen/getallcategories"); err != nil {
+		return nil, err
+	}
+	return ret.CategoriesList.Categories, nil
+}
+
+// GetCategoriesByType 获取不同主体类型的类目，verifyType 为 0 时表示企业主体，1 时表示个人主体
+func (basic *Basic) GetCategoriesByType(verifyType int) ([]Category, error) {
+	var ret categoriesResult
+	if err := basic.AuthrPost(basic.appID, getCategoriesByTypeURL, map[string]int{"verify_type": verifyType}, &ret, "wxopen/getcategoriesbytype"); err != nil {
+		return nil, err
+	}
+	return ret.CategoriesList.Categories, nil
+}
+
+// Certificate 类目的资质材料
+type Certificate struct {
+	Key   string `json:"key"`   // 资质名称
+	Value string `json:"value"` // 资质图片的 media_id
+}
+
+// AddCategoryItem 添加的类目
+type AddCategoryItem struct {
+	First      int64         `json:"first"`
+	Second     int64         `json:"second"`
+	Certicates []Certificate `json:"certicates"`
+}
+
+// AddCategory 添加类目
+func (basic *Basic) AddCategory(categories []AddCategoryItem) error {
+	req := map[string][]AddCategoryItem{"categories": categories}
+	return basic.AuthrPost(basic.appID, addCategoryURL, req, &util.CommonError{}, "wxopen/addcategory")
+}
+
+// DeleteCategory 删除类目
+func (basic *Basic) DeleteCategory(first, second int64) error {
+	req := map[string]int64{"first": first, "second": second}
+	return basic.AuthrPost(basic.appID, deleteCategoryURL, req, &util.CommonError{}, "wxopen/deletecategory")
+}
+
+// SetCategory 已设置的类目
+type SetCategory struct {
+	First       int64  `json:"first"`
+	FirstName   string `json:"first_name"`
+	Second      int64  `json:"second"`
+	SecondName  string `json:"second_name"`
+	AuditStatus int    `json:"audit_status"`
+	AuditReason string `json:"audit_reason"`
+}
+
+// Categories 已设置的类目及额度
+type Categories struct {
+	Categories    []SetCategory `json:"categories"`
+	Limit         int           `json:"limit"`          // 一个更改周期内可以添加类目的次数
+	Quota         int           `json:"quota"`          // 本更改周期内还可以添加类目的次数
+	CategoryLimit int           `json:"category_limit"` // 最多可以设置的类目数量
+}
+
+// GetCategory 获取已设置的所有类目
+func (basic *Basic) GetCategory() (*Categories, error) {
+	var ret struct {
+		util.CommonError
+		Categories
+	}
+	if err := basic.AuthrGet(basic.appID, getCategoryURL, &ret, "wxopen/getcategory"); err != nil {
+		return nil, err
+	}
+	return &ret.Categories, nil
+}
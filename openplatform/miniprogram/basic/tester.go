@@ -0,0 +1,53 @@
+package basic
+
+import (
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+const (
+	bindTesterURL   = "https://api.weixin.qq.com/wxa/bind_tester?access_token=%s"
+	unbindTesterURL = "https://api.weixin.qq.com/wxa/unbind_tester?access_token=%s"
+	memberAuthURL   = "https://api.weixin.qq.com/wxa/memberauth?access_token=%s"
+)
+
+// BindTester 绑定体验者，返回人员对应的唯一字符串
+func (basic *Basic) BindTester(wechatID string) (string, error) {
+	var ret struct {
+		util.CommonError
+		UserStr string `json:"userstr"`
+	}
+	if err := basic.AuthrPost(basic.appID, bindTesterURL, map[string]string{"wechatid": wechatID}, &ret, "wxa/bind_tester"); err != nil {
+		return "", err
+	}
+	return ret.UserStr, nil
+}
+
+// UnbindTester 解除绑定体验者，wechatID 与 userStr 二选一
+func (basic *Basic) UnbindTester(wechatID, userStr string) error {
+	req := map[string]string{}
+	if wechatID != "" {
+		req["wechatid"] = wechatID
+	}
+	if userStr != "" {
+		req["userstr"] = userStr
+	}
+	return basic.AuthrPost(basic.appID, unbindTesterURL, req, &util.CommonError{}, "wxa/unbind_tester")
+}
+
+// GetTesters 获取体验者列表，返回人员对应的唯一字符串
+func (basic *Basic) GetTesters() ([]string, error) {
+	var ret struct {
+		util.CommonError
+		Members []struct {
+			UserStr string `json:"userstr"`
+		} `json:"members"`
+	}
+	if err := basic.AuthrPost(basic.appID, memberAuthURL, map[string]string{"action": "get_experiencer"}, &ret, "wxa/memberauth"); err != nil {
+		return nil, err
+	}
+	userStrs := make([]string, 0, len(ret.Members))
+	for _, member := range ret.Members {
+		userStrs = append(userStrs, member.UserStr)
+	}
+	return userStrs, nil
+}
@@ -0,0 +1,47 @@
+package miniprogram
+
+import (
+	"sync"
+
+	openContext "github.com/kuro-liang/wechat-go/openplatform/context"
+)
+
+// defaultBatchConcurrency 批量操作的默认并发数
+const defaultBatchConcurrency = 10
+
+// Batch 对多个授权的小程序执行同一操作，如批量设置服务器域名：
+//
+//	errs := miniprogram.Batch(opCtx, appIDs, 0, func(mp *miniprogram.MiniProgram) error {
+//		_, err := mp.GetDomain().ModifyDomain(domain.ActionSet, serverDomain)
+//		return err
+//	})
+//
+// concurrency 为最大并发数，为 0 时使用 10；返回执行失败的小程序 appid 及对应的错误
+func Batch(opCtx *openContext.Context, appIDs []string, concurrency int, fn func(miniProgram *MiniProgram) error) map[string]error {
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make(map[string]error)
+		sem  = make(chan struct{}, concurrency)
+	)
+	for _, appID := range appIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(appID string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := fn(NewMiniProgram(opCtx, appID)); err != nil {
+				mu.Lock()
+				errs[appID] = err
+				mu.Unlock()
+			}
+		}(appID)
+	}
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,32 @@
+package miniprogram
+
+import (
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/openplatform/miniprogram/domain"
+	"github.com/kuro-liang/wechat-go/openplatform/optest"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestBatch(t *testing.T) {
+	defer gock.Off()
+	ctx := optest.NewContext(map[string]string{"wx-a": "authr-a", "wx-b": "authr-b"})
+
+	for _, token := range []string{"authr-a", "authr-b"} {
+		gock.New("https://api.weixin.qq.com").
+			Post("/wxa/setwebviewdomain").
+			MatchParam("access_token", token).
+			Reply(200).
+			JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+	}
+
+	// wx-c 未授权，获取 access_token 失败
+	errs := Batch(ctx, []string{"wx-a", "wx-b", "wx-c"}, 2, func(mp *MiniProgram) error {
+		_, err := mp.GetDomain().SetWebviewDomain(domain.ActionSet, []string{"https://www.qq.com"})
+		return err
+	})
+	assert.Len(t, errs, 1)
+	assert.Error(t, errs["wx-c"])
+	assert.True(t, gock.IsDone())
+}
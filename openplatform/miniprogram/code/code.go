@@ -0,0 +1,223 @@
+// Package code 第三方平台代小程序实现代码管理
+package code
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+
+	openContext "github.com/kuro-liang/wechat-go/openplatform/context"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+const (
+	commitURL               = "https://api.weixin.qq.com/wxa/commit?access_token=%s"
+	getPageURL              = "https://api.weixin.qq.com/wxa/get_page?access_token=%s"
+	getQrcodeURL            = "https://api.weixin.qq.com/wxa/get_qrcode?access_token=%s"
+	submitAuditURL          = "https://api.weixin.qq.com/wxa/submit_audit?access_token=%s"
+	getAuditStatusURL       = "https://api.weixin.qq.com/wxa/get_auditstatus?access_token=%s"
+	getLatestAuditStatusURL = "https://api.weixin.qq.com/wxa/get_latest_auditstatus?access_token=%s"
+	undoCodeAuditURL        = "https://api.weixin.qq.com/wxa/undocodeaudit?access_token=%s"
+	releaseURL              = "https://api.weixin.qq.com/wxa/release?access_token=%s"
+	revertCodeReleaseURL    = "https://api.weixin.qq.com/wxa/revertcoderelease?access_token=%s"
+	grayReleaseURL          = "https://api.weixin.qq.com/wxa/grayrelease?access_token=%s"
+	getGrayReleasePlanURL   = "https://api.weixin.qq.com/wxa/getgrayreleaseplan?access_token=%s"
+	revertGrayReleaseURL    = "https://api.weixin.qq.com/wxa/revertgrayrelease?access_token=%s"
+	getTemplateDraftListURL = "https://api.weixin.qq.com/wxa/gettemplatedraftlist?access_token=%s"
+	addToTemplateURL        = "https://api.weixin.qq.com/wxa/addtotemplate?access_token=%s"
+	getTemplateListURL      = "https://api.weixin.qq.com/wxa/gettemplatelist?access_token=%s"
+	deleteTemplateURL       = "https://api.weixin.qq.com/wxa/deletetemplate?access_token=%s"
+)
+
+// 审核状态
+const (
+	AuditStatusSuccess  = 0 // 审核成功
+	AuditStatusRejected = 1 // 审核被拒绝
+	AuditStatusAuditing = 2 // 审核中
+	AuditStatusWithdrew = 3 // 已撤回
+	AuditStatusDelayed  = 4 // 审核延后
+)
+
+// Code 代小程序实现代码管理
+// reference: https://developers.weixin.qq.com/doc/oplatform/Third-party_Platforms/2.0/api/code/commit.html
+type Code struct {
+	*openContext.Context
+	appID string
+}
+
+// NewCode new
+func NewCode(opContext *openContext.Context, appID string) *Code {
+	return &Code{Context: opContext, appID: appID}
+}
+
+// CommitParam 上传代码参数
+type CommitParam struct {
+	TemplateID  int64  `json:"template_id"`  // 代码库中的代码模板 ID
+	ExtJSON     string `json:"ext_json"`     // 第三方自定义的配置，JSON 字符串
+	UserVersion string `json:"user_version"` // 代码版本号
+	UserDesc    string `json:"user_desc"`    // 代码描述
+}
+
+// Commit 上传小程序代码并生成体验版
+func (code *Code) Commit(param *CommitParam) error {
+	return code.AuthrPost(code.appID, commitURL, param, &util.CommonError{}, "wxa/commit")
+}
+
+// GetPage 获取已上传的代码的页面列表
+func (code *Code) GetPage() ([]string, error) {
+	var ret struct {
+		util.CommonError
+		PageList []string `json:"page_list"`
+	}
+	if err := code.AuthrGet(code.appID, getPageURL, &ret, "wxa/get_page"); err != nil {
+		return nil, err
+	}
+	return ret.PageList, nil
+}
+
+// GetQrcode 获取体验版二维码，path 为体验版打开的页面，为空时打开首页
+func (code *Code) GetQrcode(path string) ([]byte, error) {
+	ak, err := code.GetAuthrAccessToken(code.appID)
+	if err != nil {
+		return nil, err
+	}
+	uri := fmt.Sprintf(getQrcodeURL, ak)
+	if path != "" {
+		uri += "&path=" + url.QueryEscape(path)
+	}
+	data, err := util.HTTPGet(uri)
+	if err != nil {
+		return nil, err
+	}
+	// 失败时返回 JSON，成功时返回图片
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		if err := util.DecodeWithCommonError(data, "wxa/get_qrcode"); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// AuditItem 审核项
+type AuditItem struct {
+	Address     string `json:"address,omitempty"`      // 小程序的页面
+	Tag         string `json:"tag,omitempty"`          // 小程序的标签，用空格分隔
+	FirstClass  string `json:"first_class,omitempty"`  // 一级类目名称
+	SecondClass string `json:"second_class,omitempty"` // 二级类目名称
+	ThirdClass  string `json:"third_class,omitempty"`  // 三级类目名称
+	FirstID     int64  `json:"first_id,omitempty"`     // 一级类目的 ID
+	SecondID    int64  `json:"second_id,omitempty"`    // 二级类目的 ID
+	ThirdID     int64  `json:"third_id,omitempty"`     // 三级类目的 ID
+	Title       string `json:"title,omitempty"`        // 小程序页面的标题
+}
+
+// PreviewInfo 预览信息
+type PreviewInfo struct {
+	VideoIDList []string `json:"video_id_list,omitempty"`
+	PicIDList   []string `json:"pic_id_list,omitempty"`
+}
+
+// SubmitAuditParam 提交审核参数
+type SubmitAuditParam struct {
+	ItemList      []AuditItem  `json:"item_list,omitempty"`
+	PreviewInfo   *PreviewInfo `json:"preview_info,omitempty"`
+	VersionDesc   string       `json:"version_desc,omitempty"`   // 小程序版本说明和功能解释
+	FeedbackInfo  string       `json:"feedback_info,omitempty"`  // 反馈内容
+	FeedbackStuff string       `json:"feedback_stuff,omitempty"` // 用 | 分割的 media_id 列表
+}
+
+// SubmitAudit 提交审核，返回审核编号
+func (code *Code) SubmitAudit(param *SubmitAuditParam) (int64, error) {
+	var ret struct {
+		util.CommonError
+		AuditID int64 `json:"auditid"`
+	}
+	if err := code.AuthrPost(code.appID, submitAuditURL, param, &ret, "wxa/submit_audit"); err != nil {
+		return 0, err
+	}
+	return ret.AuditID, nil
+}
+
+// AuditStatus 审核状态
+type AuditStatus struct {
+	AuditID    int64  `json:"auditid,omitempty"`
+	Status     int    `json:"status"`
+	Reason     string `json:"reason"`
+	ScreenShot string `json:"screenshot"` // 审核不通过的截图示例，用 | 分隔的 media_id 列表
+}
+
+// GetAuditStatus 查询审核单状态
+func (code *Code) GetAuditStatus(auditID int64) (*AuditStatus, error) {
+	var ret struct {
+		util.CommonError
+		AuditStatus
+	}
+	req := map[string]int64{"auditid": auditID}
+	if err := code.AuthrPost(code.appID, getAuditStatusURL, req, &ret, "wxa/get_auditstatus"); err != nil {
+		return nil, err
+	}
+	ret.AuditID = auditID
+	return &ret.AuditStatus, nil
+}
+
+// GetLatestAuditStatus 查询最新一次审核单状态
+func (code *Code) GetLatestAuditStatus() (*AuditStatus, error) {
+	var ret struct {
+		util.CommonError
+		AuditStatus
+		// 该接口返回的截图字段为 ScreenShot
+		LatestScreenShot string `json:"ScreenShot"`
+	}
+	if err := code.AuthrGet(code.appID, getLatestAuditStatusURL, &ret, "wxa/get_latest_auditstatus"); err != nil {
+		return nil, err
+	}
+	if ret.ScreenShot == "" {
+		ret.ScreenShot = ret.LatestScreenShot
+	}
+	return &ret.AuditStatus, nil
+}
+
+// UndoCodeAudit 撤回审核，单个帐号每天只能撤回一次
+func (code *Code) UndoCodeAudit() error {
+	return code.AuthrGet(code.appID, undoCodeAuditURL, &util.CommonError{}, "wxa/undocodeaudit")
+}
+
+// Release 发布已通过审核的小程序
+func (code *Code) Release() error {
+	return code.AuthrPost(code.appID, releaseURL, map[string]string{}, &util.CommonError{}, "wxa/release")
+}
+
+// RevertCodeRelease 版本回退，回退到上一个线上版本
+func (code *Code) RevertCodeRelease() error {
+	return code.AuthrGet(code.appID, revertCodeReleaseURL, &util.CommonError{}, "wxa/revertcoderelease")
+}
+
+// GrayRelease 分阶段发布，grayPercentage 为灰度的百分比，1 到 100 的整数
+func (code *Code) GrayRelease(grayPercentage int) error {
+	req := map[string]int{"gray_percentage": grayPercentage}
+	return code.AuthrPost(code.appID, grayReleaseURL, req, &util.CommonError{}, "wxa/grayrelease")
+}
+
+// GrayReleasePlan 分阶段发布详情
+type GrayReleasePlan struct {
+	Status          int   `json:"status"` // 0 初始状态，1 执行中，2 暂停中，3 执行完毕，4 被删除
+	CreateTimestamp int64 `json:"create_timestamp"`
+	GrayPercentage  int   `json:"gray_percentage"`
+}
+
+// GetGrayReleasePlan 查询当前分阶段发布详情
+func (code *Code) GetGrayReleasePlan() (*GrayReleasePlan, error) {
+	var ret struct {
+		util.CommonError
+		GrayReleasePlan GrayReleasePlan `json:"gray_release_plan"`
+	}
+	if err := code.AuthrGet(code.appID, getGrayReleasePlanURL, &ret, "wxa/getgrayreleaseplan"); err != nil {
+		return nil, err
+	}
+	return &ret.GrayReleasePlan, nil
+}
+
+// RevertGrayRelease 取消分阶段发布
+func (code *Code) RevertGrayRelease() error {
+	return code.AuthrGet(code.appID, revertGrayReleaseURL, &util.CommonError{}, "wxa/revertgrayrelease")
+}
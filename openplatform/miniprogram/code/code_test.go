@@ -0,0 +1,133 @@
+package code
+
+import (
+	"testing"
+
+	openContext "github.com/kuro-liang/wechat-go/openplatform/context"
+	"github.com/kuro-liang/wechat-go/openplatform/optest"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func newTestContext() *openContext.Context {
+	ctx := optest.NewContext(map[string]string{"wx-mini": "authr-1"})
+	_ = optest.SetComponentAccessToken(ctx, "cat-1")
+	return ctx
+}
+
+func TestCodeLifecycle(t *testing.T) {
+	defer gock.Off()
+	code := NewCode(newTestContext(), "wx-mini")
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/commit").
+		MatchParam("access_token", "authr-1").
+		JSON(map[string]interface{}{"template_id": 1, "ext_json": `{"extAppid":"wx-mini"}`, "user_version": "V1.0", "user_desc": "test"}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+	assert.Nil(t, code.Commit(&CommitParam{TemplateID: 1, ExtJSON: `{"extAppid":"wx-mini"}`, UserVersion: "V1.0", UserDesc: "test"}))
+
+	gock.New("https://api.weixin.qq.com").
+		Get("/wxa/get_page").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "page_list": []string{"pages/index/index"}})
+	pages, err := code.GetPage()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"pages/index/index"}, pages)
+
+	gock.New("https://api.weixin.qq.com").
+		Get("/wxa/get_qrcode").
+		MatchParam("path", "pages/index/index").
+		Reply(200).
+		SetHeader("Content-Type", "image/jpeg").
+		BodyString("jpeg")
+	qrcode, err := code.GetQrcode("pages/index/index")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("jpeg"), qrcode)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/submit_audit").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "auditid": 1234567})
+	auditID, err := code.SubmitAudit(&SubmitAuditParam{ItemList: []AuditItem{{Address: "pages/index/index", Tag: "工具"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1234567), auditID)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/get_auditstatus").
+		JSON(map[string]int64{"auditid": 1234567}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "status": 1, "reason": "帐号信息不符合规范", "screenshot": "xx|yy"})
+	status, err := code.GetAuditStatus(auditID)
+	assert.Nil(t, err)
+	assert.Equal(t, AuditStatusRejected, status.Status)
+	assert.Equal(t, "xx|yy", status.ScreenShot)
+
+	gock.New("https://api.weixin.qq.com").
+		Get("/wxa/get_latest_auditstatus").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "auditid": 1234567, "status": 0, "ScreenShot": "zz"})
+	status, err = code.GetLatestAuditStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, AuditStatusSuccess, status.Status)
+	assert.Equal(t, "zz", status.ScreenShot)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/release").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 85052, "errmsg": "app is already released"})
+	assert.EqualError(t, code.Release(), "wxa/release Error , errcode=85052 , errmsg=app is already released")
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/grayrelease").
+		JSON(map[string]int{"gray_percentage": 10}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+	assert.Nil(t, code.GrayRelease(10))
+
+	gock.New("https://api.weixin.qq.com").
+		Get("/wxa/getgrayreleaseplan").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "gray_release_plan": map[string]interface{}{"status": 1, "create_timestamp": 1517553721, "gray_percentage": 10}})
+	plan, err := code.GetGrayReleasePlan()
+	assert.Nil(t, err)
+	assert.Equal(t, 10, plan.GrayPercentage)
+	assert.True(t, gock.IsDone())
+}
+
+func TestTemplate(t *testing.T) {
+	defer gock.Off()
+	tpl := NewTemplate(newTestContext())
+
+	gock.New("https://api.weixin.qq.com").
+		Get("/wxa/gettemplatedraftlist").
+		MatchParam("access_token", "cat-1").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "draft_list": []map[string]interface{}{{"create_time": 1488965944, "user_version": "VVV", "user_desc": "AAS", "draft_id": 2}}})
+	drafts, err := tpl.GetTemplateDraftList()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), drafts[0].DraftID)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/addtotemplate").
+		JSON(map[string]int64{"draft_id": 2, "template_type": 0}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+	assert.Nil(t, tpl.AddToTemplate(2, TemplateTypeNormal))
+
+	gock.New("https://api.weixin.qq.com").
+		Get("/wxa/gettemplatelist").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "template_list": []map[string]interface{}{{"template_id": 3, "user_version": "VVV", "template_type": 0}}})
+	templates, err := tpl.GetTemplateList()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), templates[0].TemplateID)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/deletetemplate").
+		JSON(map[string]int64{"template_id": 3}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+	assert.Nil(t, tpl.DeleteTemplate(3))
+	assert.True(t, gock.IsDone())
+}
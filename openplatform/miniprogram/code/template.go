@@ -0,0 +1,101 @@
+package code
+
+import (
+	"fmt"
+
+	openContext "github.com/kuro-liang/wechat-go/openplatform/context"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// 模板类型
+const (
+	TemplateTypeNormal   = 0 // 普通模板
+	TemplateTypeStandard = 1 // 标准模板
+)
+
+// Template 代码模板库管理，使用 component_access_token 调用
+// reference: https://developers.weixin.qq.com/doc/oplatform/Third-party_Platforms/2.0/api/ThirdParty/code_template/gettemplatedraftlist.html
+type Template struct {
+	*openContext.Context
+}
+
+// NewTemplate new
+func NewTemplate(opContext *openContext.Context) *Template {
+	return &Template{opContext}
+}
+
+// Draft 草稿箱中的草稿
+type Draft struct {
+	DraftID     int64  `json:"draft_id"`
+	UserVersion string `json:"user_version"`
+	UserDesc    string `json:"user_desc"`
+	CreateTime  int64  `json:"create_time"`
+}
+
+// GetTemplateDraftList 获取代码草稿列表
+func (tpl *Template) GetTemplateDraftList() ([]Draft, error) {
+	var ret struct {
+		util.CommonError
+		DraftList []Draft `json:"draft_list"`
+	}
+	if err := tpl.get(getTemplateDraftListURL, &ret, "wxa/gettemplatedraftlist"); err != nil {
+		return nil, err
+	}
+	return ret.DraftList, nil
+}
+
+// AddToTemplate 将草稿添加到代码模板库
+func (tpl *Template) AddToTemplate(draftID int64, templateType int) error {
+	req := map[string]int64{"draft_id": draftID, "template_type": int64(templateType)}
+	return tpl.post(addToTemplateURL, req, "wxa/addtotemplate")
+}
+
+// TemplateItem 代码模板
+type TemplateItem struct {
+	TemplateID   int64  `json:"template_id"`
+	TemplateType int    `json:"template_type"`
+	UserVersion  string `json:"user_version"`
+	UserDesc     string `json:"user_desc"`
+	CreateTime   int64  `json:"create_time"`
+}
+
+// GetTemplateList 获取代码模板列表
+func (tpl *Template) GetTemplateList() ([]TemplateItem, error) {
+	var ret struct {
+		util.CommonError
+		TemplateList []TemplateItem `json:"template_list"`
+	}
+	if err := tpl.get(getTemplateListURL, &ret, "wxa/gettemplatelist"); err != nil {
+		return nil, err
+	}
+	return ret.TemplateList, nil
+}
+
+// DeleteTemplate 删除指定代码模板
+func (tpl *Template) DeleteTemplate(templateID int64) error {
+	return tpl.post(deleteTemplateURL, map[string]int64{"template_id": templateID}, "wxa/deletetemplate")
+}
+
+func (tpl *Template) get(urlFormat string, ret interface{}, apiName string) error {
+	cat, err := tpl.GetComponentAccessToken()
+	if err != nil {
+		return err
+	}
+	data, err := util.HTTPGet(fmt.Sprintf(urlFormat, cat))
+	if err != nil {
+		return err
+	}
+	return util.DecodeWithError(data, ret, apiName)
+}
+
+func (tpl *Template) post(urlFormat string, req interface{}, apiName string) error {
+	cat, err := tpl.GetComponentAccessToken()
+	if err != nil {
+		return err
+	}
+	data, err := util.PostJSON(fmt.Sprintf(urlFormat, cat), req)
+	if err != nil {
+		return err
+	}
+	return util.DecodeWithCommonError(data, apiName)
+}
@@ -0,0 +1,51 @@
+package component
+
+import (
+	"fmt"
+
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// RegisterBetaParam 创建试用小程序参数
+type RegisterBetaParam struct {
+	Name   string `json:"name"`   // 小程序名称
+	OpenID string `json:"openid"` // 微信用户在第三方平台的 openid
+
+	// State 第三方自定义的关联信息，不会提交给微信
+	State string `json:"-"`
+}
+
+// BetaRegisterResult 创建试用小程序结果
+type BetaRegisterResult struct {
+	UniqueID     string `json:"unique_id"`     // 该请求的唯一标识，注册审核事件推送中会带上
+	AuthorizeURL string `json:"authorize_url"` // 用户授权确认的链接
+}
+
+// BetaRegistrationKey 试用小程序注册的关联 key
+func BetaRegistrationKey(uniqueID string) string {
+	return fmt.Sprintf("beta:%s", uniqueID)
+}
+
+// RegisterBetaMiniProgram 创建试用小程序，用户确认后结果通过 notify_third_fastregisterbetaapp 事件推送
+// reference: https://developers.weixin.qq.com/doc/oplatform/Third-party_Platforms/2.0/api/beta_Mini_Programs/fastregister.html
+func (component *Component) RegisterBetaMiniProgram(param *RegisterBetaParam) (*BetaRegisterResult, error) {
+	componentAK, err := component.GetComponentAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	data, err := util.PostJSON(fmt.Sprintf(fastregisterbetaweappURL, componentAK), param)
+	if err != nil {
+		return nil, err
+	}
+	var ret struct {
+		util.CommonError
+		BetaRegisterResult
+	}
+	if err := util.DecodeWithError(data, &ret, "wxa/component/fastregisterbetaweapp"); err != nil {
+		return nil, err
+	}
+	if err := component.saveRegistrationState(BetaRegistrationKey(ret.UniqueID), param.State); err != nil {
+		return nil, err
+	}
+	return &ret.BetaRegisterResult, nil
+}
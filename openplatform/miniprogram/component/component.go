@@ -1,14 +1,38 @@
 package component
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
 	openContext "github.com/kuro-liang/wechat-go/openplatform/context"
 	"github.com/kuro-liang/wechat-go/util"
 )
 
 const (
-	fastregisterweappURL = "https://api.weixin.qq.com/cgi-bin/component/fastregisterweapp"
+	fastregisterweappURL         = "https://api.weixin.qq.com/cgi-bin/component/fastregisterweapp"
+	fastregisterpersonalweappURL = "https://api.weixin.qq.com/wxa/component/fastregisterpersonalweapp"
+	fastregisterbetaweappURL     = "https://api.weixin.qq.com/wxa/component/fastregisterbetaweapp?access_token=%s"
+)
+
+// registrationStateExpires 注册请求附带的 State 的保存时间
+const registrationStateExpires = 7 * 24 * time.Hour
+
+// 企业快速注册任务的状态，查询结果及注册审核事件推送中的 status 取值
+const (
+	RegisterStatusSuccess         = 0      // 创建成功
+	RegisterStatusInternalError   = 89247  // 系统内部错误
+	RegisterStatusInvalidCodeType = 89248  // 企业代码类型无效
+	RegisterStatusTaskRunning     = 89249  // 该主体已有任务执行中，距上次任务 24h 后再试
+	RegisterStatusTaskNotFound    = 89250  // 未找到该任务
+	RegisterStatusWaitingVerify   = 89251  // 模板消息已下发，待法人人脸核身校验
+	RegisterStatusVerifyFailed    = 89252  // 法人&企业信息一致性校验不通过
+	RegisterStatusVerifying       = 89253  // 法人&企业信息一致性校验中
+	RegisterStatusInvalidWechat   = 86004  // 无效微信号
+	RegisterStatusNameMismatch    = 61070  // 法人姓名与微信号不一致
+	RegisterStatusIDCardTimeout   = 100001 // 法人超时未确认，未进行身份证校验
+	RegisterStatusFaceTimeout     = 100002 // 法人超时未确认，未进行人脸识别校验
+	RegisterStatusConfirmTimeout  = 100003 // 法人超时未确认
 )
 
 // Component 快速创建小程序
@@ -25,25 +49,52 @@ func NewComponent(opContext *openContext.Context) *Component {
 type RegisterMiniProgramParam struct {
 	Name               string `json:"name"`                 // 企业名
 	Code               string `json:"code"`                 // 企业代码
-	CodeType           string `json:"code_type"`            // 企业代码类型 1：统一社会信用代码（18 位） 2：组织机构代码（9 位 xxxxxxxx-x） 3：营业执照注册号(15 位)
+	CodeType           int    `json:"code_type"`            // 企业代码类型 1：统一社会信用代码（18 位） 2：组织机构代码（9 位 xxxxxxxx-x） 3：营业执照注册号(15 位)
 	LegalPersonaWechat string `json:"legal_persona_wechat"` // 法人微信号
 	LegalPersonaName   string `json:"legal_persona_name"`   // 法人姓名（绑定银行卡）
 	ComponentPhone     string `json:"component_phone"`      // 第三方联系电话（方便法人与第三方联系）
+
+	// State 第三方自定义的关联信息（如租户 ID），不会提交给微信
+	// 收到注册审核事件推送后可使用 GetRegistrationState 取回
+	State string `json:"-"`
+}
+
+// RegistrationKey 用于关联注册请求与注册审核事件推送
+func (param *RegisterMiniProgramParam) RegistrationKey() string {
+	return EnterpriseRegistrationKey(param.Name, param.LegalPersonaWechat, param.LegalPersonaName)
+}
+
+// EnterpriseRegistrationKey 企业快速注册的关联 key，推送事件的 info 中包含相同的字段
+func EnterpriseRegistrationKey(name, legalPersonaWechat, legalPersonaName string) string {
+	return fmt.Sprintf("enterprise:%s:%s:%s", name, legalPersonaWechat, legalPersonaName)
+}
+
+// EnterpriseRegisterResult 快速注册企业小程序结果
+type EnterpriseRegisterResult struct {
+	RegistrationKey string // 关联注册请求与注册审核事件推送，可用于 GetRegistrationState
 }
 
 // RegisterMiniProgram 快速创建小程
+// 法人完成校验后，结果通过 notify_third_fasteregister 事件推送
 // reference: https://developers.weixin.qq.com/doc/oplatform/Third-party_Platforms/Mini_Programs/Fast_Registration_Interface_document.html
-func (component *Component) RegisterMiniProgram(param *RegisterMiniProgramParam) error {
+func (component *Component) RegisterMiniProgram(param *RegisterMiniProgramParam) (*EnterpriseRegisterResult, error) {
 	componentAK, err := component.GetComponentAccessToken()
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	url := fmt.Sprintf(fastregisterweappURL+"?action=create&component_access_token=%s", componentAK)
 	data, err := util.PostJSON(url, param)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := util.DecodeWithCommonError(data, "component/fastregisterweapp?action=create"); err != nil {
+		return nil, err
 	}
-	return util.DecodeWithCommonError(data, "component/fastregisterweapp?action=create")
+	result := &EnterpriseRegisterResult{RegistrationKey: param.RegistrationKey()}
+	if err := component.saveRegistrationState(result.RegistrationKey, param.State); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 // GetRegistrationStatusParam 查询任务创建状态
@@ -51,19 +102,83 @@ type GetRegistrationStatusParam struct {
 	Name               string `json:"name"`                 // 企业名
 	LegalPersonaWechat string `json:"legal_persona_wechat"` // 法人微信号
 	LegalPersonaName   string `json:"legal_persona_name"`   // 法人姓名（绑定银行卡）
+}
 
+// RegistrationStatus 注册任务的状态
+type RegistrationStatus struct {
+	Status int64  `json:"errcode"` // 任务状态，取值见 RegisterStatus 开头的常量
+	Msg    string `json:"errmsg"`
 }
 
-// GetRegistrationStatus 查询创建任务状态.
-func (component *Component) GetRegistrationStatus(param *GetRegistrationStatusParam) error {
+// Pending 任务是否仍在进行中
+func (status *RegistrationStatus) Pending() bool {
+	switch status.Status {
+	case RegisterStatusTaskRunning, RegisterStatusWaitingVerify, RegisterStatusVerifying:
+		return true
+	}
+	return false
+}
+
+// isRegisterStatus errcode 是否为文档列出的任务状态，其他 errcode（如 access_token 无效）为接口错误
+func isRegisterStatus(errCode int64) bool {
+	switch errCode {
+	case RegisterStatusSuccess,
+		RegisterStatusInternalError,
+		RegisterStatusInvalidCodeType,
+		RegisterStatusTaskRunning,
+		RegisterStatusTaskNotFound,
+		RegisterStatusWaitingVerify,
+		RegisterStatusVerifyFailed,
+		RegisterStatusVerifying,
+		RegisterStatusInvalidWechat,
+		RegisterStatusNameMismatch,
+		RegisterStatusIDCardTimeout,
+		RegisterStatusFaceTimeout,
+		RegisterStatusConfirmTimeout:
+		return true
+	}
+	return false
+}
+
+// GetRegistrationStatus 查询创建任务状态
+// 任务状态通过返回的 errcode 表示，文档列出的任务状态不作为 error 返回，其他 errcode 返回 error
+func (component *Component) GetRegistrationStatus(param *GetRegistrationStatusParam) (*RegistrationStatus, error) {
 	componentAK, err := component.GetComponentAccessToken()
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	url := fmt.Sprintf(fastregisterweappURL+"?action=search&component_access_token=%s", componentAK)
 	data, err := util.PostJSON(url, param)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	status := &RegistrationStatus{}
+	if err := json.Unmarshal(data, status); err != nil {
+		return nil, err
+	}
+	if !isRegisterStatus(status.Status) {
+		return nil, fmt.Errorf("component/fastregisterweapp?action=search Error , errcode=%d , errmsg=%s", status.Status, status.Msg)
+	}
+	return status, nil
+}
+
+// GetRegistrationState 获取注册请求附带的 State，registrationKey 可通过注册审核事件的 RegistrationKey 获得
+func (component *Component) GetRegistrationState(registrationKey string) (string, error) {
+	state, ok := component.Cache.Get(component.registrationStateCacheKey(registrationKey)).(string)
+	if !ok {
+		return "", fmt.Errorf("registration state of %s not found", registrationKey)
+	}
+	return state, nil
+}
+
+func (component *Component) saveRegistrationState(registrationKey, state string) error {
+	if state == "" {
+		return nil
 	}
-	return util.DecodeWithCommonError(data, "component/fastregisterweapp?action=search")
+	return component.Cache.Set(component.registrationStateCacheKey(registrationKey), state, registrationStateExpires)
+}
+
+// registrationStateCacheKey 多个第三方平台共用缓存时按第三方平台 appid 区分
+func (component *Component) registrationStateCacheKey(registrationKey string) string {
+	return fmt.Sprintf("fast_register_state_%s_%s", component.AppID, registrationKey)
 }
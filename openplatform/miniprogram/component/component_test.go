@@ -0,0 +1,108 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/openplatform/optest"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func newTestComponent() *Component {
+	return NewComponent(optest.NewContext(nil))
+}
+
+func TestRegisterMiniProgram(t *testing.T) {
+	defer gock.Off()
+	component := newTestComponent()
+	param := &RegisterMiniProgramParam{
+		Name:               "tencent",
+		Code:               "123",
+		CodeType:           1,
+		LegalPersonaWechat: "123",
+		LegalPersonaName:   "pony",
+		ComponentPhone:     "1234567",
+		State:              "tenant-1",
+	}
+
+	// 获取 component_access_token 失败时返回错误
+	_, err := component.RegisterMiniProgram(param)
+	assert.Error(t, err)
+
+	assert.Nil(t, optest.SetComponentAccessToken(component.Context, "cat-1"))
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/component/fastregisterweapp").
+		MatchParam("action", "create").
+		MatchParam("component_access_token", "cat-1").
+		JSON(map[string]interface{}{"name": "tencent", "code": "123", "code_type": 1, "legal_persona_wechat": "123", "legal_persona_name": "pony", "component_phone": "1234567"}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+	result, err := component.RegisterMiniProgram(param)
+	if assert.Nil(t, err) {
+		assert.Equal(t, EnterpriseRegistrationKey("tencent", "123", "pony"), result.RegistrationKey)
+	}
+
+	state, err := component.GetRegistrationState(EnterpriseRegistrationKey("tencent", "123", "pony"))
+	assert.Nil(t, err)
+	assert.Equal(t, "tenant-1", state)
+
+	// 共用缓存的其他第三方平台取不到该 State
+	other := optest.NewContext(nil)
+	other.AppID = "wx-component-2"
+	other.Cache = component.Cache
+	_, err = NewComponent(other).GetRegistrationState(result.RegistrationKey)
+	assert.Error(t, err)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/component/fastregisterweapp").
+		MatchParam("action", "search").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 89251, "errmsg": "legal person checking"})
+	status, err := component.GetRegistrationStatus(&GetRegistrationStatusParam{Name: "tencent", LegalPersonaWechat: "123", LegalPersonaName: "pony"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(RegisterStatusWaitingVerify), status.Status)
+	assert.True(t, status.Pending())
+
+	// 任务状态以外的 errcode 为接口错误
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/component/fastregisterweapp").
+		MatchParam("action", "search").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 40001, "errmsg": "invalid credential"})
+	_, err = component.GetRegistrationStatus(&GetRegistrationStatusParam{Name: "tencent", LegalPersonaWechat: "123", LegalPersonaName: "pony"})
+	assert.EqualError(t, err, "component/fastregisterweapp?action=search Error , errcode=40001 , errmsg=invalid credential")
+	assert.True(t, gock.IsDone())
+}
+
+func TestRegisterPersonalAndBeta(t *testing.T) {
+	defer gock.Off()
+	component := newTestComponent()
+	assert.Nil(t, optest.SetComponentAccessToken(component.Context, "cat-1"))
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/component/fastregisterpersonalweapp").
+		MatchParam("action", "create").
+		JSON(map[string]string{"idname": "张三", "wxuser": "zhangsan"}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "taskid": "task-1", "authorize_url": "https://mp.weixin.qq.com/xxx", "status": 0})
+	result, err := component.RegisterPersonalMiniProgram(&RegisterPersonalParam{IDName: "张三", WxUser: "zhangsan", State: "tenant-2"})
+	assert.Nil(t, err)
+	assert.Equal(t, "task-1", result.TaskID)
+	state, err := component.GetRegistrationState(PersonalRegistrationKey("zhangsan", "张三"))
+	assert.Nil(t, err)
+	assert.Equal(t, "tenant-2", state)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/component/fastregisterbetaweapp").
+		MatchParam("access_token", "cat-1").
+		JSON(map[string]string{"name": "试用小程序", "openid": "openid"}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "unique_id": "unique-1", "authorize_url": "https://mp.weixin.qq.com/yyy"})
+	beta, err := component.RegisterBetaMiniProgram(&RegisterBetaParam{Name: "试用小程序", OpenID: "openid", State: "tenant-3"})
+	assert.Nil(t, err)
+	assert.Equal(t, "unique-1", beta.UniqueID)
+	state, err = component.GetRegistrationState(BetaRegistrationKey("unique-1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "tenant-3", state)
+	assert.True(t, gock.IsDone())
+}
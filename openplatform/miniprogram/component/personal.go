@@ -0,0 +1,80 @@
+package component
+
+import (
+	"fmt"
+
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// RegisterPersonalParam 快速注册个人小程序参数
+type RegisterPersonalParam struct {
+	IDName         string `json:"idname"`                    // 个人用户名字
+	WxUser         string `json:"wxuser"`                    // 个人用户微信号
+	ComponentPhone string `json:"component_phone,omitempty"` // 第三方联系电话
+
+	// State 第三方自定义的关联信息，不会提交给微信
+	State string `json:"-"`
+}
+
+// RegistrationKey 用于关联注册请求与注册审核事件推送
+func (param *RegisterPersonalParam) RegistrationKey() string {
+	return PersonalRegistrationKey(param.WxUser, param.IDName)
+}
+
+// PersonalRegistrationKey 个人快速注册的关联 key，推送事件的 info 中包含相同的字段
+func PersonalRegistrationKey(wxUser, idName string) string {
+	return fmt.Sprintf("personal:%s:%s", wxUser, idName)
+}
+
+// PersonalRegisterResult 快速注册个人小程序结果
+type PersonalRegisterResult struct {
+	TaskID       string `json:"taskid"`        // 任务 id，用于查询任务状态
+	AuthorizeURL string `json:"authorize_url"` // 给用户扫码认证的验证 url
+	Status       int    `json:"status"`
+}
+
+// RegisterPersonalMiniProgram 快速注册个人小程序，用户扫码完成认证后结果通过 notify_third_fasteregister 事件推送
+// reference: https://developers.weixin.qq.com/doc/oplatform/Third-party_Platforms/2.0/api/Register_Mini_Programs/fastregisterpersonalweapp.html
+func (component *Component) RegisterPersonalMiniProgram(param *RegisterPersonalParam) (*PersonalRegisterResult, error) {
+	componentAK, err := component.GetComponentAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf(fastregisterpersonalweappURL+"?action=create&component_access_token=%s", componentAK)
+	data, err := util.PostJSON(url, param)
+	if err != nil {
+		return nil, err
+	}
+	var ret struct {
+		util.CommonError
+		PersonalRegisterResult
+	}
+	if err := util.DecodeWithError(data, &ret, "wxa/component/fastregisterpersonalweapp?action=create"); err != nil {
+		return nil, err
+	}
+	if err := component.saveRegistrationState(param.RegistrationKey(), param.State); err != nil {
+		return nil, err
+	}
+	return &ret.PersonalRegisterResult, nil
+}
+
+// GetPersonalRegistrationStatus 查询个人小程序注册任务状态
+func (component *Component) GetPersonalRegistrationStatus(taskID string) (*RegistrationStatus, error) {
+	componentAK, err := component.GetComponentAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf(fastregisterpersonalweappURL+"?action=query&component_access_token=%s", componentAK)
+	data, err := util.PostJSON(url, map[string]string{"taskid": taskID})
+	if err != nil {
+		return nil, err
+	}
+	var ret struct {
+		util.CommonError
+		Status int64 `json:"status"`
+	}
+	if err := util.DecodeWithError(data, &ret, "wxa/component/fastregisterpersonalweapp?action=query"); err != nil {
+		return nil, err
+	}
+	return &RegistrationStatus{Status: ret.Status, Msg: ret.ErrMsg}, nil
+}
@@ -0,0 +1,109 @@
+// Package domain 第三方平台代小程序设置服务器域名、业务域名及扫普通链接二维码打开小程序
+package domain
+
+import (
+	openContext "github.com/kuro-liang/wechat-go/openplatform/context"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+const (
+	modifyDomainURL         = "https://api.weixin.qq.com/wxa/modify_domain?access_token=%s"
+	modifyDomainDirectlyURL = "https://api.weixin.qq.com/wxa/modify_domain_directly?access_token=%s"
+	getEffectiveDomainURL   = "https://api.weixin.qq.com/wxa/get_effective_domain?access_token=%s"
+	setWebviewDomainURL     = "https://api.weixin.qq.com/wxa/setwebviewdomain?access_token=%s"
+)
+
+// 操作类型
+const (
+	ActionAdd    = "add"    // 添加
+	ActionDelete = "delete" // 删除
+	ActionSet    = "set"    // 覆盖
+	ActionGet    = "get"    // 获取
+)
+
+// Domain 小程序域名管理
+// reference: https://developers.weixin.qq.com/doc/oplatform/Third-party_Platforms/2.0/api/Mini_Program_Basic_Info/Server_Address_Configuration.html
+type Domain struct {
+	*openContext.Context
+	appID string
+}
+
+// NewDomain new
+func NewDomain(opContext *openContext.Context, appID string) *Domain {
+	return &Domain{Context: opContext, appID: appID}
+}
+
+// ServerDomain 服务器域名
+type ServerDomain struct {
+	RequestDomain   []string `json:"requestdomain,omitempty"`
+	WsRequestDomain []string `json:"wsrequestdomain,omitempty"`
+	UploadDomain    []string `json:"uploaddomain,omitempty"`
+	DownloadDomain  []string `json:"downloaddomain,omitempty"`
+	UDPDomain       []string `json:"udpdomain,omitempty"`
+	TCPDomain       []string `json:"tcpdomain,omitempty"`
+}
+
+// ModifyDomain 设置服务器域名，域名需先配置在第三方平台的服务器域名中，返回操作后的服务器域名
+func (domain *Domain) ModifyDomain(action string, serverDomain *ServerDomain) (*ServerDomain, error) {
+	return domain.modifyDomain(modifyDomainURL, action, serverDomain, "wxa/modify_domain")
+}
+
+// ModifyDomainDirectly 快速设置服务器域名，域名无需配置在第三方平台中
+func (domain *Domain) ModifyDomainDirectly(action string, serverDomain *ServerDomain) (*ServerDomain, error) {
+	return domain.modifyDomain(modifyDomainDirectlyURL, action, serverDomain, "wxa/modify_domain_directly")
+}
+
+func (domain *Domain) modifyDomain(urlFormat, action string, serverDomain *ServerDomain, apiName string) (*ServerDomain, error) {
+	req := struct {
+		Action string `json:"action"`
+		*ServerDomain
+	}{Action: action, ServerDomain: serverDomain}
+	if req.ServerDomain == nil {
+		req.ServerDomain = &ServerDomain{}
+	}
+	var ret struct {
+		util.CommonError
+		ServerDomain
+	}
+	if err := domain.AuthrPost(domain.appID, urlFormat, req, &ret, apiName); err != nil {
+		return nil, err
+	}
+	return &ret.ServerDomain, nil
+}
+
+// EffectiveDomain 各来源配置的服务器域名及最终生效的服务器域名
+type EffectiveDomain struct {
+	MpDomain        ServerDomain `json:"mp_domain"`        // 通过公众平台配置的
+	ThirdDomain     ServerDomain `json:"third_domain"`     // 通过第三方平台接口配置的
+	DirectDomain    ServerDomain `json:"direct_domain"`    // 通过快速设置接口配置的
+	EffectiveDomain ServerDomain `json:"effective_domain"` // 最终生效的
+}
+
+// GetEffectiveDomain 获取发布后生效的服务器域名
+func (domain *Domain) GetEffectiveDomain() (*EffectiveDomain, error) {
+	var ret struct {
+		util.CommonError
+		EffectiveDomain
+	}
+	if err := domain.AuthrPost(domain.appID, getEffectiveDomainURL, map[string]string{}, &ret, "wxa/get_effective_domain"); err != nil {
+		return nil, err
+	}
+	return &ret.EffectiveDomain, nil
+}
+
+// SetWebviewDomain 设置业务域名，action 为空时表示覆盖为第三方平台配置的业务域名，返回操作后的业务域名
+func (domain *Domain) SetWebviewDomain(action string, webviewDomain []string) ([]string, error) {
+	req := map[string]interface{}{}
+	if action != "" {
+		req["action"] = action
+		req["webviewdomain"] = webviewDomain
+	}
+	var ret struct {
+		util.CommonError
+		WebviewDomain []string `json:"webviewdomain"`
+	}
+	if err := domain.AuthrPost(domain.appID, setWebviewDomainURL, req, &ret, "wxa/setwebviewdomain"); err != nil {
+		return nil, err
+	}
+	return ret.WebviewDomain, nil
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/openplatform/optest"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func newTestDomain() *Domain {
+	return NewDomain(optest.NewContext(map[string]string{"wx-mini": "authr-1"}), "wx-mini")
+}
+
+func TestServerDomain(t *testing.T) {
+	defer gock.Off()
+	domain := newTestDomain()
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/modify_domain").
+		MatchParam("access_token", "authr-1").
+		JSON(map[string]interface{}{"action": "add", "requestdomain": []string{"https://www.qq.com"}}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "requestdomain": []string{"https://www.qq.com", "https://www.qq.com"}, "uploaddomain": []string{"https://www.qq.com"}})
+	serverDomain, err := domain.ModifyDomain(ActionAdd, &ServerDomain{RequestDomain: []string{"https://www.qq.com"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"https://www.qq.com"}, serverDomain.UploadDomain)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/modify_domain_directly").
+		JSON(map[string]interface{}{"action": "get"}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "requestdomain": []string{"https://direct.qq.com"}})
+	serverDomain, err = domain.ModifyDomainDirectly(ActionGet, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"https://direct.qq.com"}, serverDomain.RequestDomain)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/get_effective_domain").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "effective_domain": map[string]interface{}{"requestdomain": []string{"https://direct.qq.com"}}})
+	effective, err := domain.GetEffectiveDomain()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"https://direct.qq.com"}, effective.EffectiveDomain.RequestDomain)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/wxa/setwebviewdomain").
+		JSON(map[string]interface{}{"action": "set", "webviewdomain": []string{"https://www.qq.com"}}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 89019, "errmsg": "业务域名无更改，无需重复设置"})
+	_, err = domain.SetWebviewDomain(ActionSet, []string{"https://www.qq.com"})
+	assert.EqualError(t, err, "wxa/setwebviewdomain Error , errcode=89019 , errmsg=业务域名无更改，无需重复设置")
+	assert.True(t, gock.IsDone())
+}
+
+func TestJumpRule(t *testing.T) {
+	defer gock.Off()
+	domain := newTestDomain()
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/wxopen/qrcodejumpadd").
+		JSON(map[string]interface{}{"prefix": "https://www.qq.com/qrcode", "permit_sub_rule": "1", "path": "pages/index/index", "open_version": "1", "debug_url": []string{"https://www.qq.com/qrcode?a=1"}, "is_edit": 0}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+	assert.Nil(t, domain.AddJumpRule(&JumpRule{
+		Prefix:        "https://www.qq.com/qrcode",
+		PermitSubRule: "1",
+		Path:          "pages/index/index",
+		OpenVersion:   OpenVersionDevelop,
+		DebugURL:      []string{"https://www.qq.com/qrcode?a=1"},
+	}, false))
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/wxopen/qrcodejumpget").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "qrcodejump_open": 1, "list_size": 1, "qrcodejump_pub_quota": 20,
+			"rule_list": []map[string]interface{}{{"prefix": "https://www.qq.com/qrcode", "path": "pages/index/index", "state": 1}}})
+	rules, err := domain.GetJumpRules()
+	assert.Nil(t, err)
+	assert.Equal(t, 20, rules.QrcodeJumpPubQuota)
+	assert.Equal(t, 1, rules.RuleList[0].State)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/wxopen/qrcodejumpdownload").
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "file_name": "abc.txt", "file_content": "xyz"})
+	file, err := domain.DownloadJumpVerifyFile()
+	assert.Nil(t, err)
+	assert.Equal(t, "abc.txt", file.FileName)
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/wxopen/qrcodejumppublish").
+		JSON(map[string]string{"prefix": "https://www.qq.com/qrcode"}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+	assert.Nil(t, domain.PublishJumpRule("https://www.qq.com/qrcode"))
+	assert.True(t, gock.IsDone())
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+const (
+	qrcodeJumpAddURL      = "https://api.weixin.qq.com/cgi-bin/wxopen/qrcodejumpadd?access_token=%s"
+	qrcodeJumpGetURL      = "https://api.weixin.qq.com/cgi-bin/wxopen/qrcodejumpget?access_token=%s"
+	qrcodeJumpDeleteURL   = "https://api.weixin.qq.com/cgi-bin/wxopen/qrcodejumpdelete?access_token=%s"
+	qrcodeJumpPublishURL  = "https://api.weixin.qq.com/cgi-bin/wxopen/qrcodejumppublish?access_token=%s"
+	qrcodeJumpDownloadURL = "https://api.weixin.qq.com/cgi-bin/wxopen/qrcodejumpdownload?access_token=%s"
+)
+
+// 扫普通链接二维码打开小程序的测试范围
+const (
+	OpenVersionDevelop = "1" // 开发版
+	OpenVersionTrial   = "2" // 体验版
+	OpenVersionRelease = "3" // 正式版
+)
+
+// JumpRule 扫普通链接二维码打开小程序的规则
+type JumpRule struct {
+	Prefix        string   `json:"prefix"`          // 二维码规则
+	PermitSubRule string   `json:"permit_sub_rule"` // 是否独占符合二维码前缀匹配规则的所有子规则，1 不占用，2 占用
+	Path          string   `json:"path"`            // 小程序功能页面
+	OpenVersion   string   `json:"open_version"`    // 测试范围
+	DebugURL      []string `json:"debug_url"`       // 测试链接，至多 5 个
+	State         int      `json:"state,omitempty"` // 发布标志位，1 未发布，2 已发布
+}
+
+// AddJumpRule 增加或修改扫普通链接二维码打开小程序的规则，isEdit 为 true 时修改已有规则
+func (domain *Domain) AddJumpRule(rule *JumpRule, isEdit bool) error {
+	req := struct {
+		*JumpRule
+		IsEdit int `json:"is_edit"`
+	}{JumpRule: rule}
+	if isEdit {
+		req.IsEdit = 1
+	}
+	return domain.AuthrPost(domain.appID, qrcodeJumpAddURL, req, &util.CommonError{}, "wxopen/qrcodejumpadd")
+}
+
+// JumpRules 已设置的二维码规则
+type JumpRules struct {
+	RuleList           []JumpRule `json:"rule_list"`
+	QrcodeJumpOpen     int        `json:"qrcodejump_open"` // 是否已经打开二维码跳转链接设置，1 已打开
+	ListSize           int        `json:"list_size"`
+	QrcodeJumpPubQuota int        `json:"qrcodejump_pub_quota"` // 本月还可发布的次数
+}
+
+// GetJumpRules 获取已设置的二维码规则
+func (domain *Domain) GetJumpRules() (*JumpRules, error) {
+	var ret struct {
+		util.CommonError
+		JumpRules
+	}
+	if err := domain.AuthrPost(domain.appID, qrcodeJumpGetURL, map[string]string{}, &ret, "wxopen/qrcodejumpget"); err != nil {
+		return nil, err
+	}
+	return &ret.JumpRules, nil
+}
+
+// DeleteJumpRule 删除已设置的二维码规则
+func (domain *Domain) DeleteJumpRule(prefix string) error {
+	return domain.AuthrPost(domain.appID, qrcodeJumpDeleteURL, map[string]string{"prefix": prefix}, &util.CommonError{}, "wxopen/qrcodejumpdelete")
+}
+
+// PublishJumpRule 发布已设置的二维码规则
+func (domain *Domain) PublishJumpRule(prefix string) error {
+	return domain.AuthrPost(domain.appID, qrcodeJumpPublishURL, map[string]string{"prefix": prefix}, &util.CommonError{}, "wxopen/qrcodejumppublish")
+}
+
+// JumpVerifyFile 二维码规则的校验文件，需放置在二维码规则所在域名的根目录下
+type JumpVerifyFile struct {
+	FileName    string `json:"file_name"`
+	FileContent string `json:"file_content"`
+}
+
+// DownloadJumpVerifyFile 获取校验文件名称及内容
+func (domain *Domain) DownloadJumpVerifyFile() (*JumpVerifyFile, error) {
+	var ret struct {
+		util.CommonError
+		JumpVerifyFile
+	}
+	if err := domain.AuthrPost(domain.appID, qrcodeJumpDownloadURL, map[string]string{}, &ret, "wxopen/qrcodejumpdownload"); err != nil {
+		return nil, err
+	}
+	return &ret.JumpVerifyFile, nil
+}
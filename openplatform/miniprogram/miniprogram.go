@@ -3,7 +3,10 @@ package miniprogram
 import (
 	openContext "github.com/kuro-liang/wechat-go/openplatform/context"
 	"github.com/kuro-liang/wechat-go/openplatform/miniprogram/basic"
+	"github.com/kuro-liang/wechat-go/openplatform/miniprogram/code"
 	"github.com/kuro-liang/wechat-go/openplatform/miniprogram/component"
+	"github.com/kuro-liang/wechat-go/openplatform/miniprogram/domain"
+	"github.com/kuro-liang/wechat-go/openplatform/miniprogram/privacy"
 )
 
 // MiniProgram 代小程序实现业务
@@ -30,3 +33,23 @@ func (miniProgram *MiniProgram) GetComponent() *component.Component {
 func (miniProgram *MiniProgram) GetBasic() *basic.Basic {
 	return basic.NewBasic(miniProgram.openContext, miniProgram.AppID)
 }
+
+// GetCode 代码管理
+func (miniProgram *MiniProgram) GetCode() *code.Code {
+	return code.NewCode(miniProgram.openContext, miniProgram.AppID)
+}
+
+// GetTemplate 代码模板库管理
+func (miniProgram *MiniProgram) GetTemplate() *code.Template {
+	return code.NewTemplate(miniProgram.openContext)
+}
+
+// GetDomain 服务器域名、业务域名及二维码规则设置
+func (miniProgram *MiniProgram) GetDomain() *domain.Domain {
+	return domain.NewDomain(miniProgram.openContext, miniProgram.AppID)
+}
+
+// GetPrivacy 用户隐私保护指引设置
+func (miniProgram *MiniProgram) GetPrivacy() *privacy.Privacy {
+	return privacy.NewPrivacy(miniProgram.openContext, miniProgram.AppID)
+}
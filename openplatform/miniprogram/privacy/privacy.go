@@ -0,0 +1,95 @@
+// Package privacy 第三方平台代小程序配置用户隐私保护指引
+package privacy
+
+import (
+	openContext "github.com/kuro-liang/wechat-go/openplatform/context"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+const (
+	setPrivacySettingURL = "https://api.weixin.qq.com/cgi-bin/component/setprivacysetting?access_token=%s"
+	getPrivacySettingURL = "https://api.weixin.qq.com/cgi-bin/component/getprivacysetting?access_token=%s"
+)
+
+// 用户隐私保护指引的版本
+const (
+	VersionRelease = 1 // 现网版本
+	VersionDevelop = 2 // 开发版本
+)
+
+// Privacy 用户隐私保护指引
+// reference: https://developers.weixin.qq.com/doc/oplatform/Third-party_Platforms/2.0/api/privacy_config/set_privacy_setting.html
+type Privacy struct {
+	*openContext.Context
+	appID string
+}
+
+// NewPrivacy new
+func NewPrivacy(opContext *openContext.Context, appID string) *Privacy {
+	return &Privacy{Context: opContext, appID: appID}
+}
+
+// OwnerSetting 收集方（开发者）信息配置，联系方式至少填写一项
+type OwnerSetting struct {
+	ContactEmail         string `json:"contact_email,omitempty"`
+	ContactPhone         string `json:"contact_phone,omitempty"`
+	ContactQQ            string `json:"contact_qq,omitempty"`
+	ContactWeixin        string `json:"contact_weixin,omitempty"`
+	ExtFileMediaID       string `json:"ext_file_media_id,omitempty"`      // 自定义的用户隐私保护指引文件
+	NoticeMethod         string `json:"notice_method"`                    // 通知方式
+	StoreExpireTimestamp string `json:"store_expire_timestamp,omitempty"` // 存储期限
+}
+
+// Setting 收集的用户信息及用途
+type Setting struct {
+	PrivacyKey   string `json:"privacy_key"`             // 用户信息类型的英文名称，如 UserInfo、Location
+	PrivacyText  string `json:"privacy_text"`            // 用户信息的用途
+	PrivacyLabel string `json:"privacy_label,omitempty"` // 用户信息类型的中文名称，获取时返回
+}
+
+// SetParam 配置用户隐私保护指引参数
+type SetParam struct {
+	PrivacyVer   int          `json:"privacy_ver,omitempty"` // 为空时表示开发版本
+	OwnerSetting OwnerSetting `json:"owner_setting"`
+	SettingList  []Setting    `json:"setting_list"`
+}
+
+// SetPrivacySetting 配置小程序用户隐私保护指引
+func (privacy *Privacy) SetPrivacySetting(param *SetParam) error {
+	var ret util.CommonError
+	return privacy.AuthrPost(privacy.appID, setPrivacySettingURL, param, &ret, "component/setprivacysetting")
+}
+
+// Desc 用户信息类型的说明
+type Desc struct {
+	PrivacyKey  string `json:"privacy_key"`
+	PrivacyDesc string `json:"privacy_desc"`
+}
+
+// PrivacySetting 已配置的用户隐私保护指引
+type PrivacySetting struct {
+	CodeExist    int          `json:"code_exist"`   // 代码是否存在，0 不存在，1 存在
+	PrivacyList  []string     `json:"privacy_list"` // 代码检测出来的用户信息类型
+	SettingList  []Setting    `json:"setting_list"`
+	UpdateTime   int64        `json:"update_time"`
+	OwnerSetting OwnerSetting `json:"owner_setting"`
+	PrivacyDesc  struct {
+		PrivacyDescList []Desc `json:"privacy_desc_list"`
+	} `json:"privacy_desc"`
+}
+
+// GetPrivacySetting 查询小程序用户隐私保护指引
+func (privacy *Privacy) GetPrivacySetting(privacyVer int) (*PrivacySetting, error) {
+	req := map[string]int{}
+	if privacyVer != 0 {
+		req["privacy_ver"] = privacyVer
+	}
+	var ret struct {
+		util.CommonError
+		PrivacySetting
+	}
+	if err := privacy.AuthrPost(privacy.appID, getPrivacySettingURL, req, &ret, "component/getprivacysetting"); err != nil {
+		return nil, err
+	}
+	return &ret.PrivacySetting, nil
+}
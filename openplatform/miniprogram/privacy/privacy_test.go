@@ -0,0 +1,44 @@
+package privacy
+
+import (
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/openplatform/optest"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestPrivacySetting(t *testing.T) {
+	defer gock.Off()
+	privacy := NewPrivacy(optest.NewContext(map[string]string{"wx-mini": "authr-1"}), "wx-mini")
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/component/setprivacysetting").
+		MatchParam("access_token", "authr-1").
+		JSON(map[string]interface{}{
+			"privacy_ver":   2,
+			"owner_setting": map[string]string{"contact_email": "a@qq.com", "notice_method": "弹窗"},
+			"setting_list":  []map[string]string{{"privacy_key": "UserInfo", "privacy_text": "登录"}},
+		}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+	assert.Nil(t, privacy.SetPrivacySetting(&SetParam{
+		PrivacyVer:   VersionDevelop,
+		OwnerSetting: OwnerSetting{ContactEmail: "a@qq.com", NoticeMethod: "弹窗"},
+		SettingList:  []Setting{{PrivacyKey: "UserInfo", PrivacyText: "登录"}},
+	}))
+
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/component/getprivacysetting").
+		JSON(map[string]int{"privacy_ver": 1}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok", "code_exist": 1, "privacy_list": []string{"UserInfo"},
+			"setting_list": []map[string]string{{"privacy_key": "UserInfo", "privacy_text": "登录", "privacy_label": "用户信息"}},
+			"privacy_desc": map[string]interface{}{"privacy_desc_list": []map[string]string{{"privacy_key": "UserInfo", "privacy_desc": "用户信息（微信昵称、头像）"}}}})
+	setting, err := privacy.GetPrivacySetting(VersionRelease)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, setting.CodeExist)
+	assert.Equal(t, "用户信息", setting.SettingList[0].PrivacyLabel)
+	assert.Equal(t, "UserInfo", setting.PrivacyDesc.PrivacyDescList[0].PrivacyKey)
+	assert.True(t, gock.IsDone())
+}
@@ -41,6 +41,7 @@ func (officialAccount *OfficialAccount) PlatformJs() *js.Js {
 }
 
 // DefaultAuthrAccessToken 默认获取授权ak的方法
+// 从 AuthorizerTokenStore 读取，过期时使用保存的 authorizer_refresh_token 自动刷新
 type DefaultAuthrAccessToken struct {
 	opCtx *opContext.Context
 	appID string
@@ -2,18 +2,25 @@ package openplatform
 
 import (
 	"net/http"
+	"sync"
 
+	"github.com/kuro-liang/wechat-go/officialaccount/message"
 	"github.com/kuro-liang/wechat-go/officialaccount/server"
 	"github.com/kuro-liang/wechat-go/openplatform/account"
 	"github.com/kuro-liang/wechat-go/openplatform/config"
 	"github.com/kuro-liang/wechat-go/openplatform/context"
 	"github.com/kuro-liang/wechat-go/openplatform/miniprogram"
 	"github.com/kuro-liang/wechat-go/openplatform/officialaccount"
+	opServer "github.com/kuro-liang/wechat-go/openplatform/server"
+	log "github.com/sirupsen/logrus"
 )
 
 // OpenPlatform 微信开放平台相关api
 type OpenPlatform struct {
 	*context.Context
+
+	poolMu     sync.RWMutex
+	clientPool *ClientPool
 }
 
 // NewOpenPlatform new openplatform
@@ -24,27 +31,80 @@ func NewOpenPlatform(cfg *config.Config) *OpenPlatform {
 	ctx := &context.Context{
 		Config: cfg,
 	}
-	return &OpenPlatform{ctx}
+	return &OpenPlatform{
+		Context:    ctx,
+		clientPool: NewClientPool(ctx, DefaultPoolSize, DefaultPoolIdleTimeout),
+	}
 }
 
 // GetServer get server
+//
+// Deprecated: 使用 GetCallbackServer，可区分授权事件与授权方的消息，并自动完成全网发布检测
+// 收到 component_verify_ticket 推送时自动保存，之后 component_access_token 会按需自动获取及刷新
+// 收到授权、更新授权事件时换取并保存授权方令牌，取消授权时删除
 func (openPlatform *OpenPlatform) GetServer(req *http.Request, writer http.ResponseWriter) *server.Server {
 	off := officialaccount.NewOfficialAccount(openPlatform.Context, "")
-	return off.GetServer(req, writer)
+	srv := off.GetServer(req, writer)
+	srv.SetMessageInterceptor(openPlatform.handleComponentEvent)
+	return srv
+}
+
+// GetCallbackServer 第三方平台授权事件及授权方消息与事件的接收，实现了 http.Handler
+func (openPlatform *OpenPlatform) GetCallbackServer() *opServer.Server {
+	srv := opServer.NewServer(openPlatform.Context)
+	srv.OnUnauthorized(openPlatform.removeClient)
+	return srv
+}
+
+// handleComponentEvent 处理第三方平台推送的 ticket 及授权变更事件
+func (openPlatform *OpenPlatform) handleComponentEvent(msg *message.MixMessage) {
+	switch msg.InfoType {
+	case message.InfoTypeVerifyTicket:
+		if err := openPlatform.SetComponentVerifyTicket(msg.ComponentVerifyTicket); err != nil {
+			log.Errorf("save component_verify_ticket error: %v", err)
+		}
+	case message.InfoTypeAuthorized, message.InfoTypeUpdateAuthorized:
+		if _, err := openPlatform.QueryAuthCode(msg.AuthorizationCode); err != nil {
+			log.Errorf("query auth code of authorizer %s error: %v", msg.AuthorizerAppid, err)
+		}
+	case message.InfoTypeUnauthorized:
+		if err := openPlatform.DeleteAuthorizer(msg.AuthorizerAppid); err != nil {
+			log.Errorf("delete token of authorizer %s error: %v", msg.AuthorizerAppid, err)
+		}
+		openPlatform.removeClient(msg.AuthorizerAppid)
+	}
 }
 
-// GetOfficialAccount 公众号代处理
+// GetOfficialAccount 公众号代处理，同一授权方复用客户端池中的实例
 func (openPlatform *OpenPlatform) GetOfficialAccount(appID string) *officialaccount.OfficialAccount {
-	return officialaccount.NewOfficialAccount(openPlatform.Context, appID)
+	return openPlatform.GetClientPool().OfficialAccount(appID)
 }
 
-// GetMiniProgram 小程序代理
+// GetMiniProgram 小程序代理，同一授权方复用客户端池中的实例
 func (openPlatform *OpenPlatform) GetMiniProgram(appID string) *miniprogram.MiniProgram {
-	return miniprogram.NewMiniProgram(openPlatform.Context, appID)
+	return openPlatform.GetClientPool().MiniProgram(appID)
+}
+
+// GetClientPool 授权方客户端池，可获取各授权方的使用统计
+func (openPlatform *OpenPlatform) GetClientPool() *ClientPool {
+	openPlatform.poolMu.RLock()
+	defer openPlatform.poolMu.RUnlock()
+	return openPlatform.clientPool
+}
+
+// SetClientPool 替换授权方客户端池，用于调整容量及空闲淘汰时间，可随时调用
+func (openPlatform *OpenPlatform) SetClientPool(pool *ClientPool) {
+	openPlatform.poolMu.Lock()
+	defer openPlatform.poolMu.Unlock()
+	openPlatform.clientPool = pool
+}
+
+// removeClient 授权方取消授权时从当前的客户端池中移除
+func (openPlatform *OpenPlatform) removeClient(appID string) {
+	openPlatform.GetClientPool().Remove(appID)
 }
 
-// GetAccountManager 账号管理
-// TODO
+// GetAccountManager 开放平台帐号管理
 func (openPlatform *OpenPlatform) GetAccountManager() *account.Account {
 	return account.NewAccount(openPlatform.Context)
 }
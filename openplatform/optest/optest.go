@@ -0,0 +1,27 @@
+// Package optest 第三方平台测试辅助，创建使用内存缓存并可预置令牌的 Context
+package optest
+
+import (
+	"time"
+
+	"github.com/kuro-liang/wechat-go/cache"
+	"github.com/kuro-liang/wechat-go/openplatform/config"
+	"github.com/kuro-liang/wechat-go/openplatform/context"
+)
+
+// ComponentAppID 测试使用的第三方平台 appid
+const ComponentAppID = "wx-component"
+
+// NewContext 创建使用内存缓存的第三方平台 Context，authorizers 为预置的授权方 appid => authorizer_access_token
+func NewContext(authorizers map[string]string) *context.Context {
+	ctx := &context.Context{Config: &config.Config{AppID: ComponentAppID, AppSecret: "secret", Token: "token", Cache: cache.NewMemory()}}
+	for appID, token := range authorizers {
+		_ = ctx.GetAuthorizerTokenStore().SetAccessToken(appID, token, time.Hour)
+	}
+	return ctx
+}
+
+// SetComponentAccessToken 预置 component_access_token，避免测试中请求微信服务器
+func SetComponentAccessToken(ctx *context.Context, token string) error {
+	return ctx.Cache.Set("component_access_token_"+ctx.AppID, token, time.Hour)
+}
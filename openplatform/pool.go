@@ -0,0 +1,219 @@
+package openplatform
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/openplatform/context"
+	"github.com/kuro-liang/wechat-go/openplatform/miniprogram"
+	"github.com/kuro-liang/wechat-go/openplatform/officialaccount"
+)
+
+const (
+	// DefaultPoolSize 授权方客户端池的默认容量
+	DefaultPoolSize = 1000
+	// DefaultPoolIdleTimeout 授权方客户端的默认空闲淘汰时间
+	DefaultPoolIdleTimeout = 30 * time.Minute
+	// PoolStatsTTL 已不在池中的授权方超过该时间未使用时删除其使用统计
+	PoolStatsTTL = 24 * time.Hour
+)
+
+// TenantStats 单个授权方的客户端使用统计
+type TenantStats struct {
+	Hits      uint64    // 复用已有客户端的次数
+	Misses    uint64    // 新建客户端的次数
+	Evictions uint64    // 因空闲或容量不足被淘汰的次数
+	LastUsed  time.Time // 最近一次获取客户端的时间
+}
+
+// ClientPool 按授权方 appid 复用公众号及小程序客户端，并发安全
+// 超过容量时淘汰最久未使用的客户端，空闲超过 idleTimeout 的客户端在访问池时或由后台定期淘汰
+type ClientPool struct {
+	ctx         *context.Context
+	size        int
+	idleTimeout time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List // 元素为 *poolEntry，越靠前越近使用
+	entries map[string]*list.Element
+	stats   map[string]*TenantStats
+	// cleaning 后台淘汰是否在运行，池及统计为空时退出，下次新建客户端时再启动
+	cleaning bool
+}
+
+type poolEntry struct {
+	appID           string
+	officialAccount *officialaccount.OfficialAccount
+	miniProgram     *miniprogram.MiniProgram
+	lastUsed        time.Time
+}
+
+// NewClientPool 实例化授权方客户端池，size 或 idleTimeout 为 0 时使用默认值
+func NewClientPool(ctx *context.Context, size int, idleTimeout time.Duration) *ClientPool {
+	if size <= 0 {
+		size = DefaultPoolSize
+	}
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultPoolIdleTimeout
+	}
+	return &ClientPool{
+		ctx:         ctx,
+		size:        size,
+		idleTimeout: idleTimeout,
+		now:         time.Now,
+		lru:         list.New(),
+		entries:     make(map[string]*list.Element),
+		stats:       make(map[string]*TenantStats),
+	}
+}
+
+// cleanup 后台每隔 idleTimeout 淘汰空闲客户端及过期的使用统计，池及统计都为空时退出
+func (pool *ClientPool) cleanup() {
+	ticker := time.NewTicker(pool.idleTimeout)
+	defer ticker.Stop()
+	for range ticker.C {
+		pool.mu.Lock()
+		pool.evictStale(pool.now())
+		if pool.lru.Len() == 0 && len(pool.stats) == 0 {
+			pool.cleaning = false
+			pool.mu.Unlock()
+			return
+		}
+		pool.mu.Unlock()
+	}
+}
+
+// OfficialAccount 获取授权公众号的客户端
+func (pool *ClientPool) OfficialAccount(appID string) *officialaccount.OfficialAccount {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	entry := pool.get(appID)
+	if entry.officialAccount == nil {
+		entry.officialAccount = officialaccount.NewOfficialAccount(pool.ctx, appID)
+	}
+	return entry.officialAccount
+}
+
+// MiniProgram 获取授权小程序的客户端
+func (pool *ClientPool) MiniProgram(appID string) *miniprogram.MiniProgram {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	entry := pool.get(appID)
+	if entry.miniProgram == nil {
+		entry.miniProgram = miniprogram.NewMiniProgram(pool.ctx, appID)
+	}
+	return entry.miniProgram
+}
+
+// get 获取或创建授权方的客户端记录，调用方需持有锁
+func (pool *ClientPool) get(appID string) *poolEntry {
+	now := pool.now()
+	pool.evictIdle(now)
+
+	stats, ok := pool.stats[appID]
+	if !ok {
+		stats = &TenantStats{}
+		pool.stats[appID] = stats
+	}
+	stats.LastUsed = now
+
+	if elem, ok := pool.entries[appID]; ok {
+		stats.Hits++
+		entry := elem.Value.(*poolEntry)
+		entry.lastUsed = now
+		pool.lru.MoveToFront(elem)
+		return entry
+	}
+
+	stats.Misses++
+	if !pool.cleaning {
+		pool.cleaning = true
+		go pool.cleanup()
+	}
+	entry := &poolEntry{appID: appID, lastUsed: now}
+	pool.entries[appID] = pool.lru.PushFront(entry)
+	for pool.lru.Len() > pool.size {
+		pool.evict(pool.lru.Back())
+	}
+	return entry
+}
+
+// EvictIdle 淘汰空闲超时的客户端，并删除超过 PoolStatsTTL 未使用的授权方的使用统计，返回淘汰的客户端数量
+func (pool *ClientPool) EvictIdle() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.evictStale(pool.now())
+}
+
+func (pool *ClientPool) evictStale(now time.Time) int {
+	evicted := pool.evictIdle(now)
+	for appID, stats := range pool.stats {
+		if _, ok := pool.entries[appID]; !ok && now.Sub(stats.LastUsed) >= PoolStatsTTL {
+			delete(pool.stats, appID)
+		}
+	}
+	return evicted
+}
+
+func (pool *ClientPool) evictIdle(now time.Time) int {
+	evicted := 0
+	for elem := pool.lru.Back(); elem != nil; elem = pool.lru.Back() {
+		if now.Sub(elem.Value.(*poolEntry).lastUsed) < pool.idleTimeout {
+			break
+		}
+		pool.evict(elem)
+		evicted++
+	}
+	return evicted
+}
+
+func (pool *ClientPool) evict(elem *list.Element) {
+	entry := pool.lru.Remove(elem).(*poolEntry)
+	delete(pool.entries, entry.appID)
+	if stats, ok := pool.stats[entry.appID]; ok {
+		stats.Evictions++
+	}
+}
+
+// Remove 移除授权方的客户端及统计，授权方取消授权时由 OpenPlatform 调用
+// 保存的授权方令牌由回调 Server 在收到取消授权事件时删除
+func (pool *ClientPool) Remove(appID string) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if elem, ok := pool.entries[appID]; ok {
+		pool.lru.Remove(elem)
+		delete(pool.entries, appID)
+	}
+	delete(pool.stats, appID)
+}
+
+// Len 池中的客户端数量
+func (pool *ClientPool) Len() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.lru.Len()
+}
+
+// Stats 获取授权方的使用统计
+func (pool *ClientPool) Stats(appID string) (TenantStats, bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	stats, ok := pool.stats[appID]
+	if !ok {
+		return TenantStats{}, false
+	}
+	return *stats, true
+}
+
+// AllStats 获取全部授权方的使用统计
+func (pool *ClientPool) AllStats() map[string]TenantStats {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	all := make(map[string]TenantStats, len(pool.stats))
+	for appID, stats := range pool.stats {
+		all[appID] = *stats
+	}
+	return all
+}
@@ -0,0 +1,115 @@
+package openplatform
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/cache"
+	"github.com/kuro-liang/wechat-go/openplatform/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestOpenPlatform() *OpenPlatform {
+	return NewOpenPlatform(&config.Config{AppID: "wx-component", AppSecret: "secret", Token: "token", Cache: cache.NewMemory()})
+}
+
+func TestClientPool(t *testing.T) {
+	openPlatform := newTestOpenPlatform()
+	pool := NewClientPool(openPlatform.Context, 2, time.Minute)
+	now := time.Unix(1600000000, 0)
+	pool.now = func() time.Time { return now }
+	openPlatform.SetClientPool(pool)
+
+	// 同一授权方复用客户端
+	off := openPlatform.GetOfficialAccount("wx-a")
+	assert.Same(t, off, openPlatform.GetOfficialAccount("wx-a"))
+	mini := openPlatform.GetMiniProgram("wx-a")
+	assert.Same(t, mini, openPlatform.GetMiniProgram("wx-a"))
+	assert.Equal(t, 1, pool.Len())
+	stats, ok := pool.Stats("wx-a")
+	assert.True(t, ok)
+	assert.Equal(t, TenantStats{Hits: 3, Misses: 1, LastUsed: now}, stats)
+
+	// 超过容量时淘汰最久未使用的授权方
+	openPlatform.GetOfficialAccount("wx-b")
+	openPlatform.GetOfficialAccount("wx-a")
+	openPlatform.GetOfficialAccount("wx-c")
+	assert.Equal(t, 2, pool.Len())
+	stats, _ = pool.Stats("wx-b")
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Same(t, off, openPlatform.GetOfficialAccount("wx-a"))
+	assert.NotSame(t, off, openPlatform.GetOfficialAccount("wx-b"))
+
+	// 空闲超时淘汰
+	now = now.Add(time.Minute)
+	assert.Equal(t, 2, pool.EvictIdle())
+	assert.Equal(t, 0, pool.Len())
+	assert.Len(t, pool.AllStats(), 3)
+
+	// 移除授权方
+	openPlatform.GetMiniProgram("wx-a")
+	pool.Remove("wx-a")
+	assert.Equal(t, 0, pool.Len())
+	_, ok = pool.Stats("wx-a")
+	assert.False(t, ok)
+
+	// 不在池中的授权方超过 PoolStatsTTL 未使用时删除统计
+	openPlatform.GetOfficialAccount("wx-d")
+	now = now.Add(PoolStatsTTL - time.Minute)
+	openPlatform.GetOfficialAccount("wx-d")
+	now = now.Add(time.Minute)
+	pool.EvictIdle()
+	_, ok = pool.Stats("wx-b")
+	assert.False(t, ok)
+	_, ok = pool.Stats("wx-d")
+	assert.True(t, ok)
+}
+
+func TestClientPoolBackgroundCleanup(t *testing.T) {
+	pool := NewClientPool(newTestOpenPlatform().Context, 5, 10*time.Millisecond)
+	pool.OfficialAccount("wx-a")
+	assert.Eventually(t, func() bool { return pool.Len() == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func TestSetClientPoolAfterCallbackServer(t *testing.T) {
+	openPlatform := newTestOpenPlatform()
+	srv := openPlatform.GetCallbackServer()
+	srv.SkipValidate(true)
+
+	// 替换客户端池后，取消授权事件移除的是当前池中的客户端
+	pool := NewClientPool(openPlatform.Context, 2, time.Minute)
+	openPlatform.SetClientPool(pool)
+	openPlatform.GetOfficialAccount("wx-authorizer")
+	assert.Equal(t, 1, pool.Len())
+
+	w := httptest.NewRecorder()
+	body := `<xml><AppId>wx-component</AppId><InfoType>unauthorized</InfoType><AuthorizerAppid>wx-authorizer</AuthorizerAppid></xml>`
+	srv.ServeHTTP(w, httptest.NewRequest("POST", "/callback", strings.NewReader(body)))
+	assert.Equal(t, "success", w.Body.String())
+	assert.Equal(t, 0, pool.Len())
+}
+
+func TestClientPoolConcurrent(t *testing.T) {
+	pool := NewClientPool(newTestOpenPlatform().Context, 5, time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				appID := fmt.Sprintf("wx-%d", (i+j)%10)
+				pool.OfficialAccount(appID)
+				pool.MiniProgram(appID)
+				if j%10 == 0 {
+					pool.Remove(appID)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, pool.Len(), 5)
+}
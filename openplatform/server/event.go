@@ -0,0 +1,59 @@
+package server
+
+import (
+	"github.com/kuro-liang/wechat-go/officialaccount/message"
+	"github.com/kuro-liang/wechat-go/openplatform/miniprogram/component"
+)
+
+// ComponentEvent 推送到授权事件接收地址的第三方平台事件
+type ComponentEvent struct {
+	AppID      string           `xml:"AppId"` // 第三方平台 appid
+	CreateTime int64            `xml:"CreateTime"`
+	InfoType   message.InfoType `xml:"InfoType"`
+
+	// component_verify_ticket
+	ComponentVerifyTicket string `xml:"ComponentVerifyTicket"`
+
+	// authorized、updateauthorized、unauthorized
+	AuthorizerAppid              string `xml:"AuthorizerAppid"`
+	AuthorizationCode            string `xml:"AuthorizationCode"`
+	AuthorizationCodeExpiredTime int64  `xml:"AuthorizationCodeExpiredTime"`
+	PreAuthCode                  string `xml:"PreAuthCode"`
+
+	// notify_third_fasteregister、notify_third_fastregisterbetaapp
+	RegisterAppID string           `xml:"appid"` // 注册成功的小程序 appid
+	Status        int              `xml:"status"`
+	AuthCode      string           `xml:"auth_code"`
+	Msg           string           `xml:"msg"`
+	Info          FastRegisterInfo `xml:"info"`
+}
+
+// FastRegisterInfo 快速注册小程序时提交的信息
+type FastRegisterInfo struct {
+	// 企业小程序
+	Name               string `xml:"name"`
+	Code               string `xml:"code"`
+	CodeType           int    `xml:"code_type"`
+	LegalPersonaWechat string `xml:"legal_persona_wechat"`
+	LegalPersonaName   string `xml:"legal_persona_name"`
+	ComponentPhone     string `xml:"component_phone"`
+
+	// 个人小程序
+	WxUser string `xml:"wxuser"`
+	IDName string `xml:"idname"`
+
+	// 试用小程序
+	UniqueID string `xml:"unique_id"`
+}
+
+// RegistrationKey 注册审核事件对应的注册请求的关联 key，可用于 component.GetRegistrationState
+func (evt *ComponentEvent) RegistrationKey() string {
+	switch {
+	case evt.InfoType == message.InfoTypeNotifyThirdFastRegisterBetaApp:
+		return component.BetaRegistrationKey(evt.Info.UniqueID)
+	case evt.Info.WxUser != "":
+		return component.PersonalRegistrationKey(evt.Info.WxUser, evt.Info.IDName)
+	default:
+		return component.EnterpriseRegistrationKey(evt.Info.Name, evt.Info.LegalPersonaWechat, evt.Info.LegalPersonaName)
+	}
+}
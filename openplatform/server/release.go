@@ -0,0 +1,59 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/kuro-liang/wechat-go/officialaccount/message"
+	"github.com/kuro-liang/wechat-go/openplatform/officialaccount"
+	log "github.com/sirupsen/logrus"
+)
+
+// 全网发布接入检测
+// https://developers.weixin.qq.com/doc/oplatform/Third-party_Platforms/2.0/operation/thirdparty/releases_instructions.html
+const (
+	releaseTestText          = "TESTCOMPONENT_MSG_TYPE_TEXT"
+	releaseTestQueryAuthCode = "QUERY_AUTH_CODE:"
+)
+
+// releaseTestAccounts 全网发布检测使用的公众号及小程序，包含 appid 与原始 ID
+var releaseTestAccounts = map[string]bool{
+	"wx570bc396a51b8ff8": true,
+	"gh_3c884a361561":    true,
+	"wxd101a85aa106f53e": true,
+	"gh_8dad206e9538":    true,
+}
+
+func isReleaseTestAccount(appID, userName string) bool {
+	return releaseTestAccounts[appID] || releaseTestAccounts[userName]
+}
+
+// releaseTestReply 按全网发布检测的要求回复，返回 false 时交由消息处理方法处理
+func (srv *Server) releaseTestReply(appID string, msg *message.MixMessage) (*message.Reply, bool) {
+	switch {
+	case msg.MsgType == message.MsgTypeEvent:
+		return &message.Reply{MsgType: message.MsgTypeText, MsgData: message.NewText(string(msg.Event) + "from_callback")}, true
+	case msg.MsgType == message.MsgTypeText && msg.Content == releaseTestText:
+		return &message.Reply{MsgType: message.MsgTypeText, MsgData: message.NewText(releaseTestText + "_callback")}, true
+	case msg.MsgType == message.MsgTypeText && strings.HasPrefix(msg.Content, releaseTestQueryAuthCode):
+		// 先回复空串，再使用 query_auth_code 换取令牌并通过客服消息接口回复
+		authCode := strings.TrimPrefix(msg.Content, releaseTestQueryAuthCode)
+		go srv.releaseTestCustomerMessage(appID, authCode, string(msg.FromUserName))
+		return nil, true
+	}
+	return nil, false
+}
+
+func (srv *Server) releaseTestCustomerMessage(appID, authCode, openID string) {
+	info, err := srv.QueryAuthCode(authCode)
+	if err != nil {
+		log.Errorf("release test query auth code error: %v", err)
+		return
+	}
+	if info.Appid != "" {
+		appID = info.Appid
+	}
+	manager := officialaccount.NewOfficialAccount(srv.Context, appID).GetCustomerMessageManager()
+	if err := manager.Send(message.NewCustomerTextMessage(openID, authCode+"_from_api")); err != nil {
+		log.Errorf("release test send customer message error: %v", err)
+	}
+}
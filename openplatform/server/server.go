@@ -0,0 +1,149 @@
+// Package server 第三方平台的授权事件及消息与事件接收
+package server
+
+import (
+	"encoding/xml"
+	"net/http"
+	"path"
+
+	"github.com/kuro-liang/wechat-go/officialaccount/message"
+	opContext "github.com/kuro-liang/wechat-go/openplatform/context"
+	"github.com/kuro-liang/wechat-go/openplatform/officialaccount"
+	log "github.com/sirupsen/logrus"
+)
+
+// Server 第三方平台回调处理，同时用作授权事件接收地址与消息与事件接收地址
+// 消息与事件接收地址需包含 $APPID$，默认取请求路径的最后一段作为授权方 appid
+type Server struct {
+	*opContext.Context
+
+	skipValidate bool
+
+	componentHandlers map[message.InfoType]func(*ComponentEvent) error
+	messageHandler    func(appID string, msg *message.MixMessage) *message.Reply
+	appIDFunc         func(*http.Request) string
+	unauthorizedHooks []func(appID string)
+}
+
+// NewServer init
+func NewServer(ctx *opContext.Context) *Server {
+	return &Server{
+		Context:           ctx,
+		componentHandlers: make(map[message.InfoType]func(*ComponentEvent) error),
+		appIDFunc: func(req *http.Request) string {
+			return path.Base(req.URL.Path)
+		},
+	}
+}
+
+// SkipValidate set skip validate
+func (srv *Server) SkipValidate(skip bool) {
+	srv.skipValidate = skip
+}
+
+// HandleComponentEvent 设置某一类授权事件的处理方法
+// component_verify_ticket、授权、更新授权及取消授权事件会先由 Server 保存 ticket 及授权方令牌，再调用处理方法
+func (srv *Server) HandleComponentEvent(infoType message.InfoType, handler func(*ComponentEvent) error) {
+	srv.componentHandlers[infoType] = handler
+}
+
+// OnUnauthorized 添加授权方取消授权后的回调，在删除授权方令牌之后、HandleComponentEvent 设置的处理方法之前调用
+func (srv *Server) OnUnauthorized(hook func(appID string)) {
+	srv.unauthorizedHooks = append(srv.unauthorizedHooks, hook)
+}
+
+// SetMessageHandler 设置授权方的消息与事件处理方法，appID 为授权方 appid
+func (srv *Server) SetMessageHandler(handler func(appID string, msg *message.MixMessage) *message.Reply) {
+	srv.messageHandler = handler
+}
+
+// SetAuthorizerAppIDFunc 设置从消息与事件接收地址中解析授权方 appid 的方法
+func (srv *Server) SetAuthorizerAppIDFunc(fn func(*http.Request) string) {
+	srv.appIDFunc = fn
+}
+
+// ServeHTTP 校验并解密请求，授权事件回复 success，授权方消息按处理方法的返回回复
+func (srv *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	off := officialaccount.NewOfficialAccount(srv.Context, "")
+	offSrv := off.GetServer(req, w)
+	offSrv.SkipValidate(srv.skipValidate)
+
+	var (
+		handled   bool
+		component bool
+		handleErr error
+	)
+	offSrv.SetMessageHandler(func(msg *message.MixMessage) *message.Reply {
+		handled = true
+		if msg.InfoType != "" {
+			component = true
+			handleErr = srv.handleComponentEvent(offSrv.RequestRawXMLMsg)
+			return nil
+		}
+		return srv.handleMessage(srv.appIDFunc(req), msg)
+	})
+
+	if err := offSrv.Serve(); err != nil {
+		log.Errorf("openplatform server error: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// 服务器地址校验时已回复 echostr
+	if !handled {
+		return
+	}
+	if component {
+		if handleErr != nil {
+			log.Errorf("handle component event error: %v", handleErr)
+			http.Error(w, handleErr.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("success"))
+		return
+	}
+	if err := offSrv.Send(); err != nil {
+		log.Errorf("send reply error: %v", err)
+	}
+}
+
+func (srv *Server) handleComponentEvent(raw []byte) error {
+	evt := &ComponentEvent{}
+	if err := xml.Unmarshal(raw, evt); err != nil {
+		return err
+	}
+
+	switch evt.InfoType {
+	case message.InfoTypeVerifyTicket:
+		if err := srv.SetComponentVerifyTicket(evt.ComponentVerifyTicket); err != nil {
+			return err
+		}
+	case message.InfoTypeAuthorized, message.InfoTypeUpdateAuthorized:
+		if _, err := srv.QueryAuthCode(evt.AuthorizationCode); err != nil {
+			return err
+		}
+	case message.InfoTypeUnauthorized:
+		if err := srv.DeleteAuthorizer(evt.AuthorizerAppid); err != nil {
+			return err
+		}
+		for _, hook := range srv.unauthorizedHooks {
+			hook(evt.AuthorizerAppid)
+		}
+	}
+
+	if handler, ok := srv.componentHandlers[evt.InfoType]; ok {
+		return handler(evt)
+	}
+	return nil
+}
+
+func (srv *Server) handleMessage(appID string, msg *message.MixMessage) *message.Reply {
+	if isReleaseTestAccount(appID, string(msg.ToUserName)) {
+		if reply, ok := srv.releaseTestReply(appID, msg); ok {
+			return reply
+		}
+	}
+	if srv.messageHandler == nil {
+		return nil
+	}
+	return srv.messageHandler(appID, msg)
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/officialaccount/message"
+	"github.com/kuro-liang/wechat-go/openplatform/miniprogram/component"
+	"github.com/kuro-liang/wechat-go/openplatform/optest"
+	"github.com/kuro-liang/wechat-go/util"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func newTestServer() *Server {
+	return NewServer(optest.NewContext(nil))
+}
+
+func serve(srv *Server, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("POST", target, strings.NewReader(body)))
+	return w
+}
+
+func TestComponentEvent(t *testing.T) {
+	srv := newTestServer()
+	var events []*ComponentEvent
+	srv.HandleComponentEvent(message.InfoTypeVerifyTicket, func(evt *ComponentEvent) error {
+		events = append(events, evt)
+		return nil
+	})
+
+	body := `<xml><AppId>wx-component</AppId><CreateTime>1413192605</CreateTime><InfoType>component_verify_ticket</InfoType><ComponentVerifyTicket>ticket@@@1</ComponentVerifyTicket></xml>`
+	// 签名错误
+	w := serve(srv, "/callback?timestamp=1413192605&nonce=1&signature=bad", body)
+	assert.Equal(t, 400, w.Code)
+
+	target := "/callback?timestamp=1413192605&nonce=1&signature=" + util.Signature("token", "1413192605", "1")
+	w = serve(srv, target, body)
+	assert.Equal(t, "success", w.Body.String())
+	assert.Len(t, events, 1)
+	assert.Equal(t, "ticket@@@1", events[0].ComponentVerifyTicket)
+	ticket, err := srv.GetComponentVerifyTicket()
+	assert.Nil(t, err)
+	assert.Equal(t, "ticket@@@1", ticket)
+
+	// 取消授权时删除授权方令牌
+	store := srv.GetAuthorizerTokenStore()
+	assert.Nil(t, store.SetRefreshToken("wx-authorizer", "refresh-1"))
+	var unauthorized []string
+	srv.OnUnauthorized(func(appID string) {
+		unauthorized = append(unauthorized, appID)
+	})
+	srv.SkipValidate(true)
+	w = serve(srv, "/callback", `<xml><AppId>wx-component</AppId><InfoType>unauthorized</InfoType><AuthorizerAppid>wx-authorizer</AuthorizerAppid></xml>`)
+	assert.Equal(t, "success", w.Body.String())
+	refreshToken, _ := store.GetRefreshToken("wx-authorizer")
+	assert.Empty(t, refreshToken)
+	assert.Equal(t, []string{"wx-authorizer"}, unauthorized)
+}
+
+func TestAuthorizerMessage(t *testing.T) {
+	srv := newTestServer()
+	srv.SkipValidate(true)
+	var appIDs []string
+	srv.SetMessageHandler(func(appID string, msg *message.MixMessage) *message.Reply {
+		appIDs = append(appIDs, appID)
+		return &message.Reply{MsgType: message.MsgTypeText, MsgData: message.NewText("echo " + msg.Content)}
+	})
+
+	w := serve(srv, "/callback/wx-authorizer", `<xml><ToUserName>gh_1</ToUserName><FromUserName>openid</FromUserName><CreateTime>1</CreateTime><MsgType>text</MsgType><Content>hello</Content></xml>`)
+	assert.Contains(t, w.Body.String(), "<Content><![CDATA[echo hello]]></Content>")
+	assert.Equal(t, []string{"wx-authorizer"}, appIDs)
+}
+
+func TestReleaseTest(t *testing.T) {
+	defer gock.Off()
+	srv := newTestServer()
+	srv.SkipValidate(true)
+	srv.SetMessageHandler(func(appID string, msg *message.MixMessage) *message.Reply {
+		t.Fatal("release test message should not be handled")
+		return nil
+	})
+	testAppID := "/callback/wx570bc396a51b8ff8"
+
+	w := serve(srv, testAppID, `<xml><ToUserName>gh_3c884a361561</ToUserName><FromUserName>openid</FromUserName><CreateTime>1</CreateTime><MsgType>text</MsgType><Content>TESTCOMPONENT_MSG_TYPE_TEXT</Content></xml>`)
+	assert.Contains(t, w.Body.String(), "<Content><![CDATA[TESTCOMPONENT_MSG_TYPE_TEXT_callback]]></Content>")
+
+	w = serve(srv, testAppID, `<xml><ToUserName>gh_3c884a361561</ToUserName><FromUserName>openid</FromUserName><CreateTime>1</CreateTime><MsgType>event</MsgType><Event>LOCATION</Event></xml>`)
+	assert.Contains(t, w.Body.String(), "<Content><![CDATA[LOCATIONfrom_callback]]></Content>")
+
+	assert.Nil(t, optest.SetComponentAccessToken(srv.Context, "cat-1"))
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/component/api_query_auth").
+		JSON(map[string]string{"component_appid": "wx-component", "authorization_code": "queryauthcode@@@1"}).
+		Reply(200).
+		JSON(map[string]interface{}{"authorization_info": map[string]interface{}{
+			"authorizer_appid":         "wx570bc396a51b8ff8",
+			"authorizer_access_token":  "authr-1",
+			"expires_in":               7200,
+			"authorizer_refresh_token": "refresh-1",
+		}})
+	gock.New("https://api.weixin.qq.com").
+		Post("/cgi-bin/message/custom/send").
+		MatchParam("access_token", "authr-1").
+		JSON(map[string]interface{}{"touser": "openid", "msgtype": "text", "text": map[string]string{"content": "queryauthcode@@@1_from_api"}}).
+		Reply(200).
+		JSON(map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+
+	w = serve(srv, testAppID, `<xml><ToUserName>gh_3c884a361561</ToUserName><FromUserName>openid</FromUserName><CreateTime>1</CreateTime><MsgType>text</MsgType><Content>QUERY_AUTH_CODE:queryauthcode@@@1</Content></xml>`)
+	assert.Empty(t, w.Body.String())
+	for i := 0; i < 100 && !gock.IsDone(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, gock.IsDone())
+}
+
+func TestFastRegisterEvent(t *testing.T) {
+	srv := newTestServer()
+	srv.SkipValidate(true)
+	var key string
+	srv.HandleComponentEvent(message.InfoTypeNotifyThirdFasterRegister, func(evt *ComponentEvent) error {
+		key = evt.RegistrationKey()
+		return nil
+	})
+	w := serve(srv, "/callback", `<xml><AppId>wx-component</AppId><CreateTime>1535442403</CreateTime><InfoType>notify_third_fasteregister</InfoType><appid>wx-new</appid><status>0</status><auth_code>xxxxx</auth_code><msg>OK</msg><info><name>tencent</name><code>123</code><code_type>1</code_type><legal_persona_wechat>123</legal_persona_wechat><legal_persona_name>pony</legal_persona_name><component_phone>1234567</component_phone></info></xml>`)
+	assert.Equal(t, "success", w.Body.String())
+	assert.Equal(t, component.EnterpriseRegistrationKey("tencent", "123", "pony"), key)
+}
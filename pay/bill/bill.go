@@ -0,0 +1,250 @@
+package bill
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+var (
+	// 下载交易账单
+	// https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_6
+	downloadBillGateway = "/pay/downloadbill"
+	// 下载资金账单
+	// https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_18&index=7
+	downloadFundFlowGateway = "/pay/downloadfundflow"
+)
+
+// 账单类型
+const (
+	BillTypeAll            = "ALL"             // 当日所有订单信息（不含充值退款订单）
+	BillTypeSuccess        = "SUCCESS"         // 当日成功支付的订单（不含充值退款订单）
+	BillTypeRefund         = "REFUND"          // 当日退款订单（不含充值退款订单）
+	BillTypeRechargeRefund = "RECHARGE_REFUND" // 当日充值退款订单
+)
+
+// 资金账户类型
+const (
+	AccountTypeBasic     = "Basic"     // 基本账户
+	AccountTypeOperation = "Operation" // 运营账户
+	AccountTypeFees      = "Fees"      // 手续费账户
+)
+
+// TarTypeGZIP 返回 gzip 压缩的账单
+const TarTypeGZIP = "GZIP"
+
+// Bill 对账单
+type Bill struct {
+	*config.Config
+}
+
+// NewBill return an instance of bill package
+func NewBill(cfg *config.Config) *Bill {
+	return &Bill{cfg}
+}
+
+// DownloadParams 下载交易账单参数
+type DownloadParams struct {
+	BillDate string // 对账单日期，格式 20140603
+	BillType string // 账单类型，默认 ALL
+	TarType  string // 压缩账单，传 GZIP 时下载压缩后的账单并在读取时解压
+	SignType string
+}
+
+// downloadRequest 下载交易账单请求参数
+type downloadRequest struct {
+	AppID    string   `xml:"appid"`
+	MchID    string   `xml:"mch_id"`
+	NonceStr string   `xml:"nonce_str"`
+	Sign     string   `xml:"sign"`
+	SignType string   `xml:"sign_type,omitempty"`
+	BillDate string   `xml:"bill_date"`
+	BillType string   `xml:"bill_type"`
+	TarType  string   `xml:"tar_type,omitempty"`
+	XMLName  struct{} `xml:"xml"`
+}
+
+// FundFlowParams 下载资金账单参数
+type FundFlowParams struct {
+	BillDate    string // 资金账单日期，格式 20140603
+	AccountType string // 资金账户类型，默认 Basic
+	TarType     string // 压缩账单，传 GZIP 时下载压缩后的账单并在读取时解压
+	RootCa      string // ca证书，Config 中已配置商户证书时可不传
+}
+
+// fundFlowRequest 下载资金账单请求参数
+type fundFlowRequest struct {
+	AppID       string   `xml:"appid"`
+	MchID       string   `xml:"mch_id"`
+	NonceStr    string   `xml:"nonce_str"`
+	Sign        string   `xml:"sign"`
+	SignType    string   `xml:"sign_type"`
+	BillDate    string   `xml:"bill_date"`
+	AccountType string   `xml:"account_type"`
+	TarType     string   `xml:"tar_type,omitempty"`
+	XMLName     struct{} `xml:"xml"`
+}
+
+// errorResponse 下载失败时返回的 xml
+type errorResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	ErrorCode  string `xml:"error_code"`
+}
+
+// Download 下载交易账单，返回（已解压的）账单内容，可直接交给 NewTradeReader 解析，读取完毕后需要 Close
+func (bill *Bill) Download(p *DownloadParams) (io.ReadCloser, error) {
+	nonceStr := util.RandomStr(32)
+	billType := p.BillType
+	if billType == "" {
+		billType = BillTypeAll
+	}
+	signType := bill.ResolveSignType(p.SignType)
+	key, err := bill.SignKey()
+	if err != nil {
+		return nil, err
+	}
+
+	param := make(map[string]string)
+	param["appid"] = bill.AppID
+	param["mch_id"] = bill.MchID
+	param["nonce_str"] = nonceStr
+	param["sign_type"] = signType
+	param["bill_date"] = p.BillDate
+	param["bill_type"] = billType
+	if p.TarType != "" {
+		param["tar_type"] = p.TarType
+	}
+
+	sign, err := util.ParamSign(param, key)
+	if err != nil {
+		return nil, err
+	}
+	req := downloadRequest{
+		AppID:    bill.AppID,
+		MchID:    bill.MchID,
+		NonceStr: nonceStr,
+		Sign:     sign,
+		SignType: signType,
+		BillDate: p.BillDate,
+		BillType: billType,
+		TarType:  p.TarType,
+	}
+	return postStatement(bill.Client(), bill.GatewayURL(downloadBillGateway), req, p.TarType)
+}
+
+// DownloadFundFlow 下载资金账单，该接口需要双向证书且只支持 HMAC-SHA256 签名
+// 返回（已解压的）账单内容，可直接交给 NewFundFlowReader 解析，读取完毕后需要 Close
+func (bill *Bill) DownloadFundFlow(p *FundFlowParams) (io.ReadCloser, error) {
+	nonceStr := util.RandomStr(32)
+	accountType := p.AccountType
+	if accountType == "" {
+		accountType = AccountTypeBasic
+	}
+
+	param := make(map[string]string)
+	param["appid"] = bill.AppID
+	param["mch_id"] = bill.MchID
+	param["nonce_str"] = nonceStr
+	param["sign_type"] = util.SignTypeHMACSHA256
+	param["bill_date"] = p.BillDate
+	param["account_type"] = accountType
+	if p.TarType != "" {
+		param["tar_type"] = p.TarType
+	}
+
+	key, err := bill.SignKey()
+	if err != nil {
+		return nil, err
+	}
+	sign, err := util.ParamSign(param, key)
+	if err != nil {
+		return nil, err
+	}
+	req := fundFlowRequest{
+		AppID:       bill.AppID,
+		MchID:       bill.MchID,
+		NonceStr:    nonceStr,
+		Sign:        sign,
+		SignType:    util.SignTypeHMACSHA256,
+		BillDate:    p.BillDate,
+		AccountType: accountType,
+		TarType:     p.TarType,
+	}
+
+	client, err := bill.TLSClient(p.RootCa)
+	if err != nil {
+		return nil, err
+	}
+	return postStatement(client, bill.GatewayURL(downloadFundFlowGateway), req, p.TarType)
+}
+
+// postStatement 发送下载请求，返回不经缓冲的应答 body，避免大账单整体读入内存
+func postStatement(client *http.Client, uri string, obj interface{}, tarType string) (io.ReadCloser, error) {
+	xmlData, err := xml.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.Post(uri, "application/xml;charset=utf-8", bytes.NewReader(xmlData))
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("http code error : uri=%v , statusCode=%v", uri, response.StatusCode)
+	}
+	return decodeStatement(response.Body, tarType)
+}
+
+// errorPeekSize 识别错误返回时预读的字节数
+const errorPeekSize = 512
+
+// decodeStatement 识别错误返回并按需解压账单，返回的 ReadCloser 关闭时一并关闭 body
+func decodeStatement(body io.ReadCloser, tarType string) (io.ReadCloser, error) {
+	br := bufio.NewReaderSize(body, errorPeekSize)
+	head, _ := br.Peek(errorPeekSize)
+	if bytes.HasPrefix(bytes.TrimSpace(head), []byte("<xml>")) {
+		defer body.Close()
+		rawRet, err := ioutil.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		var rsp errorResponse
+		if err = xml.Unmarshal(rawRet, &rsp); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("download bill error, return_code=%s,error_code=%s,return_msg=%s", rsp.ReturnCode, rsp.ErrorCode, rsp.ReturnMsg)
+	}
+	if tarType != TarTypeGZIP {
+		return &statementBody{Reader: br, body: body}, nil
+	}
+	reader, err := gzip.NewReader(br)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	return &statementBody{Reader: reader, body: body, gzip: reader}, nil
+}
+
+// statementBody 账单内容，关闭时释放解压器及 http 连接
+type statementBody struct {
+	io.Reader
+	body io.Closer
+	gzip io.Closer
+}
+
+// Close 关闭账单内容
+func (s *statementBody) Close() error {
+	if s.gzip != nil {
+		_ = s.gzip.Close()
+	}
+	return s.body.Close()
+}
@@ -0,0 +1,176 @@
+package bill
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/util"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestParseTrade(t *testing.T) {
+	f, err := os.Open("testdata/trade_all.csv")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer f.Close()
+
+	rows, summary, err := ParseTrade(f)
+	assert.Nil(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "ORDER20210601001", rows[0].OutTradeNo)
+	assert.Equal(t, money.Fen(1250), rows[0].SettlementTotalFee)
+	assert.Equal(t, money.Fen(8), rows[0].PoundageFee)
+	assert.Equal(t, "REFUND", rows[1].TradeState)
+	assert.Equal(t, money.Fen(300), rows[1].RefundFee)
+	assert.Equal(t, money.Fen(-2), rows[1].PoundageFee)
+	assert.Equal(t, "-0.02000", rows[1].Poundage)
+	assert.Equal(t, "商品A, 商品B", rows[1].Body)
+
+	if assert.NotNil(t, summary) {
+		assert.Equal(t, &TradeSummary{
+			TotalCount:         2,
+			SettlementTotalFee: 1250,
+			RefundFee:          300,
+			PoundageFee:        6,
+			Poundage:           "0.06000",
+			TotalFee:           1250,
+			ApplyRefundFee:     300,
+		}, summary)
+	}
+}
+
+func TestFundFlowReader(t *testing.T) {
+	f, err := os.Open("testdata/fundflow_basic.csv")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer f.Close()
+
+	reader := NewFundFlowReader(f)
+	var amounts []money.Fen
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if !assert.Nil(t, err) {
+			return
+		}
+		amounts = append(amounts, row.Amount)
+	}
+	assert.Equal(t, []money.Fen{1250, 300}, amounts)
+	summary, err := reader.Summary()
+	assert.Nil(t, err)
+	assert.Equal(t, &FundFlowSummary{
+		TotalCount:    2,
+		IncomeCount:   1,
+		IncomeAmount:  1250,
+		ExpenseCount:  1,
+		ExpenseAmount: 300,
+	}, summary)
+}
+
+func TestDecodeStatement(t *testing.T) {
+	_, err := decodeStatement(ioutil.NopCloser(strings.NewReader(`<xml><return_code><![CDATA[FAIL]]></return_code><return_msg><![CDATA[No Bill Exist]]></return_msg><error_code><![CDATA[20002]]></error_code></xml>`)), "")
+	assert.EqualError(t, err, "download bill error, return_code=FAIL,error_code=20002,return_msg=No Bill Exist")
+
+	f, err := os.Open("testdata/trade_all.csv")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = io.Copy(w, f)
+	_ = w.Close()
+
+	body, err := decodeStatement(ioutil.NopCloser(&buf), TarTypeGZIP)
+	if !assert.Nil(t, err) {
+		return
+	}
+	rows, _, err := ParseTrade(body)
+	assert.Nil(t, err)
+	assert.Len(t, rows, 2)
+	assert.Nil(t, body.Close())
+}
+
+func TestDownload(t *testing.T) {
+	defer gock.Off()
+	content, err := ioutil.ReadFile("testdata/trade_all.csv")
+	if !assert.Nil(t, err) {
+		return
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write(content)
+	_ = w.Close()
+
+	var sent map[string]string
+	gock.New(config.DefaultBaseURL).Post(downloadBillGateway).
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return false, err
+			}
+			sent, err = util.XMLToMap(body)
+			return err == nil, err
+		}).
+		Reply(200).
+		Body(&buf)
+
+	p := &DownloadParams{BillDate: "20210601", TarType: TarTypeGZIP}
+	cfg := &config.Config{AppID: "wx2421b1c4370ec43b", MchID: "10000100", Key: "ziR0QKsTUfMOuochC9RfCdmfHECorQAP"}
+	body, err := NewBill(cfg).Download(p)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer body.Close()
+	rows, summary, err := ParseTrade(body)
+	assert.Nil(t, err)
+	assert.Len(t, rows, 2)
+	assert.NotNil(t, summary)
+	assert.Equal(t, BillTypeAll, sent["bill_type"])
+	assert.Equal(t, util.SignTypeMD5, sent["sign_type"])
+	// 默认值不写回调用方的参数
+	assert.Equal(t, &DownloadParams{BillDate: "20210601", TarType: TarTypeGZIP}, p)
+}
+
+func TestFieldParser(t *testing.T) {
+	var p fieldParser
+	assert.Equal(t, money.Fen(1), p.fen("0.01"))
+	assert.Equal(t, money.Fen(120), p.fen("¥1.2"))
+	assert.Equal(t, money.Fen(-250), p.fen("-2.50"))
+	assert.Equal(t, money.Fen(500), p.fen("5"))
+	assert.Equal(t, money.Fen(0), p.fen(""))
+	assert.Equal(t, money.Fen(1), p.roundFen("0.00600"))
+	assert.Equal(t, money.Fen(0), p.roundFen("0.00400"))
+	assert.Nil(t, p.err)
+
+	// 手续费以外的金额不应出现分以下的精度
+	p.fen("0.006")
+	assert.Error(t, p.err)
+}
+
+func TestParseTradeInvalidAmount(t *testing.T) {
+	content := "交易时间,商户订单号,应结订单金额,手续费\n" +
+		"`2021-06-01 10:12:03,`ORDER1,`12.5O,`0.08000\n"
+	_, _, err := ParseTrade(strings.NewReader(content))
+	assert.EqualError(t, err, `parse bill amount: invalid yuan amount "12.5O"`)
+
+	content = "交易时间,商户订单号,应结订单金额,手续费\n" +
+		"`2021-06-01 10:12:03,`ORDER1,`12.50,`0.08000\n" +
+		"总交易单数,应结订单总金额,手续费总金额\n" +
+		"`x,`12.50,`0.08000\n"
+	_, _, err = ParseTrade(strings.NewReader(content))
+	assert.Error(t, err)
+}
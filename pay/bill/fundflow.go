@@ -0,0 +1,104 @@
+package bill
+
+import (
+	"io"
+
+	"github.com/kuro-liang/wechat-go/pay/money"
+)
+
+// FundFlowRow 资金账单中的一条记录，金额单位为分
+type FundFlowRow struct {
+	AccountingTime string    // 记账时间
+	TransactionID  string    // 微信支付业务单号
+	FundFlowID     string    // 资金流水单号
+	BizName        string    // 业务名称
+	BizType        string    // 业务类型
+	FinancialType  string    // 收支类型，收入/支出
+	Amount         money.Fen // 收支金额
+	Balance        money.Fen // 账户结余
+	Applicant      string    // 资金变更提交申请人
+	Memo           string    // 备注
+	BizVoucherID   string    // 业务凭证号
+}
+
+// FundFlowSummary 资金账单汇总，金额单位为分
+type FundFlowSummary struct {
+	TotalCount    int64     // 资金流水总笔数
+	IncomeCount   int64     // 收入笔数
+	IncomeAmount  money.Fen // 收入金额
+	ExpenseCount  int64     // 支出笔数
+	ExpenseAmount money.Fen // 支出金额
+}
+
+// FundFlowReader 流式读取资金账单
+type FundFlowReader struct {
+	s *statementReader
+}
+
+// NewFundFlowReader 创建资金账单读取器
+func NewFundFlowReader(r io.Reader) *FundFlowReader {
+	return &FundFlowReader{s: newStatementReader(r)}
+}
+
+// Read 读取下一条资金流水，读完所有记录后返回 io.EOF
+func (r *FundFlowReader) Read() (*FundFlowRow, error) {
+	m, err := r.s.next()
+	if err != nil {
+		return nil, err
+	}
+	var p fieldParser
+	row := &FundFlowRow{
+		AccountingTime: m["记账时间"],
+		TransactionID:  m["微信支付业务单号"],
+		FundFlowID:     m["资金流水单号"],
+		BizName:        m["业务名称"],
+		BizType:        m["业务类型"],
+		FinancialType:  m["收支类型"],
+		Amount:         p.fen(pick(m, "收支金额（元）", "收支金额(元)", "收支金额")),
+		Balance:        p.fen(pick(m, "账户结余（元）", "账户结余(元)", "账户结余")),
+		Applicant:      m["资金变更提交申请人"],
+		Memo:           m["备注"],
+		BizVoucherID:   m["业务凭证号"],
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return row, nil
+}
+
+// Summary 返回账单汇总，需在 Read 返回 io.EOF 后调用
+func (r *FundFlowReader) Summary() (*FundFlowSummary, error) {
+	m := r.s.summary
+	if m == nil {
+		return nil, nil
+	}
+	var p fieldParser
+	summary := &FundFlowSummary{
+		TotalCount:    p.int(m["资金流水总笔数"]),
+		IncomeCount:   p.int(m["收入笔数"]),
+		IncomeAmount:  p.fen(m["收入金额"]),
+		ExpenseCount:  p.int(m["支出笔数"]),
+		ExpenseAmount: p.fen(m["支出金额"]),
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return summary, nil
+}
+
+// ParseFundFlow 解析完整的资金账单
+func ParseFundFlow(r io.Reader) (rows []*FundFlowRow, summary *FundFlowSummary, err error) {
+	reader := NewFundFlowReader(r)
+	for {
+		var row *FundFlowRow
+		row, err = reader.Read()
+		if err == io.EOF {
+			summary, err = reader.Summary()
+			return
+		}
+		if err != nil {
+			return
+		}
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,174 @@
+package bill
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/kuro-liang/wechat-go/pay/money"
+)
+
+// statementReader 按行读取微信支付账单
+// 账单格式为：表头行、以 ` 开头的数据行、汇总表头行、以 ` 开头的汇总行
+type statementReader struct {
+	r       *bufio.Reader
+	header  map[string]int
+	summary map[string]string
+	done    bool
+}
+
+func newStatementReader(r io.Reader) *statementReader {
+	return &statementReader{r: bufio.NewReader(r)}
+}
+
+// readLine 读取下一个非空行
+func (s *statementReader) readLine() (string, error) {
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		line = strings.TrimPrefix(line, "\ufeff")
+		if strings.TrimSpace(line) != "" {
+			return line, nil
+		}
+		if err == io.EOF {
+			return "", io.EOF
+		}
+	}
+}
+
+// next 返回下一行数据，读到汇总部分后解析汇总并返回 io.EOF
+func (s *statementReader) next() (map[string]string, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+	if s.header == nil {
+		line, err := s.readLine()
+		if err == io.EOF {
+			return nil, errors.New("empty bill content")
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.header = indexHeader(splitHeader(line))
+	}
+
+	line, err := s.readLine()
+	if err == io.EOF {
+		s.done = true
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(line, "`") {
+		return s.record(s.header, splitRecord(line))
+	}
+
+	// 非 ` 开头的行为汇总表头
+	s.done = true
+	summaryHeader := splitHeader(line)
+	line, err = s.readLine()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if err == io.EOF {
+		s.summary = map[string]string{}
+		return nil, io.EOF
+	}
+	s.summary, err = s.record(indexHeader(summaryHeader), splitRecord(line))
+	if err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (s *statementReader) record(header map[string]int, fields []string) (map[string]string, error) {
+	if len(fields) < len(header) {
+		return nil, fmt.Errorf("bill record has %d fields, header has %d", len(fields), len(header))
+	}
+	m := make(map[string]string, len(header))
+	for name, i := range header {
+		m[name] = fields[i]
+	}
+	return m, nil
+}
+
+func splitHeader(line string) []string {
+	fields := strings.Split(line, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// splitRecord 拆分数据行，每个字段前都带有 ` 以防止被表格软件转换格式
+func splitRecord(line string) []string {
+	fields := strings.Split(strings.TrimPrefix(line, "`"), ",`")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+func indexHeader(fields []string) map[string]int {
+	m := make(map[string]int, len(fields))
+	for i, name := range fields {
+		m[name] = i
+	}
+	return m
+}
+
+// pick 返回第一个存在的列的值，用于兼容新旧版账单的列名
+func pick(m map[string]string, names ...string) string {
+	for _, name := range names {
+		if v, ok := m[name]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// fieldParser 解析账单中的金额及数量字段，记录遇到的第一个错误
+type fieldParser struct {
+	err error
+}
+
+// fen 将以元为单位的金额转换为分，金额最多两位小数
+func (p *fieldParser) fen(s string) money.Fen {
+	return p.yuan(s, money.ParseYuan)
+}
+
+// roundFen 将以元为单位、可能精确到分以下的金额（如手续费 0.00600）四舍五入到分
+func (p *fieldParser) roundFen(s string) money.Fen {
+	return p.yuan(s, money.ParseYuanRound)
+}
+
+func (p *fieldParser) yuan(s string, parse func(string) (money.Fen, error)) money.Fen {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "¥")
+	if s == "" {
+		return 0
+	}
+	f, err := parse(s)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("parse bill amount: %w", err)
+	}
+	return f
+}
+
+func (p *fieldParser) int(s string) int64 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("parse bill count: %w", err)
+	}
+	return n
+}
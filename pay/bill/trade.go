@@ -0,0 +1,145 @@
+package bill
+
+import (
+	"io"
+
+	"github.com/kuro-liang/wechat-go/pay/money"
+)
+
+// TradeRow 交易账单中的一条记录，金额单位为分
+// 不同账单类型（ALL/SUCCESS/REFUND）的列不完全相同，缺失的列保持零值
+type TradeRow struct {
+	TradeTime          string    // 交易时间
+	AppID              string    // 公众账号ID
+	MchID              string    // 商户号
+	SubMchID           string    // 特约商户号
+	DeviceInfo         string    // 设备号
+	TransactionID      string    // 微信订单号
+	OutTradeNo         string    // 商户订单号
+	OpenID             string    // 用户标识
+	TradeType          string    // 交易类型
+	TradeState         string    // 交易状态
+	BankType           string    // 付款银行
+	FeeType            string    // 货币种类
+	SettlementTotalFee money.Fen // 应结订单金额
+	CouponFee          money.Fen // 代金券金额
+	RefundID           string    // 微信退款单号
+	OutRefundNo        string    // 商户退款单号
+	RefundFee          money.Fen // 退款金额
+	CouponRefundFee    money.Fen // 充值券退款金额
+	RefundType         string    // 退款类型
+	RefundStatus       string    // 退款状态
+	Body               string    // 商品名称
+	Attach             string    // 商户数据包
+	PoundageFee        money.Fen // 手续费，按四舍五入保留到分
+	Poundage           string    // 手续费原始值（元），保留分以下的精度，用于精确对账
+	Rate               string    // 费率
+	TotalFee           money.Fen // 订单金额
+	ApplyRefundFee     money.Fen // 申请退款金额
+	RateRemark         string    // 费率备注
+}
+
+// TradeSummary 交易账单汇总，金额单位为分
+type TradeSummary struct {
+	TotalCount         int64     // 总交易单数
+	SettlementTotalFee money.Fen // 应结订单总金额
+	RefundFee          money.Fen // 退款总金额
+	CouponRefundFee    money.Fen // 充值券退款总金额
+	PoundageFee        money.Fen // 手续费总金额，按四舍五入保留到分
+	Poundage           string    // 手续费总金额原始值（元）
+	TotalFee           money.Fen // 订单总金额
+	ApplyRefundFee     money.Fen // 申请退款总金额
+}
+
+// TradeReader 流式读取交易账单
+type TradeReader struct {
+	s *statementReader
+}
+
+// NewTradeReader 创建交易账单读取器
+func NewTradeReader(r io.Reader) *TradeReader {
+	return &TradeReader{s: newStatementReader(r)}
+}
+
+// Read 读取下一条交易记录，读完所有记录后返回 io.EOF
+func (r *TradeReader) Read() (*TradeRow, error) {
+	m, err := r.s.next()
+	if err != nil {
+		return nil, err
+	}
+	var p fieldParser
+	row := &TradeRow{
+		TradeTime:          m["交易时间"],
+		AppID:              m["公众账号ID"],
+		MchID:              m["商户号"],
+		SubMchID:           pick(m, "特约商户号", "子商户号"),
+		DeviceInfo:         m["设备号"],
+		TransactionID:      m["微信订单号"],
+		OutTradeNo:         m["商户订单号"],
+		OpenID:             m["用户标识"],
+		TradeType:          m["交易类型"],
+		TradeState:         m["交易状态"],
+		BankType:           m["付款银行"],
+		FeeType:            m["货币种类"],
+		SettlementTotalFee: p.fen(pick(m, "应结订单金额", "总金额")),
+		CouponFee:          p.fen(pick(m, "代金券金额", "代金券或立减优惠金额", "企业红包金额")),
+		RefundID:           m["微信退款单号"],
+		OutRefundNo:        m["商户退款单号"],
+		RefundFee:          p.fen(m["退款金额"]),
+		CouponRefundFee:    p.fen(pick(m, "充值券退款金额", "代金券或立减优惠退款金额", "企业红包退款金额")),
+		RefundType:         m["退款类型"],
+		RefundStatus:       m["退款状态"],
+		Body:               m["商品名称"],
+		Attach:             m["商户数据包"],
+		PoundageFee:        p.roundFen(m["手续费"]),
+		Poundage:           m["手续费"],
+		Rate:               m["费率"],
+		TotalFee:           p.fen(m["订单金额"]),
+		ApplyRefundFee:     p.fen(m["申请退款金额"]),
+		RateRemark:         m["费率备注"],
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return row, nil
+}
+
+// Summary 返回账单汇总，需在 Read 返回 io.EOF 后调用
+func (r *TradeReader) Summary() (*TradeSummary, error) {
+	m := r.s.summary
+	if m == nil {
+		return nil, nil
+	}
+	var p fieldParser
+	summary := &TradeSummary{
+		TotalCount:         p.int(m["总交易单数"]),
+		SettlementTotalFee: p.fen(pick(m, "应结订单总金额", "总交易额")),
+		RefundFee:          p.fen(pick(m, "退款总金额", "总退款金额")),
+		CouponRefundFee:    p.fen(pick(m, "充值券退款总金额", "总代金券或立减优惠退款金额", "总企业红包退款金额")),
+		PoundageFee:        p.roundFen(m["手续费总金额"]),
+		Poundage:           m["手续费总金额"],
+		TotalFee:           p.fen(m["订单总金额"]),
+		ApplyRefundFee:     p.fen(m["申请退款总金额"]),
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return summary, nil
+}
+
+// ParseTrade 解析完整的交易账单
+func ParseTrade(r io.Reader) (rows []*TradeRow, summary *TradeSummary, err error) {
+	reader := NewTradeReader(r)
+	for {
+		var row *TradeRow
+		row, err = reader.Read()
+		if err == io.EOF {
+			summary, err = reader.Summary()
+			return
+		}
+		if err != nil {
+			return
+		}
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,220 @@
+package combine
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/pay/notify"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// 合单支付，APIv3 接口，需要在 Config 中配置 KeyPEM、SerialNo 和 APIv3Key
+// https://pay.weixin.qq.com/wiki/doc/apiv3/apis/chapter5_1_1.shtml
+var (
+	prepayGateway = "/v3/combine-transactions/"
+	queryGateway  = "/v3/combine-transactions/out-trade-no/"
+)
+
+// 合单下单的交易类型
+const (
+	TradeTypeJSAPI  = "jsapi"
+	TradeTypeApp    = "app"
+	TradeTypeH5     = "h5"
+	TradeTypeNative = "native"
+)
+
+// Combine 合单支付
+type Combine struct {
+	*config.Config
+}
+
+// NewCombine return an instance of combine package
+func NewCombine(cfg *config.Config) *Combine {
+	return &Combine{cfg}
+}
+
+// Amount 子单金额
+type Amount struct {
+	TotalAmount money.Fen `json:"total_amount"` // 单位分
+	Currency    string    `json:"currency"`     // 默认 CNY
+}
+
+// SettleInfo 结算信息
+type SettleInfo struct {
+	ProfitSharing bool      `json:"profit_sharing,omitempty"` // 是否指定分账
+	SubsidyAmount money.Fen `json:"subsidy_amount,omitempty"` // 补差金额，单位分
+}
+
+// SubOrder 子单信息，最多支持 10 单
+type SubOrder struct {
+	MchID       string      `json:"mchid"`
+	SubMchID    string      `json:"sub_mchid,omitempty"` // 服务商模式下的二级商户号
+	Attach      string      `json:"attach"`
+	Amount      Amount      `json:"amount"`
+	OutTradeNo  string      `json:"out_trade_no"`
+	Description string      `json:"description"`
+	GoodsTag    string      `json:"goods_tag,omitempty"`
+	SettleInfo  *SettleInfo `json:"settle_info,omitempty"`
+}
+
+// SceneInfo 场景信息
+type SceneInfo struct {
+	DeviceID      string  `json:"device_id,omitempty"`
+	PayerClientIP string  `json:"payer_client_ip"`
+	H5Info        *H5Info `json:"h5_info,omitempty"` // H5 必填
+}
+
+// H5Info H5 场景信息
+type H5Info struct {
+	Type string `json:"type"` // iOS, Android, Wap
+}
+
+// PayerInfo 支付者
+type PayerInfo struct {
+	OpenID string `json:"openid"`
+}
+
+// Params 合单下单参数
+type Params struct {
+	CombineOutTradeNo string     `json:"combine_out_trade_no"`
+	SceneInfo         *SceneInfo `json:"scene_info,omitempty"`
+	SubOrders         []SubOrder `json:"sub_orders"`
+	CombinePayerInfo  *PayerInfo `json:"combine_payer_info,omitempty"` // JSAPI 必填
+	TimeStart         string     `json:"time_start,omitempty"`         // rfc3339 格式
+	TimeExpire        string     `json:"time_expire,omitempty"`        // rfc3339 格式
+	NotifyURL         string     `json:"notify_url"`
+}
+
+// prepayRequest 合单下单请求参数
+type prepayRequest struct {
+	CombineAppID string `json:"combine_appid"`
+	CombineMchID string `json:"combine_mchid"`
+	*Params
+}
+
+// PrepayResult 合单下单返回
+type PrepayResult struct {
+	PrepayID string `json:"prepay_id"` // JSAPI/APP
+	CodeURL  string `json:"code_url"`  // Native
+	H5URL    string `json:"h5_url"`    // H5
+}
+
+// Prepay 合单下单，tradeType 为 jsapi、app、h5 或 native
+func (c *Combine) Prepay(tradeType string, p *Params) (res PrepayResult, err error) {
+	if len(p.SubOrders) == 0 {
+		err = errors.New("combine order needs at least one sub order")
+		return
+	}
+	// 默认值写入副本，不修改调用方的参数
+	params := *p
+	if params.NotifyURL == "" {
+		params.NotifyURL = c.NotifyURL
+	}
+	params.SubOrders = make([]SubOrder, len(p.SubOrders))
+	copy(params.SubOrders, p.SubOrders)
+	for i := range params.SubOrders {
+		if params.SubOrders[i].Amount.Currency == "" {
+			params.SubOrders[i].Amount.Currency = "CNY"
+		}
+	}
+	req := prepayRequest{
+		CombineAppID: c.AppID,
+		CombineMchID: c.MchID,
+		Params:       &params,
+	}
+	data, err := c.RequestV3(http.MethodPost, prepayGateway+tradeType, req)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &res)
+	return
+}
+
+// Query 合单查询
+func (c *Combine) Query(combineOutTradeNo string) (res notify.CombineResult, err error) {
+	data, err := c.RequestV3(http.MethodGet, queryGateway+url.PathEscape(combineOutTradeNo), nil)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &res)
+	return
+}
+
+// CloseSubOrder 关单的子单
+type CloseSubOrder struct {
+	MchID      string `json:"mchid"`
+	OutTradeNo string `json:"out_trade_no"`
+	SubMchID   string `json:"sub_mchid,omitempty"`
+}
+
+// closeRequest 合单关单请求参数
+type closeRequest struct {
+	CombineAppID string          `json:"combine_appid"`
+	SubOrders    []CloseSubOrder `json:"sub_orders"`
+}
+
+// Close 合单关单，子单需要全部传入
+func (c *Combine) Close(combineOutTradeNo string, subOrders []CloseSubOrder) error {
+	req := closeRequest{
+		CombineAppID: c.AppID,
+		SubOrders:    subOrders,
+	}
+	_, err := c.RequestV3(http.MethodPost, queryGateway+url.PathEscape(combineOutTradeNo)+"/close", req)
+	return err
+}
+
+// BridgeConfig JSAPI/小程序调起支付的参数
+type BridgeConfig struct {
+	AppID     string `json:"appId"`
+	Timestamp string `json:"timeStamp"`
+	NonceStr  string `json:"nonceStr"`
+	Package   string `json:"package"`
+	SignType  string `json:"signType"`
+	PaySign   string `json:"paySign"`
+}
+
+// BridgeAppConfig APP 调起支付的参数
+type BridgeAppConfig struct {
+	AppID     string `json:"appid"`
+	MchID     string `json:"partnerid"`
+	PrePayID  string `json:"prepayid"`
+	Package   string `json:"package"`
+	NonceStr  string `json:"noncestr"`
+	Timestamp string `json:"timestamp"`
+	Sign      string `json:"sign"`
+}
+
+// BridgeConfig 根据合单下单得到的 prepay_id 生成 JSAPI/小程序调起支付的参数
+func (c *Combine) BridgeConfig(prepayID string) (cfg BridgeConfig, err error) {
+	cfg = BridgeConfig{
+		AppID:     c.AppID,
+		Timestamp: strconv.FormatInt(time.Now().Unix(), 10),
+		NonceStr:  util.RandomStr(32),
+		Package:   "prepay_id=" + prepayID,
+		SignType:  "RSA",
+	}
+	message := cfg.AppID + "\n" + cfg.Timestamp + "\n" + cfg.NonceStr + "\n" + cfg.Package + "\n"
+	cfg.PaySign, err = util.RSASignSHA256(string(c.KeyPEM), []byte(message))
+	return
+}
+
+// BridgeAppConfig 根据合单下单得到的 prepay_id 生成 APP 调起支付的参数
+func (c *Combine) BridgeAppConfig(prepayID string) (cfg BridgeAppConfig, err error) {
+	cfg = BridgeAppConfig{
+		AppID:     c.AppID,
+		MchID:     c.MchID,
+		PrePayID:  prepayID,
+		Package:   "Sign=WXPay",
+		NonceStr:  util.RandomStr(32),
+		Timestamp: strconv.FormatInt(time.Now().Unix(), 10),
+	}
+	message := cfg.AppID + "\n" + cfg.Timestamp + "\n" + cfg.NonceStr + "\n" + cfg.PrePayID + "\n"
+	cfg.Sign, err = util.RSASignSHA256(string(c.KeyPEM), []byte(message))
+	return
+}
@@ -0,0 +1,200 @@
+package combine
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/pay/notify"
+	"github.com/kuro-liang/wechat-go/pay/paytest"
+	"github.com/kuro-liang/wechat-go/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const apiV3Key = "0123456789abcdef0123456789abcdef"
+
+// countingTransport 统计经过自定义 client 发出的请求数
+type countingTransport struct {
+	n int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.n++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestPrepayAndBridgeConfig(t *testing.T) {
+	merchantCert := paytest.NewTestCert(t, "10000100")
+	certPEM, keyPEM := merchantCert.CertPEM, merchantCert.KeyPEM
+	platform := paytest.NewTestCert(t, "wechatpay")
+	authRe := regexp.MustCompile(`nonce_str="([^"]+)",signature="([^"]+)",timestamp="([^"]+)",serial_no="SERIAL"`)
+
+	var sent map[string]interface{}
+	unsigned := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		m := authRe.FindStringSubmatch(r.Header.Get("Authorization"))
+		if m == nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"code":"SIGN_ERROR","message":"签名错误"}`))
+			return
+		}
+		message := r.Method + "\n" + r.URL.RequestURI() + "\n" + m[3] + "\n" + m[1] + "\n" + string(body) + "\n"
+		if err := util.RSAVerifySHA256WithCert(string(certPEM), []byte(message), m[2]); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"code":"SIGN_ERROR","message":"签名错误"}`))
+			return
+		}
+		// 成功的应答由平台证书签名
+		reply := func(status int, data string) {
+			if !unsigned {
+				assert.Nil(t, platform.SignV3(w.Header(), []byte(data)))
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(data))
+		}
+		switch r.URL.Path {
+		case "/v3/combine-transactions/jsapi":
+			_ = json.Unmarshal(body, &sent)
+			reply(http.StatusOK, `{"prepay_id":"wx201410272009395522657a690389285100"}`)
+		case "/v3/combine-transactions/out-trade-no/C001/close":
+			reply(http.StatusNoContent, "")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"ORDER_NOT_EXIST","message":"订单不存在"}`))
+		}
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	cfg := &config.Config{
+		AppID:           "wxd678efh567hg6787",
+		MchID:           "1230000109",
+		NotifyURL:       "https://yourapp.com/notify",
+		BaseURL:         server.URL,
+		KeyPEM:          keyPEM,
+		SerialNo:        "SERIAL",
+		PlatformCertPEM: platform.CertPEM,
+		HTTPClient:      &http.Client{Transport: transport},
+	}
+	c := NewCombine(cfg)
+	params := &Params{
+		CombineOutTradeNo: "C001",
+		CombinePayerInfo:  &PayerInfo{OpenID: "oUpF8uMuAJO_M2pxb1Q9zNjWeS6o"},
+		SubOrders: []SubOrder{
+			{MchID: "1230000109", SubMchID: "1900000109", OutTradeNo: "S001", Description: "腾讯充值中心-QQ会员充值", Amount: Amount{TotalAmount: 10}},
+			{MchID: "1230000109", SubMchID: "1900000110", OutTradeNo: "S002", Description: "腾讯充值中心-QQ会员充值", Amount: Amount{TotalAmount: 20},
+				SettleInfo: &SettleInfo{ProfitSharing: true, SubsidyAmount: 5}},
+		},
+	}
+	res, err := c.Prepay(TradeTypeJSAPI, params)
+	assert.Nil(t, err)
+	assert.Equal(t, "wx201410272009395522657a690389285100", res.PrepayID)
+	// 默认值只用于请求，不写回调用方的参数
+	assert.Empty(t, params.NotifyURL)
+	assert.Empty(t, params.SubOrders[0].Amount.Currency)
+	assert.Equal(t, "wxd678efh567hg6787", sent["combine_appid"])
+	assert.Equal(t, "https://yourapp.com/notify", sent["notify_url"])
+	if subOrders, ok := sent["sub_orders"].([]interface{}); assert.True(t, ok) && assert.Len(t, subOrders, 2) {
+		assert.Equal(t, "CNY", subOrders[0].(map[string]interface{})["amount"].(map[string]interface{})["currency"])
+		assert.Equal(t, map[string]interface{}{"profit_sharing": true, "subsidy_amount": float64(5)}, subOrders[1].(map[string]interface{})["settle_info"])
+	}
+	assert.Equal(t, 1, transport.n)
+
+	assert.Nil(t, c.Close("C001", []CloseSubOrder{{MchID: "1230000109", OutTradeNo: "S001"}}))
+
+	_, err = c.Query("C404")
+	if v3Err, ok := err.(*config.V3Error); assert.True(t, ok) {
+		assert.Equal(t, "ORDER_NOT_EXIST", v3Err.Code)
+	}
+
+	bridge, err := c.BridgeConfig(res.PrepayID)
+	assert.Nil(t, err)
+	message := bridge.AppID + "\n" + bridge.Timestamp + "\n" + bridge.NonceStr + "\n" + bridge.Package + "\n"
+	assert.Nil(t, util.RSAVerifySHA256WithCert(string(certPEM), []byte(message), bridge.PaySign))
+
+	// 未签名的应答及未配置平台证书时均不能信任应答
+	unsigned = true
+	_, err = c.Prepay(TradeTypeJSAPI, params)
+	assert.Error(t, err)
+	unsigned = false
+	cfg.PlatformCertPEM = nil
+	_, err = c.Prepay(TradeTypeJSAPI, params)
+	assert.EqualError(t, err, "platform certificate is not configured")
+}
+
+func TestParseCombineNotify(t *testing.T) {
+	platform := paytest.NewTestCert(t, "wechatpay")
+
+	plaintext := `{"combine_appid":"wxd678efh567hg6787","combine_mchid":"1230000109","combine_out_trade_no":"C001","sub_orders":[{"mchid":"1230000109","trade_type":"JSAPI","trade_state":"SUCCESS","transaction_id":"4200000000201909050000000001","out_trade_no":"S001","amount":{"total_amount":10,"payer_amount":10,"currency":"CNY","payer_currency":"CNY"}}],"combine_payer_info":{"openid":"oUpF8uMuAJO_M2pxb1Q9zNjWeS6o"}}`
+	block, _ := aes.NewCipher([]byte(apiV3Key))
+	gcm, _ := cipher.NewGCM(block)
+	nonce := "fdasflkja484"
+	ciphertext := gcm.Seal(nil, []byte(nonce), []byte(plaintext), []byte("transaction"))
+	body, _ := json.Marshal(notify.V3Notify{
+		ID:        "EV-2018022511223320873",
+		EventType: "TRANSACTION.SUCCESS",
+		Resource: notify.V3Resource{
+			Algorithm:      "AEAD_AES_256_GCM",
+			Ciphertext:     base64.StdEncoding.EncodeToString(ciphertext),
+			AssociatedData: "transaction",
+			Nonce:          nonce,
+		},
+	})
+
+	header := http.Header{}
+	assert.Nil(t, platform.SignV3(header, body))
+
+	n := notify.NewNotify(&config.Config{APIv3Key: apiV3Key, PlatformCertPEM: platform.CertPEM})
+	res, err := n.ParseCombineNotify(header, body)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "C001", res.CombineOutTradeNo)
+		assert.Equal(t, money.Fen(10), res.SubOrders[0].Amount.PayerAmount)
+		assert.Equal(t, "oUpF8uMuAJO_M2pxb1Q9zNjWeS6o", res.CombinePayerInfo.OpenID)
+	}
+
+	tampered := header.Clone()
+	tampered.Set("Wechatpay-Nonce", "tampered")
+	_, err = n.ParseCombineNotify(tampered, body)
+	assert.EqualError(t, err, "v3 notify verify failed: wechatpay signature verify failed")
+
+	// 序列号与平台证书不符
+	tampered = header.Clone()
+	tampered.Set("Wechatpay-Serial", "0")
+	_, err = n.ParseCombineNotify(tampered, body)
+	assert.Error(t, err)
+
+	// 超过 5 分钟的通知视为重放
+	expired := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	signature, err := util.RSASignSHA256(string(platform.KeyPEM), []byte(expired+"\n"+header.Get("Wechatpay-Nonce")+"\n"+string(body)+"\n"))
+	assert.Nil(t, err)
+	tampered = header.Clone()
+	tampered.Set("Wechatpay-Timestamp", expired)
+	tampered.Set("Wechatpay-Signature", signature)
+	_, err = n.ParseCombineNotify(tampered, body)
+	assert.EqualError(t, err, "v3 notify verify failed: wechatpay timestamp "+expired+" expired")
+
+	// 未配置平台证书时不能跳过校验
+	_, err = notify.NewNotify(&config.Config{APIv3Key: apiV3Key}).ParseCombineNotify(header, body)
+	assert.EqualError(t, err, "v3 notify verify failed: platform certificate is not configured")
+
+	// 只接受支付成功的通知
+	var refund notify.V3Notify
+	assert.Nil(t, json.Unmarshal(body, &refund))
+	refund.EventType = "REFUND.SUCCESS"
+	refundBody, _ := json.Marshal(refund)
+	refundHeader := http.Header{}
+	assert.Nil(t, platform.SignV3(refundHeader, refundBody))
+	_, err = n.ParseCombineNotify(refundHeader, refundBody)
+	assert.EqualError(t, err, "unexpected v3 notify event_type=REFUND.SUCCESS")
+}
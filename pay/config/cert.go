@@ -0,0 +1,110 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// TLSClient 返回携带商户证书的 http.Client，证书只解析一次，连接在各接口间复用
+// rootCa 为各接口 Params 中的证书路径，仅在 Config 未配置证书时使用
+// 设置了 HTTPClient 时沿用其超时、代理等设置，HTTPClient 需在首次请求前设置
+func (cfg *Config) TLSClient(rootCa string) (*http.Client, error) {
+	cfg.certMu.Lock()
+	defer cfg.certMu.Unlock()
+
+	if cfg.hasCert() {
+		if cfg.certClient == nil {
+			client, err := cfg.newCertClient()
+			if err != nil {
+				return nil, err
+			}
+			cfg.certClient = cfg.withHTTPClient(client)
+		}
+		return cfg.certClient, nil
+	}
+
+	if rootCa == "" {
+		return nil, errors.New("merchant cert is not configured")
+	}
+	if client, ok := cfg.certClients[rootCa]; ok {
+		return client, nil
+	}
+	p12, err := ioutil.ReadFile(rootCa)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find cert path=%s, error=%v", rootCa, err)
+	}
+	client, err := util.NewTLSClientFromP12(p12, cfg.MchID)
+	if err != nil {
+		return nil, err
+	}
+	client = cfg.withHTTPClient(client)
+	if cfg.certClients == nil {
+		cfg.certClients = make(map[string]*http.Client)
+	}
+	cfg.certClients[rootCa] = client
+	return client, nil
+}
+
+// PostXMLWithCert 使用商户证书发送 xml 请求，加载证书失败时返回原始错误，发送请求后的错误包装为 *RequestError
+func (cfg *Config) PostXMLWithCert(uri string, obj interface{}, rootCa string) ([]byte, error) {
+	client, err := cfg.TLSClient(rootCa)
+	if err != nil {
+		return nil, err
+	}
+	return postXML(client, uri, obj)
+}
+
+// withHTTPClient 在 HTTPClient 的基础上加入商户证书，HTTPClient 的 Transport 不是 *http.Transport 时只沿用超时等设置
+func (cfg *Config) withHTTPClient(certClient *http.Client) *http.Client {
+	if cfg.HTTPClient == nil {
+		return certClient
+	}
+	client := *cfg.HTTPClient
+	certTransport, ok := certClient.Transport.(*http.Transport)
+	if !ok {
+		return certClient
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		client.Transport = certTransport
+		return &client
+	}
+	transport = transport.Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.Certificates = certTransport.TLSClientConfig.Certificates
+	client.Transport = transport
+	return &client
+}
+
+func (cfg *Config) hasCert() bool {
+	return cfg.CertFile != "" || len(cfg.CertP12) > 0 || len(cfg.CertPEM) > 0
+}
+
+func (cfg *Config) newCertClient() (*http.Client, error) {
+	switch {
+	case len(cfg.CertPEM) > 0:
+		if len(cfg.KeyPEM) == 0 {
+			return nil, errors.New("merchant cert key pem is empty")
+		}
+		return util.NewTLSClientFromPEM(cfg.CertPEM, cfg.KeyPEM)
+	case len(cfg.CertP12) > 0:
+		return util.NewTLSClientFromP12(cfg.CertP12, cfg.MchID)
+	default:
+		p12, err := ioutil.ReadFile(cfg.CertFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find cert path=%s, error=%v", cfg.CertFile, err)
+		}
+		return util.NewTLSClientFromP12(p12, cfg.MchID)
+	}
+}
@@ -0,0 +1,83 @@
+package config_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/paytest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLSClient(t *testing.T) {
+	cert := paytest.NewTestCert(t, "10000100")
+	certPEM, keyPEM := cert.CertPEM, cert.KeyPEM
+	cfg := &config.Config{MchID: "10000100", CertPEM: certPEM, KeyPEM: keyPEM}
+
+	client, err := cfg.TLSClient("")
+	assert.Nil(t, err)
+	again, err := cfg.TLSClient("/path/ignored.p12")
+	assert.Nil(t, err)
+	assert.True(t, client == again, "client should be cached")
+
+	_, err = (&config.Config{}).TLSClient("")
+	assert.EqualError(t, err, "merchant cert is not configured")
+
+	_, err = (&config.Config{CertPEM: certPEM}).TLSClient("")
+	assert.EqualError(t, err, "merchant cert key pem is empty")
+
+	_, err = (&config.Config{CertP12: []byte("not a p12"), MchID: "10000100"}).TLSClient("")
+	assert.NotNil(t, err)
+}
+
+// countingTransport 统计经过自定义 client 发出的请求数
+type countingTransport struct {
+	n int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.n++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<xml><return_code>SUCCESS</return_code></xml>`))
+	}))
+	defer server.Close()
+
+	// v2 接口同样使用配置的 client
+	transport := &countingTransport{}
+	cfg := &config.Config{HTTPClient: &http.Client{Transport: transport}}
+	_, err := cfg.PostXML(server.URL, struct {
+		XMLName struct{} `xml:"xml"`
+	}{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, transport.n)
+
+	// 使用商户证书的 client 沿用超时及代理设置
+	cert := paytest.NewTestCert(t, "10000100")
+	proxy := func(*http.Request) (*url.URL, error) { return nil, nil }
+	cfg = &config.Config{
+		MchID:      "10000100",
+		CertPEM:    cert.CertPEM,
+		KeyPEM:     cert.KeyPEM,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{Proxy: proxy}},
+	}
+	client, err := cfg.TLSClient("")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 5*time.Second, client.Timeout)
+	if tr, ok := client.Transport.(*http.Transport); assert.True(t, ok) {
+		assert.NotNil(t, tr.Proxy)
+		assert.Len(t, tr.TLSClientConfig.Certificates, 1)
+	}
+	// 不修改配置的 client
+	if base := cfg.HTTPClient.Transport.(*http.Transport).TLSClientConfig; base != nil {
+		assert.Empty(t, base.Certificates)
+	}
+}
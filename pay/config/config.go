@@ -1,9 +1,179 @@
 package config
 
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// DefaultBaseURL 微信支付接口地址
+const DefaultBaseURL = "https://api.mch.weixin.qq.com"
+
+// sandboxPrefix 仿真测试系统的路径前缀
+// https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=23_1&index=2
+const sandboxPrefix = "/sandboxnew"
+
+// getSignKeyPath 获取仿真测试系统的验签密钥
+var getSignKeyPath = "/sandboxnew/pay/getsignkey"
+
 // Config .config for pay
 type Config struct {
 	AppID     string `json:"app_id"`
 	MchID     string `json:"mch_id"`
 	Key       string `json:"key"`
 	NotifyURL string `json:"notify_url"`
+	SignType  string `json:"sign_type"` // 默认签名类型，MD5 或 HMAC-SHA256，为空时使用 MD5
+	Sandbox   bool   `json:"sandbox"`   // 是否使用仿真测试系统，仿真测试系统仅支持 MD5 签名
+	BaseURL   string `json:"base_url"`  // 接口地址，为空时使用 DefaultBaseURL，可指向本地的模拟网关
+
+	// 服务商模式，AppID、MchID、Key 为服务商的信息，各接口参数中可按次覆盖子商户
+	SubAppID string `json:"sub_app_id"` // 子商户公众账号ID
+	SubMchID string `json:"sub_mch_id"` // 子商户号
+
+	// 商户证书，退款、付款、红包、资金账单等接口需要，三种方式任选其一
+	CertFile string `json:"cert_file"` // apiclient_cert.p12 文件路径
+	CertP12  []byte `json:"-"`         // apiclient_cert.p12 文件内容
+	CertPEM  []byte `json:"-"`         // apiclient_cert.pem 文件内容，需与 KeyPEM 同时设置
+	KeyPEM   []byte `json:"-"`         // apiclient_key.pem 文件内容，APIv3 接口使用该私钥签名
+
+	// APIv3 接口（如合单支付）使用
+	SerialNo        string `json:"serial_no"` // 商户证书序列号
+	APIv3Key        string `json:"apiv3_key"` // APIv3 密钥，用于解密回调通知
+	PlatformCertPEM []byte `json:"-"`         // 微信支付平台证书，用于校验接口应答及回调通知的签名
+
+	// 发送请求使用的 client，可设置超时、代理等，为空时使用 http.DefaultClient
+	HTTPClient *http.Client `json:"-"`
+
+	mu         sync.Mutex
+	sandboxKey string
+	publicKey  string // 付款到银行卡使用的 RSA 公钥
+
+	certMu      sync.Mutex
+	certClient  *http.Client
+	certClients map[string]*http.Client
+}
+
+// GatewayURL 根据接口路径返回完整的请求地址，仿真测试模式下自动加上 /sandboxnew 前缀
+func (cfg *Config) GatewayURL(path string) string {
+	baseURL := cfg.baseURL()
+	if cfg.Sandbox && !strings.HasPrefix(path, sandboxPrefix) {
+		path = sandboxPrefix + path
+	}
+	return baseURL + path
+}
+
+func (cfg *Config) baseURL() string {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return strings.TrimRight(baseURL, "/")
+}
+
+// ResolveSubMerchant 返回本次请求使用的子商户信息，优先使用调用时传入的值，其次是配置的默认值
+func (cfg *Config) ResolveSubMerchant(subAppID, subMchID string) (string, string) {
+	if subMchID == "" {
+		subMchID = cfg.SubMchID
+		if subAppID == "" {
+			subAppID = cfg.SubAppID
+		}
+	}
+	return subAppID, subMchID
+}
+
+// ResolveSignType 返回本次请求使用的签名类型，优先使用调用时传入的值，其次是配置的默认值
+func (cfg *Config) ResolveSignType(signType string) string {
+	if cfg.Sandbox {
+		return util.SignTypeMD5
+	}
+	if signType != "" {
+		return signType
+	}
+	if cfg.SignType != "" {
+		return cfg.SignType
+	}
+	return util.SignTypeMD5
+}
+
+// SignKey 返回签名使用的密钥，仿真测试模式下会请求并缓存仿真测试系统的验签密钥
+func (cfg *Config) SignKey() (string, error) {
+	if !cfg.Sandbox {
+		return cfg.Key, nil
+	}
+
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	if cfg.sandboxKey != "" {
+		return cfg.sandboxKey, nil
+	}
+	key, err := cfg.fetchSandboxKey()
+	if err != nil {
+		return "", err
+	}
+	cfg.sandboxKey = key
+	return key, nil
+}
+
+// PublicKey 返回缓存的付款到银行卡 RSA 公钥，未缓存时返回空字符串
+func (cfg *Config) PublicKey() string {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return cfg.publicKey
+}
+
+// SetPublicKey 缓存付款到银行卡 RSA 公钥，传入空字符串时清除缓存
+func (cfg *Config) SetPublicKey(publicKey string) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	cfg.publicKey = publicKey
+}
+
+// signKeyRequest 获取验签密钥请求参数
+type signKeyRequest struct {
+	MchID    string   `xml:"mch_id"`
+	NonceStr string   `xml:"nonce_str"`
+	Sign     string   `xml:"sign"`
+	XMLName  struct{} `xml:"xml"`
+}
+
+// signKeyResponse 获取验签密钥返回
+type signKeyResponse struct {
+	ReturnCode     string `xml:"return_code"`
+	ReturnMsg      string `xml:"return_msg"`
+	MchID          string `xml:"mch_id"`
+	SandboxSignKey string `xml:"sandbox_signkey"`
+}
+
+// fetchSandboxKey 使用正式的 API 密钥请求仿真测试系统的验签密钥
+func (cfg *Config) fetchSandboxKey() (string, error) {
+	nonceStr := util.RandomStr(32)
+	param := map[string]string{
+		"mch_id":    cfg.MchID,
+		"nonce_str": nonceStr,
+	}
+	sign, err := util.ParamSign(param, cfg.Key)
+	if err != nil {
+		return "", err
+	}
+	req := signKeyRequest{
+		MchID:    cfg.MchID,
+		NonceStr: nonceStr,
+		Sign:     sign,
+	}
+	rawRet, err := cfg.PostXML(cfg.GatewayURL(getSignKeyPath), req)
+	if err != nil {
+		return "", err
+	}
+	var rsp signKeyResponse
+	if err = xml.Unmarshal(rawRet, &rsp); err != nil {
+		return "", err
+	}
+	if rsp.ReturnCode != "SUCCESS" || rsp.SandboxSignKey == "" {
+		return "", fmt.Errorf("get sandbox signkey error, return_code=%s,return_msg=%s", rsp.ReturnCode, rsp.ReturnMsg)
+	}
+	return rsp.SandboxSignKey, nil
 }
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/util"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestGatewayURL(t *testing.T) {
+	cfg := &Config{}
+	assert.Equal(t, "https://api.mch.weixin.qq.com/pay/unifiedorder", cfg.GatewayURL("/pay/unifiedorder"))
+
+	cfg.BaseURL = "http://127.0.0.1:8080/"
+	assert.Equal(t, "http://127.0.0.1:8080/pay/unifiedorder", cfg.GatewayURL("/pay/unifiedorder"))
+
+	cfg.Sandbox = true
+	assert.Equal(t, "http://127.0.0.1:8080/sandboxnew/pay/unifiedorder", cfg.GatewayURL("/pay/unifiedorder"))
+	assert.Equal(t, "http://127.0.0.1:8080/sandboxnew/pay/getsignkey", cfg.GatewayURL(getSignKeyPath))
+}
+
+func TestResolveSignType(t *testing.T) {
+	cfg := &Config{}
+	assert.Equal(t, util.SignTypeMD5, cfg.ResolveSignType(""))
+	cfg.SignType = util.SignTypeHMACSHA256
+	assert.Equal(t, util.SignTypeHMACSHA256, cfg.ResolveSignType(""))
+	assert.Equal(t, util.SignTypeMD5, cfg.ResolveSignType(util.SignTypeMD5))
+	cfg.Sandbox = true
+	assert.Equal(t, util.SignTypeMD5, cfg.ResolveSignType(util.SignTypeHMACSHA256))
+}
+
+func TestSandboxSignKey(t *testing.T) {
+	defer gock.Off()
+	gock.New(DefaultBaseURL).Post(getSignKeyPath).Times(1).
+		Reply(200).
+		BodyString(`<xml><return_code><![CDATA[SUCCESS]]></return_code><return_msg><![CDATA[ok]]></return_msg><mch_id><![CDATA[10000100]]></mch_id><sandbox_signkey><![CDATA[sandbox-key]]></sandbox_signkey></xml>`)
+
+	cfg := &Config{MchID: "10000100", Key: "real-key", Sandbox: true}
+	key, err := cfg.SignKey()
+	assert.Nil(t, err)
+	assert.Equal(t, "sandbox-key", key)
+
+	// 第二次从缓存读取，不再请求
+	key, err = cfg.SignKey()
+	assert.Nil(t, err)
+	assert.Equal(t, "sandbox-key", key)
+	assert.True(t, gock.IsDone())
+
+	key, err = (&Config{Key: "real-key"}).SignKey()
+	assert.Nil(t, err)
+	assert.Equal(t, "real-key", key)
+}
+
+func TestResolveSubMerchant(t *testing.T) {
+	cfg := &Config{SubAppID: "wx-sub", SubMchID: "1900000109"}
+	subAppID, subMchID := cfg.ResolveSubMerchant("", "")
+	assert.Equal(t, "wx-sub", subAppID)
+	assert.Equal(t, "1900000109", subMchID)
+
+	// 按次指定子商户时不使用配置中的子商户 appid
+	subAppID, subMchID = cfg.ResolveSubMerchant("", "1900000110")
+	assert.Equal(t, "", subAppID)
+	assert.Equal(t, "1900000110", subMchID)
+
+	subAppID, subMchID = (&Config{}).ResolveSubMerchant("", "")
+	assert.Equal(t, "", subAppID)
+	assert.Equal(t, "", subMchID)
+}
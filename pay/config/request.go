@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// RequestError 请求已发出但没有收到有效的返回（网络错误、超时、http 状态码错误等），接口的处理结果未知
+type RequestError struct {
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// Client 返回发送请求使用的 http.Client，未设置 HTTPClient 时使用 http.DefaultClient
+func (cfg *Config) Client() *http.Client {
+	if cfg.HTTPClient != nil {
+		return cfg.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// PostXML 发送 xml 请求，发送请求及读取返回时的错误包装为 *RequestError
+func (cfg *Config) PostXML(uri string, obj interface{}) ([]byte, error) {
+	return postXML(cfg.Client(), uri, obj)
+}
+
+func postXML(client *http.Client, uri string, obj interface{}) ([]byte, error) {
+	data, err := util.PostXMLWithClient(client, uri, obj)
+	if err != nil {
+		return nil, &RequestError{Err: err}
+	}
+	return data, nil
+}
+
+// NeedQuery 接口调用失败时是否需要查询确认结果
+// 仅在请求已发出但没有收到返回，或返回了 unknownCodes 中的错误码（如 SYSTEMERROR）时结果未知；
+// 参数校验、签名、证书等本地错误，以及其他业务错误，说明请求没有被处理，不需要查询
+func NeedQuery(err error, errCode string, unknownCodes ...string) bool {
+	if err == nil {
+		return false
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return true
+	}
+	for _, code := range unknownCodes {
+		if errCode == code {
+			return true
+		}
+	}
+	return false
+}
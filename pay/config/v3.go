@@ -0,0 +1,136 @@
+package config
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// 微信支付 APIv3 签名
+// https://pay.weixin.qq.com/wiki/doc/apiv3/wechatpay/wechatpay4_0.shtml
+const v3AuthSchema = "WECHATPAY2-SHA256-RSA2048"
+
+// v3MaxClockSkew 应答及回调通知的时间戳与本地时间允许的最大偏差，超过时视为重放
+// https://pay.weixin.qq.com/wiki/doc/apiv3/wechatpay/wechatpay4_1.shtml
+const v3MaxClockSkew = 5 * time.Minute
+
+// V3Error APIv3 接口返回的错误
+type V3Error struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *V3Error) Error() string {
+	return fmt.Sprintf("wechat pay v3 error, status=%d,code=%s,message=%s", e.StatusCode, e.Code, e.Message)
+}
+
+// V3Authorization 生成 APIv3 请求的 Authorization 头
+// canonicalURL 为请求的绝对路径（含查询参数），如 /v3/combine-transactions/jsapi
+func (cfg *Config) V3Authorization(method, canonicalURL string, body []byte) (string, error) {
+	if len(cfg.KeyPEM) == 0 || cfg.SerialNo == "" {
+		return "", errors.New("merchant private key or serial_no is not configured")
+	}
+	nonceStr := util.RandomStr(32)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	message := method + "\n" + canonicalURL + "\n" + timestamp + "\n" + nonceStr + "\n" + string(body) + "\n"
+	signature, err := util.RSASignSHA256(string(cfg.KeyPEM), []byte(message))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`%s mchid="%s",nonce_str="%s",signature="%s",timestamp="%s",serial_no="%s"`,
+		v3AuthSchema, cfg.MchID, nonceStr, signature, timestamp, cfg.SerialNo), nil
+}
+
+// VerifyV3Signature 使用平台证书校验应答或回调通知的签名，同时校验证书序列号及时间戳
+// 未配置平台证书时返回错误，不能跳过校验
+func (cfg *Config) VerifyV3Signature(header http.Header, body []byte) error {
+	if len(cfg.PlatformCertPEM) == 0 {
+		return errors.New("platform certificate is not configured")
+	}
+	block, _ := pem.Decode(cfg.PlatformCertPEM)
+	if block == nil {
+		return errors.New("platform certificate format error")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+	serialNo := fmt.Sprintf("%X", cert.SerialNumber)
+	if !strings.EqualFold(header.Get("Wechatpay-Serial"), serialNo) {
+		return fmt.Errorf("wechatpay serial %q does not match platform certificate %s", header.Get("Wechatpay-Serial"), serialNo)
+	}
+
+	timestamp := header.Get("Wechatpay-Timestamp")
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid wechatpay timestamp %q", timestamp)
+	}
+	if skew := time.Since(time.Unix(sec, 0)); skew > v3MaxClockSkew || skew < -v3MaxClockSkew {
+		return fmt.Errorf("wechatpay timestamp %s expired", timestamp)
+	}
+
+	message := timestamp + "\n" + header.Get("Wechatpay-Nonce") + "\n" + string(body) + "\n"
+	if err = util.RSAVerifySHA256WithCert(string(cfg.PlatformCertPEM), []byte(message), header.Get("Wechatpay-Signature")); err != nil {
+		return errors.New("wechatpay signature verify failed")
+	}
+	return nil
+}
+
+// RequestV3 发送 APIv3 请求，obj 为 nil 时不发送请求体，返回 2xx 以外的状态码时返回 *V3Error
+// 成功的应答使用平台证书校验签名
+func (cfg *Config) RequestV3(method, path string, obj interface{}) ([]byte, error) {
+	var body []byte
+	if obj != nil {
+		buf := new(bytes.Buffer)
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(obj); err != nil {
+			return nil, err
+		}
+		body = bytes.TrimRight(buf.Bytes(), "\n")
+	}
+
+	authorization, err := cfg.V3Authorization(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest(method, cfg.baseURL()+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", authorization)
+	request.Header.Set("Accept", "application/json")
+	if obj != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := cfg.Client().Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		v3Err := &V3Error{StatusCode: response.StatusCode}
+		_ = json.Unmarshal(data, v3Err)
+		return nil, v3Err
+	}
+	if err = cfg.VerifyV3Signature(response.Header, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
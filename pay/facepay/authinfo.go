@@ -0,0 +1,122 @@
+package facepay
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// 获取调用凭证
+// https://pay.weixin.qq.com/wiki/doc/wxfacepay/develop/sdk-android.html#获取调用凭证-get-wxpayface-authinfo
+var (
+	authInfoGateway = "https://payapp.weixin.qq.com/face/get_wxpayface_authinfo"
+	authInfoPath    = "/face/get_wxpayface_authinfo"
+)
+
+// AuthInfoParams 获取调用凭证参数
+type AuthInfoParams struct {
+	StoreID   string // 门店编号
+	StoreName string // 门店名称
+	DeviceID  string // 终端设备编号
+	Attach    string // 附加字段
+	RawData   string // 刷脸 SDK getWxpayfaceRawdata 返回的 rawdata，已由 SDK 签名，原样传入
+	SubAppID  string // 服务商模式下的子商户公众账号ID，为空时使用 Config 中的配置
+	SubMchID  string // 服务商模式下的子商户号，为空时使用 Config 中的配置
+}
+
+// authInfoRequest 获取调用凭证请求参数
+type authInfoRequest struct {
+	StoreID   string   `xml:"store_id"`
+	StoreName string   `xml:"store_name"`
+	DeviceID  string   `xml:"device_id"`
+	Attach    string   `xml:"attach,omitempty"`
+	RawData   string   `xml:"rawdata"`
+	AppID     string   `xml:"appid"`
+	MchID     string   `xml:"mch_id"`
+	SubAppID  string   `xml:"sub_appid,omitempty"`
+	SubMchID  string   `xml:"sub_mch_id,omitempty"`
+	Now       string   `xml:"now"`
+	Version   string   `xml:"version"`
+	SignType  string   `xml:"sign_type"`
+	NonceStr  string   `xml:"nonce_str"`
+	Sign      string   `xml:"sign"`
+	XMLName   struct{} `xml:"xml"`
+}
+
+// AuthInfoResponse 获取调用凭证返回
+type AuthInfoResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	AuthInfo   string `xml:"authinfo,omitempty"`   // 调用凭证，传给刷脸 SDK 的 getWxpayfaceCode
+	ExpiresIn  int    `xml:"expires_in,omitempty"` // 有效期，单位秒
+	AppID      string `xml:"appid,omitempty"`
+	MchID      string `xml:"mch_id,omitempty"`
+	SubAppID   string `xml:"sub_appid,omitempty"`
+	SubMchID   string `xml:"sub_mch_id,omitempty"`
+	NonceStr   string `xml:"nonce_str,omitempty"`
+	Sign       string `xml:"sign,omitempty"`
+}
+
+// GetAuthInfo 获取刷脸 SDK 的调用凭证，该接口仅支持 MD5 签名
+func (fp *FacePay) GetAuthInfo(p *AuthInfoParams) (rsp *AuthInfoResponse, err error) {
+	subAppID, subMchID := fp.ResolveSubMerchant(p.SubAppID, p.SubMchID)
+	req := authInfoRequest{
+		StoreID:   p.StoreID,
+		StoreName: p.StoreName,
+		DeviceID:  p.DeviceID,
+		Attach:    p.Attach,
+		RawData:   p.RawData,
+		AppID:     fp.AppID,
+		MchID:     fp.MchID,
+		SubAppID:  subAppID,
+		SubMchID:  subMchID,
+		Now:       strconv.FormatInt(time.Now().Unix(), 10),
+		Version:   "1",
+		SignType:  util.SignTypeMD5,
+		NonceStr:  util.RandomStr(32),
+	}
+	key, err := fp.SignKey()
+	if err != nil {
+		return
+	}
+	req.Sign, err = util.ParamSign(map[string]string{
+		"store_id":   req.StoreID,
+		"store_name": req.StoreName,
+		"device_id":  req.DeviceID,
+		"attach":     req.Attach,
+		"rawdata":    req.RawData,
+		"appid":      req.AppID,
+		"mch_id":     req.MchID,
+		"sub_appid":  req.SubAppID,
+		"sub_mch_id": req.SubMchID,
+		"now":        req.Now,
+		"version":    req.Version,
+		"sign_type":  req.SignType,
+		"nonce_str":  req.NonceStr,
+	}, key)
+	if err != nil {
+		return
+	}
+
+	// 该接口域名与其他接口不同，配置了 BaseURL 时使用 BaseURL
+	gateway := authInfoGateway
+	if fp.BaseURL != "" {
+		gateway = fp.GatewayURL(authInfoPath)
+	}
+	rawRet, err := fp.PostXML(gateway, req)
+	if err != nil {
+		return
+	}
+	err = xml.Unmarshal(rawRet, &rsp)
+	if err != nil {
+		return
+	}
+	if rsp.ReturnCode == "SUCCESS" && rsp.AuthInfo != "" {
+		return
+	}
+	err = fmt.Errorf("get wxpayface authinfo error, return_code=%s,return_msg=%s", rsp.ReturnCode, rsp.ReturnMsg)
+	return
+}
@@ -0,0 +1,248 @@
+package facepay
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// 刷脸支付
+// https://pay.weixin.qq.com/wiki/doc/wxfacepay/develop/backend.html
+var (
+	facePayGateway = "/pay/facepay"
+	queryGateway   = "/pay/facepayquery"
+	reverseGateway = "/secapi/pay/facepayreverse"
+)
+
+// 交易状态
+const (
+	TradeStateSuccess    = "SUCCESS"    // 支付成功
+	TradeStateRefund     = "REFUND"     // 转入退款
+	TradeStateNotPay     = "NOTPAY"     // 未支付
+	TradeStateClosed     = "CLOSED"     // 已关闭
+	TradeStateRevoked    = "REVOKED"    // 已撤销
+	TradeStateUserPaying = "USERPAYING" // 用户支付中
+	TradeStatePayError   = "PAYERROR"   // 支付失败
+)
+
+// 支付结果未知，需要查询确认的错误码
+const (
+	ErrCodeSystemError   = "SYSTEMERROR"
+	ErrCodeBankError     = "BANKERROR"
+	ErrCodeUserPaying    = "USERPAYING"
+	ErrCodeOrderNotExist = "ORDERNOTEXIST"
+)
+
+// FacePay 刷脸支付
+type FacePay struct {
+	*config.Config
+}
+
+// NewFacePay return an instance of facepay package
+func NewFacePay(cfg *config.Config) *FacePay {
+	return &FacePay{cfg}
+}
+
+// Params 刷脸支付参数
+type Params struct {
+	DeviceInfo string    // 终端设备号
+	Body       string    // 商品描述
+	Detail     string    // 商品详情
+	Attach     string    // 附加数据
+	OutTradeNo string    // 商户订单号
+	TotalFee   money.Fen // 订单金额，单位分
+	FeeType    string    // 货币类型，默认 CNY
+	CreateIP   string    // 终端IP
+	GoodsTag   string    // 订单优惠标记
+	OpenID     string    // 刷脸 SDK 返回的用户 openid
+	FaceCode   string    // 刷脸 SDK 返回的人脸凭证
+	SignType   string    // 签名类型，为空时使用 Config 中的配置
+	SubAppID   string    // 服务商模式下的子商户公众账号ID，为空时使用 Config 中的配置
+	SubMchID   string    // 服务商模式下的子商户号，为空时使用 Config 中的配置
+}
+
+// QueryParams 查询及撤销订单参数，商户订单号与微信订单号二选一
+type QueryParams struct {
+	OutTradeNo    string
+	TransactionID string
+	SignType      string
+	SubAppID      string
+	SubMchID      string
+	RootCa        string // ca证书，撤销订单时使用，Config 中已配置商户证书时可不传
+}
+
+// request 刷脸支付接口请求参数
+type request struct {
+	AppID          string    `xml:"appid"`
+	MchID          string    `xml:"mch_id"`
+	SubAppID       string    `xml:"sub_appid,omitempty"`
+	SubMchID       string    `xml:"sub_mch_id,omitempty"`
+	DeviceInfo     string    `xml:"device_info,omitempty"`
+	NonceStr       string    `xml:"nonce_str"`
+	Sign           string    `xml:"sign"`
+	SignType       string    `xml:"sign_type,omitempty"`
+	Body           string    `xml:"body,omitempty"`
+	Detail         string    `xml:"detail,omitempty"`
+	Attach         string    `xml:"attach,omitempty"`
+	OutTradeNo     string    `xml:"out_trade_no,omitempty"`
+	TransactionID  string    `xml:"transaction_id,omitempty"`
+	TotalFee       money.Fen `xml:"total_fee,omitempty"`
+	FeeType        string    `xml:"fee_type,omitempty"`
+	SpbillCreateIP string    `xml:"spbill_create_ip,omitempty"`
+	GoodsTag       string    `xml:"goods_tag,omitempty"`
+	OpenID         string    `xml:"openid,omitempty"`
+	FaceCode       string    `xml:"face_code,omitempty"`
+	XMLName        struct{}  `xml:"xml"`
+}
+
+// params 参与签名的参数
+func (req *request) params() map[string]string {
+	param := map[string]string{
+		"appid":            req.AppID,
+		"mch_id":           req.MchID,
+		"sub_appid":        req.SubAppID,
+		"sub_mch_id":       req.SubMchID,
+		"device_info":      req.DeviceInfo,
+		"nonce_str":        req.NonceStr,
+		"sign_type":        req.SignType,
+		"body":             req.Body,
+		"detail":           req.Detail,
+		"attach":           req.Attach,
+		"out_trade_no":     req.OutTradeNo,
+		"transaction_id":   req.TransactionID,
+		"fee_type":         req.FeeType,
+		"spbill_create_ip": req.SpbillCreateIP,
+		"goods_tag":        req.GoodsTag,
+		"openid":           req.OpenID,
+		"face_code":        req.FaceCode,
+	}
+	if req.TotalFee > 0 {
+		param["total_fee"] = req.TotalFee.String()
+	}
+	return param
+}
+
+// Response 刷脸支付、查询及撤销订单返回
+type Response struct {
+	ReturnCode     string    `xml:"return_code"`
+	ReturnMsg      string    `xml:"return_msg"`
+	AppID          string    `xml:"appid,omitempty"`
+	MchID          string    `xml:"mch_id,omitempty"`
+	SubAppID       string    `xml:"sub_appid,omitempty"`
+	SubMchID       string    `xml:"sub_mch_id,omitempty"`
+	DeviceInfo     string    `xml:"device_info,omitempty"`
+	NonceStr       string    `xml:"nonce_str,omitempty"`
+	Sign           string    `xml:"sign,omitempty"`
+	ResultCode     string    `xml:"result_code,omitempty"`
+	ErrCode        string    `xml:"err_code,omitempty"`
+	ErrCodeDes     string    `xml:"err_code_des,omitempty"`
+	OpenID         string    `xml:"openid,omitempty"`
+	IsSubscribe    string    `xml:"is_subscribe,omitempty"`
+	SubOpenID      string    `xml:"sub_openid,omitempty"`
+	SubIsSubscribe string    `xml:"sub_is_subscribe,omitempty"`
+	TradeType      string    `xml:"trade_type,omitempty"`
+	TradeState     string    `xml:"trade_state,omitempty"` // 查询返回
+	TradeStateDesc string    `xml:"trade_state_desc,omitempty"`
+	BankType       string    `xml:"bank_type,omitempty"`
+	FeeType        string    `xml:"fee_type,omitempty"`
+	TotalFee       money.Fen `xml:"total_fee,omitempty"`
+	CashFeeType    string    `xml:"cash_fee_type,omitempty"`
+	CashFee        money.Fen `xml:"cash_fee,omitempty"`
+	TransactionID  string    `xml:"transaction_id,omitempty"`
+	OutTradeNo     string    `xml:"out_trade_no,omitempty"`
+	Attach         string    `xml:"attach,omitempty"`
+	TimeEnd        string    `xml:"time_end,omitempty"`
+	Recall         string    `xml:"recall,omitempty"` // 撤销返回，Y 表示需要继续调用撤销
+}
+
+// Pay 刷脸支付，使用刷脸 SDK 返回的 face_code 与 openid 扣款
+// 返回 USERPAYING、SYSTEMERROR 等支付结果未知的错误时，需调用 Query 确认，或使用 SafePay
+func (fp *FacePay) Pay(p *Params) (rsp *Response, err error) {
+	if err = money.OrderLimit.Check(p.TotalFee); err != nil {
+		return
+	}
+	subAppID, subMchID := fp.ResolveSubMerchant(p.SubAppID, p.SubMchID)
+	req := &request{
+		AppID:          fp.AppID,
+		MchID:          fp.MchID,
+		SubAppID:       subAppID,
+		SubMchID:       subMchID,
+		DeviceInfo:     p.DeviceInfo,
+		NonceStr:       util.RandomStr(32),
+		SignType:       fp.ResolveSignType(p.SignType),
+		Body:           p.Body,
+		Detail:         p.Detail,
+		Attach:         p.Attach,
+		OutTradeNo:     p.OutTradeNo,
+		TotalFee:       p.TotalFee,
+		FeeType:        p.FeeType,
+		SpbillCreateIP: p.CreateIP,
+		GoodsTag:       p.GoodsTag,
+		OpenID:         p.OpenID,
+		FaceCode:       p.FaceCode,
+	}
+	return fp.send(facePayGateway, req, false, "")
+}
+
+// Query 查询刷脸支付订单
+func (fp *FacePay) Query(p *QueryParams) (rsp *Response, err error) {
+	return fp.send(queryGateway, fp.queryRequest(p), false, "")
+}
+
+// Reverse 撤销刷脸支付订单，未支付的订单会被关闭，已支付的订单会退款
+// 返回的 Recall 为 Y 时需要再次调用撤销
+func (fp *FacePay) Reverse(p *QueryParams) (rsp *Response, err error) {
+	return fp.send(reverseGateway, fp.queryRequest(p), true, p.RootCa)
+}
+
+func (fp *FacePay) queryRequest(p *QueryParams) *request {
+	subAppID, subMchID := fp.ResolveSubMerchant(p.SubAppID, p.SubMchID)
+	return &request{
+		AppID:         fp.AppID,
+		MchID:         fp.MchID,
+		SubAppID:      subAppID,
+		SubMchID:      subMchID,
+		NonceStr:      util.RandomStr(32),
+		SignType:      fp.ResolveSignType(p.SignType),
+		OutTradeNo:    p.OutTradeNo,
+		TransactionID: p.TransactionID,
+	}
+}
+
+func (fp *FacePay) send(gateway string, req *request, withCert bool, rootCa string) (rsp *Response, err error) {
+	key, err := fp.SignKey()
+	if err != nil {
+		return
+	}
+	req.Sign, err = util.ParamSign(req.params(), key)
+	if err != nil {
+		return
+	}
+
+	var rawRet []byte
+	if withCert {
+		rawRet, err = fp.PostXMLWithCert(fp.GatewayURL(gateway), req, rootCa)
+	} else {
+		rawRet, err = fp.PostXML(fp.GatewayURL(gateway), req)
+	}
+	if err != nil {
+		return
+	}
+	err = xml.Unmarshal(rawRet, &rsp)
+	if err != nil {
+		return
+	}
+	if rsp.ReturnCode == "SUCCESS" {
+		if rsp.ResultCode == "SUCCESS" {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("facepay error, errcode=%s,errmsg=%s", rsp.ErrCode, rsp.ErrCodeDes)
+		return
+	}
+	err = fmt.Errorf("[msg : xmlUnmarshalError] [rawReturn : %s] [sign : %s]", string(rawRet), req.Sign)
+	return
+}
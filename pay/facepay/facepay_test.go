@@ -0,0 +1,132 @@
+package facepay
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/paytest"
+	"github.com/kuro-liang/wechat-go/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const testKey = "ziR0QKsTUfMOuochC9RfCdmfHECorQAP"
+
+// fakeGateway 模拟刷脸支付接口，支付时返回 USERPAYING，查询若干次后返回 state
+type fakeGateway struct {
+	mu       sync.Mutex
+	state    string // 查询最终返回的交易状态
+	pending  int    // 返回 USERPAYING 的查询次数
+	queries  int
+	reverses int
+	authInfo map[string]string
+}
+
+func (g *fakeGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	param, err := util.XMLToMap(body)
+	if err != nil {
+		fmt.Fprint(w, `<xml><return_code>FAIL</return_code><return_msg>invalid request</return_msg></xml>`)
+		return
+	}
+	if sign, _ := util.ParamSign(param, testKey); sign != param["sign"] {
+		fmt.Fprint(w, `<xml><return_code>FAIL</return_code><return_msg>签名错误</return_msg></xml>`)
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch r.URL.Path {
+	case authInfoPath:
+		g.authInfo = param
+		fmt.Fprint(w, `<xml><return_code>SUCCESS</return_code><return_msg>请求成功</return_msg><authinfo><![CDATA[authinfo-demo]]></authinfo><expires_in>3600</expires_in></xml>`)
+	case facePayGateway:
+		fmt.Fprint(w, `<xml><return_code>SUCCESS</return_code><result_code>FAIL</result_code><err_code>USERPAYING</err_code><err_code_des>需要用户输入支付密码</err_code_des></xml>`)
+	case queryGateway:
+		g.queries++
+		state := g.state
+		if g.queries <= g.pending {
+			state = TradeStateUserPaying
+		}
+		fmt.Fprintf(w, `<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><out_trade_no>%s</out_trade_no><trade_state>%s</trade_state><transaction_id>4200000001</transaction_id><total_fee>1</total_fee></xml>`, param["out_trade_no"], state)
+	case reverseGateway:
+		g.reverses++
+		recall := "N"
+		if g.reverses == 1 {
+			recall = "Y"
+		}
+		fmt.Fprintf(w, `<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><recall>%s</recall></xml>`, recall)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestFacePay(t *testing.T, g *fakeGateway) (*FacePay, func()) {
+	cert := paytest.NewTestCert(t, "10000100")
+	srv := httptest.NewServer(g)
+	cfg := &config.Config{
+		AppID:    "wx2421b1c4370ec43b",
+		MchID:    "10000100",
+		Key:      testKey,
+		SignType: util.SignTypeHMACSHA256,
+		BaseURL:  srv.URL,
+		CertPEM:  cert.CertPEM,
+		KeyPEM:   cert.KeyPEM,
+	}
+	return NewFacePay(cfg), srv.Close
+}
+
+func TestGetAuthInfo(t *testing.T) {
+	g := &fakeGateway{}
+	fp, closeFn := newTestFacePay(t, g)
+	defer closeFn()
+
+	rsp, err := fp.GetAuthInfo(&AuthInfoParams{StoreID: "1001", StoreName: "门店", DeviceID: "dev-1", RawData: "raw-data"})
+	assert.Nil(t, err)
+	assert.Equal(t, "authinfo-demo", rsp.AuthInfo)
+	assert.Equal(t, 3600, rsp.ExpiresIn)
+	assert.Equal(t, util.SignTypeMD5, g.authInfo["sign_type"])
+	assert.Equal(t, "raw-data", g.authInfo["rawdata"])
+}
+
+func TestSafePay(t *testing.T) {
+	g := &fakeGateway{state: TradeStateSuccess, pending: 2}
+	fp, closeFn := newTestFacePay(t, g)
+	defer closeFn()
+
+	p := &Params{Body: "test", OutTradeNo: "1415757673", TotalFee: 1, OpenID: "openid", FaceCode: "face-code"}
+	_, err := fp.Pay(p)
+	assert.EqualError(t, err, "facepay error, errcode=USERPAYING,errmsg=需要用户输入支付密码")
+
+	rsp, err := fp.SafePay(p, 0, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, TradeStateSuccess, rsp.TradeState)
+	assert.Equal(t, "4200000001", rsp.TransactionID)
+	assert.Equal(t, 0, g.reverses)
+}
+
+func TestSafePayReverse(t *testing.T) {
+	g := &fakeGateway{state: TradeStateSuccess, pending: 10}
+	fp, closeFn := newTestFacePay(t, g)
+	defer closeFn()
+
+	// 3 次查询前及第 2 次撤销前各等待 interval
+	interval := 10 * time.Millisecond
+	start := time.Now()
+	_, err := fp.SafePay(&Params{Body: "test", OutTradeNo: "1415757674", TotalFee: 1, FaceCode: "face-code"}, interval, 3)
+	assert.Contains(t, err.Error(), "not paid and reversed")
+	assert.Equal(t, 3, g.queries)
+	assert.Equal(t, 2, g.reverses)
+	assert.True(t, time.Since(start) >= 4*interval)
+
+	// 本地参数校验失败时没有发起扣款，不查询也不撤销
+	_, err = fp.SafePay(&Params{OutTradeNo: "1415757675"}, 0, 3)
+	assert.Error(t, err)
+	assert.Equal(t, 3, g.queries)
+	assert.Equal(t, 2, g.reverses)
+}
@@ -0,0 +1,69 @@
+package facepay
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+)
+
+// SafePay 按付款码支付的流程完成刷脸支付
+// 支付结果未知时每隔 interval 查询一次订单，最多查询 times 次；
+// 仍未确认支付成功时撤销订单，避免用户稍后支付成功而商户未发货，需要继续撤销时同样间隔 interval
+// 参数校验、签名等本地错误及其他业务错误说明没有发起扣款，直接返回
+func (fp *FacePay) SafePay(p *Params, interval time.Duration, times int) (rsp *Response, err error) {
+	rsp, err = fp.Pay(p)
+	if !needQuery(rsp, err) {
+		return
+	}
+	payErr := err
+
+	query := &QueryParams{OutTradeNo: p.OutTradeNo, SignType: p.SignType, SubAppID: p.SubAppID, SubMchID: p.SubMchID}
+	for i := 0; i < times; i++ {
+		time.Sleep(interval)
+		q, queryErr := fp.Query(query)
+		if queryErr != nil {
+			if q != nil && q.ReturnCode == "SUCCESS" && q.ErrCode != ErrCodeSystemError && q.ErrCode != ErrCodeOrderNotExist {
+				// 查询参数错误等无法通过重试恢复的错误，直接撤销
+				break
+			}
+			continue
+		}
+		switch q.TradeState {
+		case TradeStateSuccess:
+			return q, nil
+		case TradeStateUserPaying, TradeStateNotPay:
+			continue
+		default:
+			return q, fmt.Errorf("facepay %s is %s: %s", p.OutTradeNo, q.TradeState, q.TradeStateDesc)
+		}
+	}
+
+	if reverseErr := fp.reverse(query, times, interval); reverseErr != nil {
+		return rsp, fmt.Errorf("facepay error: %v, reverse error: %v", payErr, reverseErr)
+	}
+	return rsp, fmt.Errorf("facepay %s not paid and reversed: %v", p.OutTradeNo, payErr)
+}
+
+// reverse 撤销订单，Recall 为 Y 时间隔 interval 后重试，最多重试 retries 次
+func (fp *FacePay) reverse(p *QueryParams, retries int, interval time.Duration) error {
+	for i := 0; ; i++ {
+		rsp, err := fp.Reverse(p)
+		if rsp == nil || rsp.Recall != "Y" {
+			return err
+		}
+		if i >= retries {
+			return fmt.Errorf("reverse %s still needs recall after %d retries", p.OutTradeNo, retries)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// needQuery 支付结果是否未知，用户支付中也需要查询确认
+func needQuery(rsp *Response, err error) bool {
+	errCode := ""
+	if rsp != nil {
+		errCode = rsp.ErrCode
+	}
+	return config.NeedQuery(err, errCode, ErrCodeSystemError, ErrCodeBankError, ErrCodeUserPaying)
+}
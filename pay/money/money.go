@@ -0,0 +1,133 @@
+// Package money 微信支付金额，接口中的金额均以分为单位
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Fen 以分为单位的金额
+type Fen int64
+
+// 各接口的金额限制
+var (
+	// OrderLimit 下单金额，最小 0.01 元
+	OrderLimit = Limit{Min: 1}
+	// RefundLimit 退款金额，最小 0.01 元，且不超过订单金额
+	RefundLimit = Limit{Min: 1}
+	// WalletTransferLimit 付款到零钱，单笔 0.3 元至 2 万元
+	WalletTransferLimit = Limit{Min: 30, Max: 2000000}
+	// BankTransferLimit 付款到银行卡，单笔 0.01 元至 2 万元
+	BankTransferLimit = Limit{Min: 1, Max: 2000000}
+	// RedPackLimit 现金红包及小程序红包，单个红包 1 元至 200 元
+	RedPackLimit = Limit{Min: 100, Max: 20000}
+	// RedPackSceneLimit 传入场景 id 时的现金红包，单个红包 0.3 元至 5000 元
+	RedPackSceneLimit = Limit{Min: 30, Max: 500000}
+)
+
+// Limit 金额范围，Max 为 0 时不限制上限
+type Limit struct {
+	Min Fen
+	Max Fen
+}
+
+// Check 校验金额是否在范围内
+func (l Limit) Check(f Fen) error {
+	if f < l.Min || (l.Max > 0 && f > l.Max) {
+		if l.Max > 0 {
+			return fmt.Errorf("amount %s yuan out of range [%s, %s]", f.Yuan(), l.Min.Yuan(), l.Max.Yuan())
+		}
+		return fmt.Errorf("amount %s yuan less than %s", f.Yuan(), l.Min.Yuan())
+	}
+	return nil
+}
+
+// ParseYuan 将以元为单位的金额转换为分，如 "1.01" 转换为 101，最多支持两位小数
+func ParseYuan(s string) (Fen, error) {
+	return parseYuan(s, false)
+}
+
+// ParseYuanRound 与 ParseYuan 相同，但允许超过两位小数，按四舍五入保留到分
+// 用于账单中精确到分以下的手续费等金额，如 "0.00600" 转换为 1
+func ParseYuanRound(s string) (Fen, error) {
+	return parseYuan(s, true)
+}
+
+func parseYuan(s string, round bool) (Fen, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	parts := strings.SplitN(s, ".", 2)
+	if parts[0] == "" && (len(parts) == 1 || parts[1] == "") {
+		return 0, fmt.Errorf("invalid yuan amount %q", s)
+	}
+
+	var yuan, fen int64
+	var err error
+	if parts[0] != "" {
+		if yuan, err = parseDigits(parts[0]); err != nil {
+			return 0, fmt.Errorf("invalid yuan amount %q", s)
+		}
+	}
+	if len(parts) == 2 {
+		dec := parts[1]
+		if len(dec) > 2 && !round {
+			return 0, fmt.Errorf("yuan amount %q has more than two decimal places", s)
+		}
+		if dec != "" {
+			if _, err = parseDigits(dec); err != nil {
+				return 0, fmt.Errorf("invalid yuan amount %q", s)
+			}
+			fen, _ = strconv.ParseInt((dec + "0")[:2], 10, 64)
+			if len(dec) > 2 && dec[2] >= '5' {
+				fen++
+			}
+		}
+	}
+	total := Fen(yuan*100 + fen)
+	if negative {
+		total = -total
+	}
+	return total, nil
+}
+
+// ParseFen 解析以分为单位的金额，如接口返回的 total_fee
+func ParseFen(s string) (Fen, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return Fen(n), nil
+}
+
+func parseDigits(s string) (int64, error) {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, errors.New("not a number")
+		}
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// String 返回以分为单位的字符串，用于接口参数
+func (f Fen) String() string {
+	return strconv.FormatInt(int64(f), 10)
+}
+
+// Yuan 返回以元为单位、保留两位小数的字符串，如 101 返回 "1.01"
+func (f Fen) Yuan() string {
+	sign := ""
+	n := int64(f)
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, n/100, n%100)
+}
+
+// Int 返回 int 类型的分
+func (f Fen) Int() int {
+	return int(f)
+}
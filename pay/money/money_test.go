@@ -0,0 +1,65 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseYuan(t *testing.T) {
+	cases := map[string]Fen{
+		"1.01":  101,
+		"1":     100,
+		"1.5":   150,
+		"0.07":  7,
+		".3":    30,
+		"-2.10": -210,
+		" 8.8 ": 880,
+	}
+	for s, expected := range cases {
+		f, err := ParseYuan(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, expected, f, s)
+	}
+
+	for _, s := range []string{"", ".", "1.001", "1e2", "abc", "1.a"} {
+		_, err := ParseYuan(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestParseYuanRound(t *testing.T) {
+	cases := map[string]Fen{
+		"0.00600":  1,
+		"0.00499":  0,
+		"1.005":    101,
+		"-0.00600": -1,
+		"12.5":     1250,
+	}
+	for s, expected := range cases {
+		f, err := ParseYuanRound(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, expected, f, s)
+	}
+	_, err := ParseYuanRound("0.0a6")
+	assert.Error(t, err)
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "1.01", Fen(101).Yuan())
+	assert.Equal(t, "0.05", Fen(5).Yuan())
+	assert.Equal(t, "-2.10", Fen(-210).Yuan())
+	assert.Equal(t, "101", Fen(101).String())
+
+	f, err := ParseFen("101")
+	assert.Nil(t, err)
+	assert.Equal(t, Fen(101), f)
+}
+
+func TestLimit(t *testing.T) {
+	assert.Nil(t, OrderLimit.Check(1))
+	assert.EqualError(t, OrderLimit.Check(0), "amount 0.00 yuan less than 0.01")
+	assert.EqualError(t, WalletTransferLimit.Check(29), "amount 0.29 yuan out of range [0.30, 20000.00]")
+	assert.Nil(t, WalletTransferLimit.Check(2000000))
+	assert.Error(t, WalletTransferLimit.Check(2000001))
+}
@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// 合单支付通知
+// https://pay.weixin.qq.com/wiki/doc/apiv3/apis/chapter5_1_13.shtml
+
+// EventTransactionSuccess 支付成功通知的 event_type
+const EventTransactionSuccess = "TRANSACTION.SUCCESS"
+
+// V3Notify APIv3 回调通知
+type V3Notify struct {
+	ID           string     `json:"id"`
+	CreateTime   string     `json:"create_time"`
+	EventType    string     `json:"event_type"` // 支付成功为 TRANSACTION.SUCCESS
+	ResourceType string     `json:"resource_type"`
+	Summary      string     `json:"summary"`
+	Resource     V3Resource `json:"resource"`
+}
+
+// V3Resource 回调通知中加密的数据
+type V3Resource struct {
+	Algorithm      string `json:"algorithm"` // AEAD_AES_256_GCM
+	Ciphertext     string `json:"ciphertext"`
+	AssociatedData string `json:"associated_data"`
+	Nonce          string `json:"nonce"`
+	OriginalType   string `json:"original_type"`
+}
+
+// V3Resp APIv3 回调通知的应答
+type V3Resp struct {
+	Code    string `json:"code"` // SUCCESS/FAIL
+	Message string `json:"message"`
+}
+
+// CombineResult 合单支付结果，同时也是合单查询的返回
+type CombineResult struct {
+	CombineAppID      string            `json:"combine_appid"`
+	CombineMchID      string            `json:"combine_mchid"`
+	CombineOutTradeNo string            `json:"combine_out_trade_no"`
+	SceneInfo         *CombineSceneInfo `json:"scene_info,omitempty"`
+	SubOrders         []CombineSubOrder `json:"sub_orders"`
+	CombinePayerInfo  *CombinePayerInfo `json:"combine_payer_info,omitempty"`
+}
+
+// CombineSceneInfo 场景信息
+type CombineSceneInfo struct {
+	DeviceID string `json:"device_id,omitempty"`
+}
+
+// CombinePayerInfo 支付者
+type CombinePayerInfo struct {
+	OpenID string `json:"openid"`
+}
+
+// CombineSubOrder 子单
+type CombineSubOrder struct {
+	MchID         string        `json:"mchid"`
+	SubMchID      string        `json:"sub_mchid,omitempty"`
+	TradeType     string        `json:"trade_type"`
+	TradeState    string        `json:"trade_state"`
+	BankType      string        `json:"bank_type"`
+	Attach        string        `json:"attach"`
+	SuccessTime   string        `json:"success_time"`
+	TransactionID string        `json:"transaction_id"`
+	OutTradeNo    string        `json:"out_trade_no"`
+	Amount        CombineAmount `json:"amount"`
+}
+
+// CombineAmount 子单金额，单位分
+type CombineAmount struct {
+	TotalAmount   money.Fen `json:"total_amount"`
+	PayerAmount   money.Fen `json:"payer_amount"`
+	Currency      string    `json:"currency"`
+	PayerCurrency string    `json:"payer_currency"`
+}
+
+// ParseV3Notify 校验 APIv3 回调通知的签名并解密，返回通知及解密后的数据
+// 必须配置平台证书，时间戳超过 5 分钟或证书序列号不符的通知视为无效
+func (notify *Notify) ParseV3Notify(header http.Header, body []byte) (*V3Notify, []byte, error) {
+	return notify.parseV3Notify(header, body, "")
+}
+
+// parseV3Notify eventType 不为空时，只解密该类型的通知
+func (notify *Notify) parseV3Notify(header http.Header, body []byte, eventType string) (*V3Notify, []byte, error) {
+	if err := notify.VerifyV3Signature(header, body); err != nil {
+		return nil, nil, fmt.Errorf("v3 notify verify failed: %v", err)
+	}
+
+	res := &V3Notify{}
+	if err := json.Unmarshal(body, res); err != nil {
+		return nil, nil, err
+	}
+	if eventType != "" && res.EventType != eventType {
+		return nil, nil, fmt.Errorf("unexpected v3 notify event_type=%s", res.EventType)
+	}
+	if notify.APIv3Key == "" {
+		return nil, nil, errors.New("apiv3 key is not configured")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(res.Resource.Ciphertext)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := util.AesGCMDecrypt([]byte(notify.APIv3Key), []byte(res.Resource.Nonce), ciphertext, []byte(res.Resource.AssociatedData))
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, plaintext, nil
+}
+
+// ParseCombineNotify 解析合单支付结果通知，event_type 不是 TRANSACTION.SUCCESS 的通知返回 error
+func (notify *Notify) ParseCombineNotify(header http.Header, body []byte) (*CombineResult, error) {
+	_, plaintext, err := notify.parseV3Notify(header, body, EventTransactionSuccess)
+	if err != nil {
+		return nil, err
+	}
+	res := &CombineResult{}
+	if err = json.Unmarshal(plaintext, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,208 @@
+package notify
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/cache"
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// defaultDedupeTimeout 去重记录的保存时间，微信在 24 小时内按递增间隔重复通知
+const defaultDedupeTimeout = 25 * time.Hour
+
+// Handler 支付结果及退款结果通知的 http.Handler
+// 依次完成解析、验签、appid 及商户号校验、金额校验、去重，再调用业务处理函数并返回 SUCCESS/FAIL
+type Handler struct {
+	notify *Notify
+
+	cache          cache.Cache
+	cacheTimeout   time.Duration
+	cacheKeyPrefix string
+
+	amountLookup    func(outTradeNo string) (money.Fen, error)
+	paidHandler     func(res *PaidResult) error
+	refundedHandler func(res *RefundedResult, info *RefundedReqInfo) error
+
+	mu         sync.Mutex
+	processing map[string]bool
+}
+
+// NewHandler 实例化通知处理
+func NewHandler(notify *Notify) *Handler {
+	return &Handler{
+		notify:         notify,
+		cacheTimeout:   defaultDedupeTimeout,
+		cacheKeyPrefix: "gowechat_pay_notify",
+		processing:     make(map[string]bool),
+	}
+}
+
+// SetCache 设置去重使用的缓存，支付通知按 transaction_id、退款通知按 refund_id 去重
+// 业务处理成功后写入缓存，timeout 为 0 时保存 25 小时
+func (h *Handler) SetCache(c cache.Cache, timeout time.Duration) {
+	h.cache = c
+	if timeout > 0 {
+		h.cacheTimeout = timeout
+	}
+}
+
+// SetAmountLookup 设置订单金额查询，用于校验支付通知中的 total_fee，查询返回 error 时通知失败
+func (h *Handler) SetAmountLookup(lookup func(outTradeNo string) (money.Fen, error)) {
+	h.amountLookup = lookup
+}
+
+// SetPaidHandler 设置支付结果通知的业务处理，返回 error 时微信会稍后重新通知
+// 支付失败（result_code 为 FAIL）的通知同样会调用，此时不做金额校验与去重
+func (h *Handler) SetPaidHandler(handler func(res *PaidResult) error) {
+	h.paidHandler = handler
+}
+
+// SetRefundedHandler 设置退款结果通知的业务处理，info 为解密后的退款信息
+func (h *Handler) SetRefundedHandler(handler func(res *RefundedResult, info *RefundedReqInfo) error) {
+	h.refundedHandler = handler
+}
+
+// ServeHTTP 根据是否包含 req_info 区分退款结果通知与支付结果通知
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeResp(w, err)
+		return
+	}
+	params, err := util.XMLToMap(body)
+	if err != nil {
+		writeResp(w, err)
+		return
+	}
+	if _, ok := params["req_info"]; ok {
+		writeResp(w, h.handleRefunded(body, params))
+		return
+	}
+	writeResp(w, h.handlePaid(params))
+}
+
+func (h *Handler) handlePaid(params map[string]string) error {
+	if h.paidHandler == nil {
+		return errors.New("paid handler is not set")
+	}
+	res := newPaidResult(params)
+	if params["return_code"] != "SUCCESS" {
+		return fmt.Errorf("return_code=%s,return_msg=%s", params["return_code"], params["return_msg"])
+	}
+	if !h.notify.PaidVerifySign(*res) {
+		return errors.New("签名失败")
+	}
+	if err := h.checkMerchant(params["appid"], params["mch_id"]); err != nil {
+		return err
+	}
+	if params["result_code"] != "SUCCESS" {
+		return h.paidHandler(res)
+	}
+	// 按微信订单号去重，缺少订单号的通知无法去重，视为无效
+	if params["transaction_id"] == "" {
+		return errors.New("缺少 transaction_id")
+	}
+
+	if h.amountLookup != nil {
+		expected, err := h.amountLookup(params["out_trade_no"])
+		if err != nil {
+			return err
+		}
+		if res.TotalFee == nil || *res.TotalFee != expected {
+			return fmt.Errorf("金额不一致，out_trade_no=%s", params["out_trade_no"])
+		}
+	}
+	return h.dedupe("paid_"+params["transaction_id"], func() error {
+		return h.paidHandler(res)
+	})
+}
+
+func (h *Handler) handleRefunded(body []byte, params map[string]string) error {
+	if h.refundedHandler == nil {
+		return errors.New("refunded handler is not set")
+	}
+	if params["return_code"] != "SUCCESS" {
+		return fmt.Errorf("return_code=%s,return_msg=%s", params["return_code"], params["return_msg"])
+	}
+	if err := h.checkMerchant(params["appid"], params["mch_id"]); err != nil {
+		return err
+	}
+	res := &RefundedResult{}
+	if err := xml.Unmarshal(body, res); err != nil {
+		return err
+	}
+	// 退款结果通知没有签名，能使用 API 密钥解密即视为来自微信
+	info, err := h.notify.DecryptReqInfo(res)
+	if err != nil {
+		return err
+	}
+	if info.RefundID == nil || *info.RefundID == "" {
+		return errors.New("缺少 refund_id")
+	}
+	return h.dedupe("refunded_"+*info.RefundID, func() error {
+		return h.refundedHandler(res, info)
+	})
+}
+
+func (h *Handler) checkMerchant(appID, mchID string) error {
+	if appID != h.notify.AppID || mchID != h.notify.MchID {
+		return fmt.Errorf("appid=%s,mch_id=%s 与配置不一致", appID, mchID)
+	}
+	return nil
+}
+
+// dedupe 已处理过的通知直接返回成功；同一通知正在处理时返回失败，由微信稍后重试
+func (h *Handler) dedupe(id string, fn func() error) error {
+	if h.cache == nil {
+		return fn()
+	}
+	key := fmt.Sprintf("%s_%s", h.cacheKeyPrefix, id)
+	if h.cache.IsExist(key) {
+		return nil
+	}
+
+	h.mu.Lock()
+	// 加锁后再检查一次，避免在上一个请求处理完成后重复执行
+	if h.cache.IsExist(key) {
+		h.mu.Unlock()
+		return nil
+	}
+	if h.processing[key] {
+		h.mu.Unlock()
+		return errors.New("notify is processing")
+	}
+	h.processing[key] = true
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.processing, key)
+		h.mu.Unlock()
+	}()
+
+	if err := fn(); err != nil {
+		return err
+	}
+	// 业务已处理成功，写缓存失败时仍返回成功，以免重复处理
+	_ = h.cache.Set(key, true, h.cacheTimeout)
+	return nil
+}
+
+// writeResp 返回通知的处理结果
+func writeResp(w http.ResponseWriter, err error) {
+	resp := PaidResp{ReturnCode: "SUCCESS", ReturnMsg: "OK"}
+	if err != nil {
+		resp = PaidResp{ReturnCode: "FAIL", ReturnMsg: err.Error()}
+	}
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	_ = xml.NewEncoder(w).Encode(struct {
+		PaidResp
+		XMLName struct{} `xml:"xml"`
+	}{PaidResp: resp})
+}
@@ -0,0 +1,210 @@
+package notify
+
+import (
+	"crypto/aes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/cache"
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const handlerTestKey = "ziR0QKsTUfMOuochC9RfCdmfHECorQAP"
+
+func newHandlerTestNotify() *Notify {
+	return NewNotify(&config.Config{AppID: "wx2421b1c4370ec43b", MchID: "10000100", Key: handlerTestKey})
+}
+
+func encodeTestXML(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString("<xml>")
+	for _, k := range keys {
+		b.WriteString("<" + k + "><![CDATA[" + params[k] + "]]></" + k + ">")
+	}
+	b.WriteString("</xml>")
+	return b.String()
+}
+
+func signedPaidNotify(totalFee string) string {
+	return signPaidNotify(paidNotifyParams(totalFee))
+}
+
+func paidNotifyParams(totalFee string) map[string]string {
+	return map[string]string{
+		"return_code":    "SUCCESS",
+		"result_code":    "SUCCESS",
+		"appid":          "wx2421b1c4370ec43b",
+		"mch_id":         "10000100",
+		"nonce_str":      "5d2b6c2a8db53831f7eda20af46e531c",
+		"openid":         "oUpF8uMEb4qRXf22hE3X68TekukE",
+		"out_trade_no":   "1409811653",
+		"transaction_id": "1004400740201409030005092168",
+		"total_fee":      totalFee,
+		"cash_fee":       totalFee,
+		"trade_type":     "JSAPI",
+		"time_end":       "20140903131540",
+	}
+}
+
+func signPaidNotify(params map[string]string) string {
+	params["sign"], _ = util.ParamSign(params, handlerTestKey)
+	return encodeTestXML(params)
+}
+
+// refundedNotify 使用 API 密钥加密退款结果，生成退款结果通知
+func refundedNotify(plain string) string {
+	sum := md5.Sum([]byte(handlerTestKey))
+	block, _ := aes.NewCipher([]byte(hex.EncodeToString(sum[:])))
+	padded := util.PKCS5Padding([]byte(plain), block.BlockSize())
+	encrypted := make([]byte, len(padded))
+	util.NewECBEncryptor(block).CryptBlocks(encrypted, padded)
+	return encodeTestXML(map[string]string{
+		"return_code": "SUCCESS",
+		"appid":       "wx2421b1c4370ec43b",
+		"mch_id":      "10000100",
+		"nonce_str":   "TeqClE3i0mvn3DrK",
+		"req_info":    base64.StdEncoding.EncodeToString(encrypted),
+	})
+}
+
+func serveNotify(h *Handler, body string) string {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/notify", strings.NewReader(body)))
+	return w.Body.String()
+}
+
+func TestHandlerPaid(t *testing.T) {
+	h := NewHandler(newHandlerTestNotify())
+	h.SetCache(cache.NewMemory(), 0)
+	h.SetAmountLookup(func(outTradeNo string) (money.Fen, error) {
+		if outTradeNo != "1409811653" {
+			return 0, errors.New("order not found")
+		}
+		return 1, nil
+	})
+	calls := 0
+	h.SetPaidHandler(func(res *PaidResult) error {
+		calls++
+		if calls == 1 {
+			return errors.New("db error")
+		}
+		return nil
+	})
+
+	// 业务处理失败时返回 FAIL，重复通知时再次处理
+	assert.Contains(t, serveNotify(h, signedPaidNotify("1")), "<return_code>FAIL</return_code><return_msg>db error</return_msg>")
+	assert.Contains(t, serveNotify(h, signedPaidNotify("1")), "<return_code>SUCCESS</return_code>")
+	assert.Contains(t, serveNotify(h, signedPaidNotify("1")), "<return_code>SUCCESS</return_code>")
+	assert.Equal(t, 2, calls)
+
+	assert.Contains(t, serveNotify(h, signedPaidNotify("2")), "金额不一致")
+
+	tampered := strings.Replace(signedPaidNotify("1"), "JSAPI", "NATIVE", 1)
+	assert.Contains(t, serveNotify(h, tampered), "签名失败")
+	assert.Equal(t, 2, calls)
+
+	// 缺少微信订单号的通知无法去重，不能交给业务处理
+	params := paidNotifyParams("1")
+	delete(params, "transaction_id")
+	assert.Contains(t, serveNotify(h, signPaidNotify(params)), "缺少 transaction_id")
+	assert.Equal(t, 2, calls)
+}
+
+// raceCache 在第一次 IsExist 返回前执行 hook，模拟并发请求在去重检查与加锁之间交错
+type raceCache struct {
+	cache.Cache
+	hook func()
+}
+
+func (c *raceCache) IsExist(key string) bool {
+	exist := c.Cache.IsExist(key)
+	if hook := c.hook; hook != nil {
+		c.hook = nil
+		hook()
+	}
+	return exist
+}
+
+func TestHandlerConcurrentDuplicate(t *testing.T) {
+	h := NewHandler(newHandlerTestNotify())
+	h.SetCache(cache.NewMemory(), 0)
+	var mu sync.Mutex
+	calls := 0
+	h.SetPaidHandler(func(res *PaidResult) error {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveNotify(h, signedPaidNotify("1"))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, calls)
+	assert.Contains(t, serveNotify(h, signedPaidNotify("1")), "<return_code>SUCCESS</return_code>")
+	assert.Equal(t, 1, calls)
+
+	// 第二个请求通过去重检查后，第一个请求已处理完成并释放处理标记，加锁后应再次检查缓存
+	c := &raceCache{Cache: cache.NewMemory()}
+	h.SetCache(c, 0)
+	calls = 0
+	var first string
+	c.hook = func() { first = serveNotify(h, signedPaidNotify("1")) }
+	assert.Contains(t, serveNotify(h, signedPaidNotify("1")), "<return_code>SUCCESS</return_code>")
+	assert.Contains(t, first, "<return_code>SUCCESS</return_code>")
+	assert.Equal(t, 1, calls)
+}
+
+func TestHandlerMerchantMismatch(t *testing.T) {
+	n := newHandlerTestNotify()
+	n.MchID = "10000101"
+	h := NewHandler(n)
+	h.SetPaidHandler(func(res *PaidResult) error { return nil })
+	assert.Contains(t, serveNotify(h, signedPaidNotify("1")), "与配置不一致")
+}
+
+func TestHandlerRefunded(t *testing.T) {
+	body := refundedNotify(`<root><out_refund_no><![CDATA[131811191610442717309]]></out_refund_no><out_trade_no><![CDATA[71106718111915575302817]]></out_trade_no><refund_fee><![CDATA[3960]]></refund_fee><refund_id><![CDATA[50000408942018111907145868882]]></refund_id><refund_status><![CDATA[SUCCESS]]></refund_status><total_fee><![CDATA[3960]]></total_fee><transaction_id><![CDATA[4200000215201811190261405420]]></transaction_id></root>`)
+
+	h := NewHandler(newHandlerTestNotify())
+	h.SetCache(cache.NewMemory(), 0)
+	var refunds []money.Fen
+	h.SetRefundedHandler(func(res *RefundedResult, info *RefundedReqInfo) error {
+		refunds = append(refunds, *info.RefundFee)
+		return nil
+	})
+	assert.Contains(t, serveNotify(h, body), "<return_code>SUCCESS</return_code>")
+	assert.Contains(t, serveNotify(h, body), "<return_code>SUCCESS</return_code>")
+	assert.Equal(t, []money.Fen{3960}, refunds)
+
+	// 缺少退款单号的通知无法去重，不能交给业务处理
+	noRefundID := refundedNotify(`<root><out_refund_no><![CDATA[131811191610442717310]]></out_refund_no><refund_fee><![CDATA[1]]></refund_fee><refund_status><![CDATA[SUCCESS]]></refund_status></root>`)
+	assert.Contains(t, serveNotify(h, noRefundID), "缺少 refund_id")
+	assert.Equal(t, []money.Fen{3960}, refunds)
+
+	// 未设置支付结果处理时，支付通知返回 FAIL
+	assert.Contains(t, serveNotify(h, signedPaidNotify("1")), "paid handler is not set")
+}
@@ -1,60 +1,65 @@
 package notify
 
 import (
-	"fmt"
-	"reflect"
+	"encoding/xml"
+	"errors"
 	"sort"
-	"strings"
+	"strconv"
 
-	"github.com/fatih/structs"
+	"github.com/kuro-liang/wechat-go/pay/money"
 	"github.com/kuro-liang/wechat-go/util"
-	"github.com/spf13/cast"
 )
 
 // doc: https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_7&index=8
 
 // PaidResult 下单回调
+// 使用 xml.Unmarshal 或 ParsePaidResult 解析时会保留全部原始字段，用于验签及读取未声明的字段
 type PaidResult struct {
 	ReturnCode *string `xml:"return_code"`
 	ReturnMsg  *string `xml:"return_msg"`
 
-	AppID              *string `xml:"appid" json:"appid"`
-	MchID              *string `xml:"mch_id"`
-	DeviceInfo         *string `xml:"device_info"`
-	NonceStr           *string `xml:"nonce_str"`
-	Sign               *string `xml:"sign"`
-	SignType           *string `xml:"sign_type"`
-	ResultCode         *string `xml:"result_code"`
-	ErrCode            *string `xml:"err_code"`
-	ErrCodeDes         *string `xml:"err_code_des"`
-	OpenID             *string `xml:"openid"`
-	IsSubscribe        *string `xml:"is_subscribe"`
-	TradeType          *string `xml:"trade_type"`
-	TradeState         *string `xml:"trade_state"`
-	BankType           *string `xml:"bank_type"`
-	TotalFee           *int    `xml:"total_fee"`
-	SettlementTotalFee *int    `xml:"settlement_total_fee"`
-	FeeType            *string `xml:"fee_type"`
-	CashFee            *string `xml:"cash_fee"`
-	CashFeeType        *string `xml:"cash_fee_type"`
-	CouponFee          *int    `xml:"coupon_fee"`
-	CouponCount        *int    `xml:"coupon_count"`
-
-	// coupon_type_$n 这里只声明 3 个，如果有更多的可以自己组合
-	CouponType0 *string `xml:"coupon_type_0"`
-	CouponType1 *string `xml:"coupon_type_1"`
-	CouponType2 *string `xml:"coupon_type_2"`
-	CouponID0   *string `xml:"coupon_id_0"`
-	CouponID1   *string `xml:"coupon_id_1"`
-	CouponID2   *string `xml:"coupon_id_2"`
-	CouponFee0  *string `xml:"coupon_fee_0"`
-	CouponFee1  *string `xml:"coupon_fee_1"`
-	CouponFee2  *string `xml:"coupon_fee_2"`
+	AppID              *string    `xml:"appid" json:"appid"`
+	MchID              *string    `xml:"mch_id"`
+	SubAppID           *string    `xml:"sub_appid"`
+	SubMchID           *string    `xml:"sub_mch_id"`
+	DeviceInfo         *string    `xml:"device_info"`
+	NonceStr           *string    `xml:"nonce_str"`
+	Sign               *string    `xml:"sign"`
+	SignType           *string    `xml:"sign_type"`
+	ResultCode         *string    `xml:"result_code"`
+	ErrCode            *string    `xml:"err_code"`
+	ErrCodeDes         *string    `xml:"err_code_des"`
+	OpenID             *string    `xml:"openid"`
+	IsSubscribe        *string    `xml:"is_subscribe"`
+	SubOpenID          *string    `xml:"sub_openid"`
+	SubIsSubscribe     *string    `xml:"sub_is_subscribe"`
+	TradeType          *string    `xml:"trade_type"`
+	TradeState         *string    `xml:"trade_state"`
+	BankType           *string    `xml:"bank_type"`
+	TotalFee           *money.Fen `xml:"total_fee"`
+	SettlementTotalFee *money.Fen `xml:"settlement_total_fee"`
+	FeeType            *string    `xml:"fee_type"`
+	CashFee            *money.Fen `xml:"cash_fee"`
+	CashFeeType        *string    `xml:"cash_fee_type"`
+	CouponFee          *money.Fen `xml:"coupon_fee"`
+	CouponCount        *int       `xml:"coupon_count"`
+
+	// Coupons 对应 coupon_type_$n、coupon_id_$n、coupon_fee_$n
+	Coupons []Coupon `xml:"-"`
 
 	TransactionID *string `xml:"transaction_id"`
 	OutTradeNo    *string `xml:"out_trade_no"`
 	Attach        *string `xml:"attach"`
 	TimeEnd       *string `xml:"time_end"`
+
+	params map[string]string
+}
+
+// Coupon 订单使用的代金券
+type Coupon struct {
+	CouponType string // CASH/NO_CASH
+	CouponID   string
+	CouponFee  money.Fen
 }
 
 // PaidResp 消息通知返回
@@ -63,47 +68,228 @@ type PaidResp struct {
 	ReturnMsg  string `xml:"return_msg"`
 }
 
-// PaidVerifySign 支付成功结果验签
-func (notify *Notify) PaidVerifySign(notifyRes PaidResult) bool {
-	// STEP1, 转换 struct 为 map，并对 map keys 做排序
-	resMap := structs.Map(notifyRes)
+// ParsePaidResult 解析支付结果通知或订单查询返回的 xml
+func ParsePaidResult(data []byte) (*PaidResult, error) {
+	params, err := util.XMLToMap(data)
+	if err != nil {
+		return nil, err
+	}
+	return newPaidResult(params), nil
+}
 
-	sortedKeys := make([]string, 0, len(resMap))
-	for k := range resMap {
-		sortedKeys = append(sortedKeys, k)
+// ParsePaidNotify 解析支付结果通知并验签，签名错误时返回 error
+func (notify *Notify) ParsePaidNotify(data []byte) (*PaidResult, error) {
+	res, err := ParsePaidResult(data)
+	if err != nil {
+		return nil, err
+	}
+	if !notify.PaidVerifySign(*res) {
+		return nil, errors.New("paid notify sign verify failed")
 	}
-	sort.Strings(sortedKeys)
+	return res, nil
+}
 
-	// STEP2, 对key=value的键值对用&连接起来，略过空值 & sign
-	var signStrings string
-	for _, k := range sortedKeys {
-		value := fmt.Sprintf("%v", cast.ToString(resMap[k]))
-		if value != "" && strings.ToLower(k) != "sign" {
-			signStrings = signStrings + getTagKeyName(k, &notifyRes) + "=" + value + "&"
-		}
+// UnmarshalXML 实现 xml.Unmarshaler，按下标展开代金券并保留全部原始字段
+func (res *PaidResult) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
+	params, err := util.DecodeXMLMap(d)
+	if err != nil {
+		return err
 	}
+	*res = *newPaidResult(params)
+	return nil
+}
+
+// Get 读取原始字段，可用于获取未在结构体中声明的字段
+func (res *PaidResult) Get(key string) string {
+	return res.Params()[key]
+}
 
-	// STEP3, 在键值对的最后加上key=API_KEY
-	signStrings = signStrings + "key=" + notify.Key
+// Params 返回参与签名的全部字段
+// 通过解析得到的结果返回原始字段，手动构造的结果根据结构体字段生成
+func (res *PaidResult) Params() map[string]string {
+	if res.params != nil {
+		return res.params
+	}
+	params := make(map[string]string)
+	setString := func(key string, v *string) {
+		if v != nil {
+			params[key] = *v
+		}
+	}
+	setInt := func(key string, v *int) {
+		if v != nil {
+			params[key] = strconv.Itoa(*v)
+		}
+	}
+	setFen := func(key string, v *money.Fen) {
+		if v != nil {
+			params[key] = v.String()
+		}
+	}
+	setString("return_code", res.ReturnCode)
+	setString("return_msg", res.ReturnMsg)
+	setString("appid", res.AppID)
+	setString("mch_id", res.MchID)
+	setString("sub_appid", res.SubAppID)
+	setString("sub_mch_id", res.SubMchID)
+	setString("device_info", res.DeviceInfo)
+	setString("nonce_str", res.NonceStr)
+	setString("sign", res.Sign)
+	setString("sign_type", res.SignType)
+	setString("result_code", res.ResultCode)
+	setString("err_code", res.ErrCode)
+	setString("err_code_des", res.ErrCodeDes)
+	setString("openid", res.OpenID)
+	setString("is_subscribe", res.IsSubscribe)
+	setString("sub_openid", res.SubOpenID)
+	setString("sub_is_subscribe", res.SubIsSubscribe)
+	setString("trade_type", res.TradeType)
+	setString("trade_state", res.TradeState)
+	setString("bank_type", res.BankType)
+	setFen("total_fee", res.TotalFee)
+	setFen("settlement_total_fee", res.SettlementTotalFee)
+	setString("fee_type", res.FeeType)
+	setFen("cash_fee", res.CashFee)
+	setString("cash_fee_type", res.CashFeeType)
+	setFen("coupon_fee", res.CouponFee)
+	setInt("coupon_count", res.CouponCount)
+	for i, coupon := range res.Coupons {
+		idx := strconv.Itoa(i)
+		params["coupon_type_"+idx] = coupon.CouponType
+		params["coupon_id_"+idx] = coupon.CouponID
+		params["coupon_fee_"+idx] = coupon.CouponFee.String()
+	}
+	setString("transaction_id", res.TransactionID)
+	setString("out_trade_no", res.OutTradeNo)
+	setString("attach", res.Attach)
+	setString("time_end", res.TimeEnd)
+	return params
+}
 
-	// STEP4, 根据SignType计算出签名
-	var signType string
-	if notifyRes.SignType != nil {
-		signType = *notifyRes.SignType
+// PaidVerifySign 支付成功结果验签，对通知中除 sign 外的全部非空字段签名
+func (notify *Notify) PaidVerifySign(notifyRes PaidResult) bool {
+	params := notifyRes.Params()
+	sign, ok := params["sign"]
+	if !ok || sign == "" {
+		return false
 	}
-	sign, err := util.CalculateSign(signStrings, signType, notify.Key)
+
+	key, err := notify.SignKey()
 	if err != nil {
 		return false
 	}
-	if sign != *notifyRes.Sign {
+	// 对 key=value 的键值对按 key 排序后用 & 连接起来，略过空值 & sign，最后加上 key=API_KEY
+	signStrings := util.OrderParam(params, "&key="+key)
+	expected, err := util.CalculateSign(signStrings, params["sign_type"], key)
+	if err != nil {
 		return false
 	}
-	return true
+	return expected == sign
+}
+
+func newPaidResult(params map[string]string) *PaidResult {
+	res := &PaidResult{
+		ReturnCode:         stringField(params, "return_code"),
+		ReturnMsg:          stringField(params, "return_msg"),
+		AppID:              stringField(params, "appid"),
+		MchID:              stringField(params, "mch_id"),
+		SubAppID:           stringField(params, "sub_appid"),
+		SubMchID:           stringField(params, "sub_mch_id"),
+		DeviceInfo:         stringField(params, "device_info"),
+		NonceStr:           stringField(params, "nonce_str"),
+		Sign:               stringField(params, "sign"),
+		SignType:           stringField(params, "sign_type"),
+		ResultCode:         stringField(params, "result_code"),
+		ErrCode:            stringField(params, "err_code"),
+		ErrCodeDes:         stringField(params, "err_code_des"),
+		OpenID:             stringField(params, "openid"),
+		IsSubscribe:        stringField(params, "is_subscribe"),
+		SubOpenID:          stringField(params, "sub_openid"),
+		SubIsSubscribe:     stringField(params, "sub_is_subscribe"),
+		TradeType:          stringField(params, "trade_type"),
+		TradeState:         stringField(params, "trade_state"),
+		BankType:           stringField(params, "bank_type"),
+		TotalFee:           fenField(params, "total_fee"),
+		SettlementTotalFee: fenField(params, "settlement_total_fee"),
+		FeeType:            stringField(params, "fee_type"),
+		CashFee:            fenField(params, "cash_fee"),
+		CashFeeType:        stringField(params, "cash_fee_type"),
+		CouponFee:          fenField(params, "coupon_fee"),
+		CouponCount:        intField(params, "coupon_count"),
+		TransactionID:      stringField(params, "transaction_id"),
+		OutTradeNo:         stringField(params, "out_trade_no"),
+		Attach:             stringField(params, "attach"),
+		TimeEnd:            stringField(params, "time_end"),
+		params:             params,
+	}
+	res.Coupons = parseCoupons(params)
+	return res
 }
 
-func getTagKeyName(key string, notifyRes *PaidResult) string {
-	s := reflect.TypeOf(notifyRes).Elem()
-	f, _ := s.FieldByName(key)
-	name := f.Tag.Get("xml")
-	return name
+// parseCoupons 按下标展开 coupon_type_$n、coupon_id_$n、coupon_fee_$n
+// 不依赖 coupon_count，以免遗漏数量与字段不一致时的代金券
+func parseCoupons(params map[string]string) []Coupon {
+	indexes := make([]int, 0)
+	seen := make(map[int]bool)
+	for _, prefix := range []string{"coupon_id_", "coupon_fee_", "coupon_type_"} {
+		for key := range params {
+			if len(key) <= len(prefix) || key[:len(prefix)] != prefix {
+				continue
+			}
+			n, err := strconv.Atoi(key[len(prefix):])
+			if err != nil || seen[n] {
+				continue
+			}
+			seen[n] = true
+			indexes = append(indexes, n)
+		}
+	}
+	if len(indexes) == 0 {
+		return nil
+	}
+	sort.Ints(indexes)
+
+	coupons := make([]Coupon, 0, len(indexes))
+	for _, n := range indexes {
+		idx := strconv.Itoa(n)
+		fee, _ := money.ParseFen(params["coupon_fee_"+idx])
+		coupons = append(coupons, Coupon{
+			CouponType: params["coupon_type_"+idx],
+			CouponID:   params["coupon_id_"+idx],
+			CouponFee:  fee,
+		})
+	}
+	return coupons
+}
+
+func stringField(params map[string]string, key string) *string {
+	v, ok := params[key]
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+func intField(params map[string]string, key string) *int {
+	v, ok := params[key]
+	if !ok {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+func fenField(params map[string]string, key string) *money.Fen {
+	v, ok := params[key]
+	if !ok {
+		return nil
+	}
+	f, err := money.ParseFen(v)
+	if err != nil {
+		return nil
+	}
+	return &f
 }
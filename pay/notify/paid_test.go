@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"encoding/xml"
+	"fmt"
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const paidKey = "ziR0QKsTUfMOuochC9RfCdmfHECorQAP"
+
+func signedPaidXML(t *testing.T, params map[string]string) []byte {
+	sign, err := util.ParamSign(params, paidKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := "<xml>"
+	for k, v := range params {
+		raw += fmt.Sprintf("<%s><![CDATA[%s]]></%s>", k, v, k)
+	}
+	return []byte(raw + "<sign>" + sign + "</sign></xml>")
+}
+
+func TestParsePaidNotify(t *testing.T) {
+	params := map[string]string{
+		"return_code":    "SUCCESS",
+		"result_code":    "SUCCESS",
+		"appid":          "wx2421b1c4370ec43b",
+		"mch_id":         "10000100",
+		"nonce_str":      "5d2b6c2a8db53831f7eda20af46e531c",
+		"openid":         "oUpF8uMEb4qRXf22hE3X68TekukE",
+		"out_trade_no":   "1409811653",
+		"transaction_id": "1004400740201409030005092168",
+		"total_fee":      "100",
+		"cash_fee":       "60",
+		"coupon_fee":     "40",
+		"coupon_count":   "4",
+		"time_end":       "20140903131540",
+	}
+	for i := 0; i < 4; i++ {
+		params[fmt.Sprintf("coupon_id_%d", i)] = fmt.Sprintf("C%d", i)
+		params[fmt.Sprintf("coupon_fee_%d", i)] = "10"
+		params[fmt.Sprintf("coupon_type_%d", i)] = "CASH"
+	}
+	raw := signedPaidXML(t, params)
+
+	notify := NewNotify(&config.Config{Key: paidKey})
+	res, err := notify.ParsePaidNotify(raw)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, money.Fen(100), *res.TotalFee)
+	assert.Equal(t, money.Fen(60), *res.CashFee)
+	assert.Len(t, res.Coupons, 4)
+	assert.Equal(t, Coupon{CouponType: "CASH", CouponID: "C3", CouponFee: 10}, res.Coupons[3])
+	assert.Equal(t, "20140903131540", res.Get("time_end"))
+
+	// xml.Unmarshal 与 ParsePaidResult 结果一致
+	var unmarshaled PaidResult
+	assert.Nil(t, xml.Unmarshal(raw, &unmarshaled))
+	assert.Equal(t, res.Coupons, unmarshaled.Coupons)
+	assert.True(t, notify.PaidVerifySign(unmarshaled))
+
+	// 手动构造的结果根据结构体字段验签
+	manual := *res
+	manual.params = nil
+	assert.True(t, notify.PaidVerifySign(manual))
+
+	tampered := *res.TotalFee + 1
+	manual.TotalFee = &tampered
+	assert.False(t, notify.PaidVerifySign(manual))
+
+	_, err = NewNotify(&config.Config{Key: "wrong"}).ParsePaidNotify(raw)
+	assert.NotNil(t, err)
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/xml"
 	"errors"
 
+	"github.com/kuro-liang/wechat-go/pay/money"
 	"github.com/kuro-liang/wechat-go/util"
 )
 
@@ -19,25 +20,27 @@ type RefundedResult struct {
 
 	AppID    *string `xml:"appid"`
 	MchID    *string `xml:"mch_id"`
+	SubAppID *string `xml:"sub_appid"`
+	SubMchID *string `xml:"sub_mch_id"`
 	NonceStr *string `xml:"nonce_str"`
 	ReqInfo  *string `xml:"req_info"`
 }
 
 // RefundedReqInfo 退款结果（明文）
 type RefundedReqInfo struct {
-	TransactionID       *string `xml:"transaction_id"`
-	OutTradeNO          *string `xml:"out_trade_no"`
-	RefundID            *string `xml:"refund_id"`
-	OutRefundNO         *string `xml:"out_refund_no"`
-	TotalFee            *int    `xml:"total_fee"`
-	SettlementTotalFee  *int    `xml:"settlement_total_fee"`
-	RefundFee           *int    `xml:"refund_fee"`
-	SettlementRefundFee *int    `xml:"settlement_refund_fee"`
-	RefundStatus        *string `xml:"refund_status"`
-	SuccessTime         *string `xml:"success_time"`
-	RefundRecvAccount   *string `xml:"refund_recv_accout"`
-	RefundAccount       *string `xml:"refund_account"`
-	RefundRequestSource *string `xml:"refund_request_source"`
+	TransactionID       *string    `xml:"transaction_id"`
+	OutTradeNO          *string    `xml:"out_trade_no"`
+	RefundID            *string    `xml:"refund_id"`
+	OutRefundNO         *string    `xml:"out_refund_no"`
+	TotalFee            *money.Fen `xml:"total_fee"`
+	SettlementTotalFee  *money.Fen `xml:"settlement_total_fee"`
+	RefundFee           *money.Fen `xml:"refund_fee"`
+	SettlementRefundFee *money.Fen `xml:"settlement_refund_fee"`
+	RefundStatus        *string    `xml:"refund_status"`
+	SuccessTime         *string    `xml:"success_time"`
+	RefundRecvAccount   *string    `xml:"refund_recv_accout"`
+	RefundAccount       *string    `xml:"refund_account"`
+	RefundRequestSource *string    `xml:"refund_request_source"`
 }
 
 // RefundedResp 消息通知返回
@@ -8,22 +8,26 @@ import (
 )
 
 // https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_3
-var closeGateway = "https://api.mch.weixin.qq.com/pay/closeorder"
+var closeGateway = "/pay/closeorder"
 
 // CloseParams 传入的参数
 type CloseParams struct {
 	OutTradeNo string // 商户订单号
 	SignType   string // 签名类型
+	SubAppID   string // 服务商模式下的子商户公众账号ID，为空时使用 Config 中的配置
+	SubMchID   string // 服务商模式下的子商户号，为空时使用 Config 中的配置
 }
 
 // closeRequest 接口请求参数
 type closeRequest struct {
-	AppID      string `xml:"appid"`               // 公众账号ID
-	MchID      string `xml:"mch_id"`              // 商户号
-	NonceStr   string `xml:"nonce_str"`           // 随机字符串
-	Sign       string `xml:"sign"`                // 签名
-	SignType   string `xml:"sign_type,omitempty"` // 签名类型
-	OutTradeNo string `xml:"out_trade_no"`        // 商户订单号
+	AppID      string `xml:"appid"`                // 公众账号ID
+	MchID      string `xml:"mch_id"`               // 商户号
+	SubAppID   string `xml:"sub_appid,omitempty"`  // 子商户公众账号ID
+	SubMchID   string `xml:"sub_mch_id,omitempty"` // 子商户号
+	NonceStr   string `xml:"nonce_str"`            // 随机字符串
+	Sign       string `xml:"sign"`                 // 签名
+	SignType   string `xml:"sign_type,omitempty"`  // 签名类型
+	OutTradeNo string `xml:"out_trade_no"`         // 商户订单号
 }
 
 // CloseResult 关闭订单返回结果
@@ -33,6 +37,8 @@ type CloseResult struct {
 
 	AppID      *string `xml:"appid" json:"appid"`
 	MchID      *string `xml:"mch_id"`
+	SubAppID   *string `xml:"sub_appid"`
+	SubMchID   *string `xml:"sub_mch_id"`
 	NonceStr   *string `xml:"nonce_str"`
 	Sign       *string `xml:"sign"`
 	ResultCode *string `xml:"result_code"`
@@ -45,8 +51,11 @@ type CloseResult struct {
 func (o *Order) CloseOrder(p *CloseParams) (closeResult CloseResult, err error) {
 	nonceStr := util.RandomStr(32)
 	// 签名类型
-	if p.SignType == "" {
-		p.SignType = "MD5"
+	p.SignType = o.ResolveSignType(p.SignType)
+	p.SubAppID, p.SubMchID = o.ResolveSubMerchant(p.SubAppID, p.SubMchID)
+	key, err := o.SignKey()
+	if err != nil {
+		return
 	}
 
 	params := make(map[string]string)
@@ -55,13 +64,15 @@ func (o *Order) CloseOrder(p *CloseParams) (closeResult CloseResult, err error)
 	params["nonce_str"] = nonceStr
 	params["out_trade_no"] = p.OutTradeNo
 	params["sign_type"] = p.SignType
+	params["sub_appid"] = p.SubAppID
+	params["sub_mch_id"] = p.SubMchID
 
 	var (
 		sign   string
 		rawRet []byte
 	)
 
-	sign, err = util.ParamSign(params, o.Key)
+	sign, err = util.ParamSign(params, key)
 	if err != nil {
 		return
 	}
@@ -72,9 +83,11 @@ func (o *Order) CloseOrder(p *CloseParams) (closeResult CloseResult, err error)
 		Sign:       sign,
 		OutTradeNo: p.OutTradeNo,
 		SignType:   p.SignType,
+		SubAppID:   p.SubAppID,
+		SubMchID:   p.SubMchID,
 	}
 
-	rawRet, err = util.PostXML(closeGateway, request)
+	rawRet, err = o.PostXML(o.GatewayURL(closeGateway), request)
 	if err != nil {
 		return
 	}
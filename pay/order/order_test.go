@@ -0,0 +1,126 @@
+package order
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/util"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+const testKey = "ziR0QKsTUfMOuochC9RfCdmfHECorQAP"
+
+// mockGateway 校验请求签名，并将请求参数保存到 sent
+func mockGateway(t *testing.T, gateway string, sent map[string]string, reply string) {
+	gock.New(config.DefaultBaseURL).Post(gateway).
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return false, err
+			}
+			param, err := util.XMLToMap(body)
+			if err != nil {
+				return false, err
+			}
+			sign, err := util.ParamSign(param, testKey)
+			assert.Nil(t, err)
+			assert.Equal(t, sign, param["sign"], "request sign mismatch")
+			for k, v := range param {
+				sent[k] = v
+			}
+			return true, nil
+		}).
+		Reply(200).BodyString(reply)
+}
+
+func TestPartnerMode(t *testing.T) {
+	defer gock.Off()
+	o := NewOrder(&config.Config{
+		AppID:    "wx2421b1c4370ec43b",
+		MchID:    "10000100",
+		Key:      testKey,
+		SubAppID: "wx8888888888888888",
+		SubMchID: "1900000109",
+	})
+
+	// 下单使用配置中的子商户，sub_openid 参与签名
+	sent := map[string]string{}
+	mockGateway(t, payGateway, sent, `<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><prepay_id>wx201410272009395522657a690389285100</prepay_id><sub_appid>wx8888888888888888</sub_appid><sub_mch_id>1900000109</sub_mch_id></xml>`)
+	payOrder, err := o.PrePayOrder(&Params{
+		TotalFee:   1,
+		CreateIP:   "127.0.0.1",
+		Body:       "test",
+		OutTradeNo: "1415757673",
+		TradeType:  "JSAPI",
+		SubOpenID:  "oUpF8uMuAJO_M2pxb1Q9zNjWeS6o",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "1900000109", payOrder.SubMchID)
+	assert.Equal(t, "wx8888888888888888", sent["sub_appid"])
+	assert.Equal(t, "1900000109", sent["sub_mch_id"])
+	assert.Equal(t, "oUpF8uMuAJO_M2pxb1Q9zNjWeS6o", sent["sub_openid"])
+
+	sent = map[string]string{}
+	mockGateway(t, queryGateway, sent, `<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><sub_mch_id>1900000109</sub_mch_id><trade_state>SUCCESS</trade_state></xml>`)
+	paid, err := o.QueryOrder(&QueryParams{OutTradeNo: "1415757673"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "1900000109", *paid.SubMchID)
+	}
+	assert.Equal(t, "wx8888888888888888", sent["sub_appid"])
+	assert.Equal(t, "1900000109", sent["sub_mch_id"])
+
+	// 按次指定子商户时不使用配置中的子商户 appid
+	sent = map[string]string{}
+	mockGateway(t, closeGateway, sent, `<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><sub_mch_id>1900000110</sub_mch_id></xml>`)
+	_, err = o.CloseOrder(&CloseParams{OutTradeNo: "1415757673", SubMchID: "1900000110"})
+	assert.Nil(t, err)
+	assert.Equal(t, "1900000110", sent["sub_mch_id"])
+	_, ok := sent["sub_appid"]
+	assert.False(t, ok)
+
+	assert.True(t, gock.IsDone())
+}
+
+func TestPartnerModeAppBridge(t *testing.T) {
+	defer gock.Off()
+	o := NewOrder(&config.Config{
+		AppID:    "wx2421b1c4370ec43b",
+		MchID:    "10000100",
+		Key:      testKey,
+		SubAppID: "wx8888888888888888",
+		SubMchID: "1900000109",
+	})
+
+	sent := map[string]string{}
+	mockGateway(t, payGateway, sent, `<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><appid>wx2421b1c4370ec43b</appid><mch_id>10000100</mch_id><prepay_id>wx201410272009395522657a690389285100</prepay_id><sub_appid>wx8888888888888888</sub_appid><sub_mch_id>1900000109</sub_mch_id></xml>`)
+	cfg, err := o.BridgeAppConfig(&Params{
+		TotalFee:   1,
+		CreateIP:   "127.0.0.1",
+		Body:       "test",
+		OutTradeNo: "1415757673",
+		TradeType:  "APP",
+		NotifyURL:  "https://example.com/notify",
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "1900000109", sent["sub_mch_id"])
+
+	// APP 调起支付使用子商户的 appid 及商户号，并参与签名
+	assert.Equal(t, "wx8888888888888888", cfg.AppID)
+	assert.Equal(t, "1900000109", cfg.MchID)
+	sign, err := util.ParamSign(map[string]string{
+		"appid":     cfg.AppID,
+		"partnerid": cfg.MchID,
+		"prepayid":  cfg.PrePayID,
+		"package":   cfg.Package,
+		"noncestr":  cfg.NonceStr,
+		"timestamp": cfg.Timestamp,
+	}, testKey)
+	assert.Nil(t, err)
+	assert.Equal(t, sign, cfg.Sign)
+	assert.True(t, gock.IsDone())
+}
@@ -8,11 +8,12 @@ import (
 	"time"
 
 	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
 	"github.com/kuro-liang/wechat-go/util"
 )
 
 // https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_1
-var payGateway = "https://api.mch.weixin.qq.com/pay/unifiedorder"
+var payGateway = "/pay/unifiedorder"
 
 // SUCCESS 表示支付成功
 const SUCCESS = "SUCCESS"
@@ -31,7 +32,7 @@ func NewOrder(cfg *config.Config) *Order {
 // Params was NEEDED when request Unified order
 // 传入的参数，用于生成 prepay_id 的必需参数
 type Params struct {
-	TotalFee   string
+	TotalFee   money.Fen // 订单金额，单位分
 	CreateIP   string
 	Body       string
 	OutTradeNo string
@@ -43,6 +44,11 @@ type Params struct {
 	Attach     string
 	GoodsTag   string
 	NotifyURL  string
+
+	// 服务商模式，为空时使用 Config 中的配置
+	SubAppID  string // 子商户公众账号ID
+	SubMchID  string // 子商户号
+	SubOpenID string // 用户在子商户 appid 下的唯一标识，与 OpenID 二选一
 }
 
 // Config 是传出用于 js sdk 用的参数
@@ -72,6 +78,8 @@ type PreOrder struct {
 	ReturnMsg  string `xml:"return_msg"`
 	AppID      string `xml:"appid,omitempty"`
 	MchID      string `xml:"mch_id,omitempty"`
+	SubAppID   string `xml:"sub_appid,omitempty"`
+	SubMchID   string `xml:"sub_mch_id,omitempty"`
 	NonceStr   string `xml:"nonce_str,omitempty"`
 	Sign       string `xml:"sign,omitempty"`
 	ResultCode string `xml:"result_code,omitempty"`
@@ -85,28 +93,31 @@ type PreOrder struct {
 
 // payRequest 接口请求参数
 type payRequest struct {
-	AppID          string `xml:"appid"`                 // 公众账号ID
-	MchID          string `xml:"mch_id"`                // 商户号
-	DeviceInfo     string `xml:"device_info,omitempty"` // 设备号
-	NonceStr       string `xml:"nonce_str"`             // 随机字符串
-	Sign           string `xml:"sign"`                  // 签名
-	SignType       string `xml:"sign_type,omitempty"`   // 签名类型
-	Body           string `xml:"body"`                  // 商品描述
-	Detail         string `xml:"detail,omitempty"`      // 商品详情
-	Attach         string `xml:"attach,omitempty"`      // 附加数据
-	OutTradeNo     string `xml:"out_trade_no"`          // 商户订单号
-	FeeType        string `xml:"fee_type,omitempty"`    // 标价币种
-	TotalFee       string `xml:"total_fee"`             // 标价金额
-	SpbillCreateIP string `xml:"spbill_create_ip"`      // 终端IP
-	TimeStart      string `xml:"time_start,omitempty"`  // 交易起始时间
-	TimeExpire     string `xml:"time_expire,omitempty"` // 交易结束时间
-	GoodsTag       string `xml:"goods_tag,omitempty"`   // 订单优惠标记
-	NotifyURL      string `xml:"notify_url"`            // 通知地址
-	TradeType      string `xml:"trade_type"`            // 交易类型
-	ProductID      string `xml:"product_id,omitempty"`  // 商品ID
-	LimitPay       string `xml:"limit_pay,omitempty"`   // 指定支付方式
-	OpenID         string `xml:"openid,omitempty"`      // 用户标识
-	SceneInfo      string `xml:"scene_info,omitempty"`  // 场景信息
+	AppID          string    `xml:"appid"`                 // 公众账号ID
+	MchID          string    `xml:"mch_id"`                // 商户号
+	SubAppID       string    `xml:"sub_appid,omitempty"`   // 子商户公众账号ID
+	SubMchID       string    `xml:"sub_mch_id,omitempty"`  // 子商户号
+	DeviceInfo     string    `xml:"device_info,omitempty"` // 设备号
+	NonceStr       string    `xml:"nonce_str"`             // 随机字符串
+	Sign           string    `xml:"sign"`                  // 签名
+	SignType       string    `xml:"sign_type,omitempty"`   // 签名类型
+	Body           string    `xml:"body"`                  // 商品描述
+	Detail         string    `xml:"detail,omitempty"`      // 商品详情
+	Attach         string    `xml:"attach,omitempty"`      // 附加数据
+	OutTradeNo     string    `xml:"out_trade_no"`          // 商户订单号
+	FeeType        string    `xml:"fee_type,omitempty"`    // 标价币种
+	TotalFee       money.Fen `xml:"total_fee"`             // 标价金额
+	SpbillCreateIP string    `xml:"spbill_create_ip"`      // 终端IP
+	TimeStart      string    `xml:"time_start,omitempty"`  // 交易起始时间
+	TimeExpire     string    `xml:"time_expire,omitempty"` // 交易结束时间
+	GoodsTag       string    `xml:"goods_tag,omitempty"`   // 订单优惠标记
+	NotifyURL      string    `xml:"notify_url"`            // 通知地址
+	TradeType      string    `xml:"trade_type"`            // 交易类型
+	ProductID      string    `xml:"product_id,omitempty"`  // 商品ID
+	LimitPay       string    `xml:"limit_pay,omitempty"`   // 指定支付方式
+	OpenID         string    `xml:"openid,omitempty"`      // 用户标识
+	SubOpenID      string    `xml:"sub_openid,omitempty"`  // 用户子标识
+	SceneInfo      string    `xml:"scene_info,omitempty"`  // 场景信息
 
 	XMLName struct{} `xml:"xml"`
 }
@@ -124,6 +135,9 @@ func (req *payRequest) BridgePayRequest(p *Params, AppID, MchID, nonceStr, sign
 		NotifyURL:      p.NotifyURL,
 		TradeType:      p.TradeType,
 		OpenID:         p.OpenID,
+		SubAppID:       p.SubAppID,
+		SubMchID:       p.SubMchID,
+		SubOpenID:      p.SubOpenID,
 		SignType:       p.SignType,
 		Detail:         p.Detail,
 		Attach:         p.Attach,
@@ -142,8 +156,12 @@ func (o *Order) BridgeConfig(p *Params) (cfg Config, err error) {
 	if err != nil {
 		return
 	}
+	key, err := o.SignKey()
+	if err != nil {
+		return
+	}
 	buffer.WriteString("appId=")
-	buffer.WriteString(order.AppID)
+	buffer.WriteString(o.bridgeAppID(order.AppID, p))
 	buffer.WriteString("&nonceStr=")
 	buffer.WriteString(order.NonceStr)
 	buffer.WriteString("&package=")
@@ -153,9 +171,9 @@ func (o *Order) BridgeConfig(p *Params) (cfg Config, err error) {
 	buffer.WriteString("&timeStamp=")
 	buffer.WriteString(timestamp)
 	buffer.WriteString("&key=")
-	buffer.WriteString(o.Key)
+	buffer.WriteString(key)
 
-	sign, err := util.CalculateSign(buffer.String(), p.SignType, o.Key)
+	sign, err := util.CalculateSign(buffer.String(), p.SignType, key)
 	if err != nil {
 		return
 	}
@@ -180,17 +198,29 @@ func (o *Order) BridgeAppConfig(p *Params) (cfg ConfigForApp, err error) {
 	if err != nil {
 		return
 	}
+	key, err := o.SignKey()
+	if err != nil {
+		return
+	}
 
+	// 服务商模式下使用子商户的 appid 及商户号调起支付
+	appID, partnerID := order.AppID, order.MchID
+	if subAppID, subMchID := o.ResolveSubMerchant(p.SubAppID, p.SubMchID); subMchID != "" {
+		partnerID = subMchID
+		if subAppID != "" {
+			appID = subAppID
+		}
+	}
 	result := map[string]string{
-		"appid":     order.AppID,
-		"partnerid": order.MchID,
+		"appid":     appID,
+		"partnerid": partnerID,
 		"prepayid":  order.PrePayID,
 		"package":   _package,
 		"noncestr":  noncestr,
 		"timestamp": timestamp,
 	}
 	// 签名
-	sign, err := util.ParamSign(result, o.Key)
+	sign, err := util.ParamSign(result, key)
 	if err != nil {
 		return
 	}
@@ -209,6 +239,9 @@ func (o *Order) BridgeAppConfig(p *Params) (cfg ConfigForApp, err error) {
 
 // PrePayOrder return data for invoke wechat payment
 func (o *Order) PrePayOrder(p *Params) (payOrder PreOrder, err error) {
+	if err = money.OrderLimit.Check(p.TotalFee); err != nil {
+		return
+	}
 	nonceStr := util.RandomStr(32)
 
 	// 通知地址
@@ -217,8 +250,11 @@ func (o *Order) PrePayOrder(p *Params) (payOrder PreOrder, err error) {
 	}
 
 	// 签名类型
-	if p.SignType == "" {
-		p.SignType = util.SignTypeMD5
+	p.SignType = o.ResolveSignType(p.SignType)
+	p.SubAppID, p.SubMchID = o.ResolveSubMerchant(p.SubAppID, p.SubMchID)
+	key, err := o.SignKey()
+	if err != nil {
+		return
 	}
 
 	param := map[string]string{
@@ -228,7 +264,7 @@ func (o *Order) PrePayOrder(p *Params) (payOrder PreOrder, err error) {
 		"nonce_str":        nonceStr,
 		"out_trade_no":     p.OutTradeNo,
 		"spbill_create_ip": p.CreateIP,
-		"total_fee":        p.TotalFee,
+		"total_fee":        p.TotalFee.String(),
 		"trade_type":       p.TradeType,
 		"openid":           p.OpenID,
 		"sign_type":        p.SignType,
@@ -236,6 +272,9 @@ func (o *Order) PrePayOrder(p *Params) (payOrder PreOrder, err error) {
 		"attach":           p.Attach,
 		"goods_tag":        p.GoodsTag,
 		"notify_url":       p.NotifyURL,
+		"sub_appid":        p.SubAppID,
+		"sub_mch_id":       p.SubMchID,
+		"sub_openid":       p.SubOpenID,
 	}
 
 	if p.TimeExpire != "" {
@@ -243,7 +282,7 @@ func (o *Order) PrePayOrder(p *Params) (payOrder PreOrder, err error) {
 		param["time_expire"] = p.TimeExpire
 	}
 
-	sign, err := util.ParamSign(param, o.Key)
+	sign, err := util.ParamSign(param, key)
 	if err != nil {
 		return
 	}
@@ -252,7 +291,7 @@ func (o *Order) PrePayOrder(p *Params) (payOrder PreOrder, err error) {
 		// 如果有传入交易结束时间
 		request.TimeExpire = p.TimeExpire
 	}
-	rawRet, err := util.PostXML(payGateway, request)
+	rawRet, err := o.PostXML(o.GatewayURL(payGateway), request)
 	if err != nil {
 		return
 	}
@@ -285,3 +324,11 @@ func (o *Order) PrePayID(p *Params) (prePayID string, err error) {
 	prePayID = order.PrePayID
 	return
 }
+
+// bridgeAppID 服务商模式下使用 sub_openid 下单时，需使用子商户的 appid 调起支付
+func (o *Order) bridgeAppID(appID string, p *Params) string {
+	if subAppID, _ := o.ResolveSubMerchant(p.SubAppID, p.SubMchID); p.SubOpenID != "" && subAppID != "" {
+		return subAppID
+	}
+	return appID
+}
@@ -8,32 +8,40 @@ import (
 	"github.com/kuro-liang/wechat-go/util"
 )
 
-var queryGateway = "https://api.mch.weixin.qq.com/pay/orderquery"
+// https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_2
+var queryGateway = "/pay/orderquery"
 
 // QueryParams 传入的参数
 type QueryParams struct {
 	OutTradeNo    string // 商户订单号
 	SignType      string // 签名类型
 	TransactionID string // 微信订单号
+	SubAppID      string // 服务商模式下的子商户公众账号ID，为空时使用 Config 中的配置
+	SubMchID      string // 服务商模式下的子商户号，为空时使用 Config 中的配置
 }
 
 // queryRequest 接口请求参数
 type queryRequest struct {
-	AppID         string `xml:"appid"`               // 公众账号ID
-	MchID         string `xml:"mch_id"`              // 商户号
-	NonceStr      string `xml:"nonce_str"`           // 随机字符串
-	Sign          string `xml:"sign"`                // 签名
-	SignType      string `xml:"sign_type,omitempty"` // 签名类型
-	TransactionID string `xml:"transaction_id"`      // 微信订单号
-	OutTradeNo    string `xml:"out_trade_no"`        // 商户订单号
+	AppID         string `xml:"appid"`                // 公众账号ID
+	MchID         string `xml:"mch_id"`               // 商户号
+	SubAppID      string `xml:"sub_appid,omitempty"`  // 子商户公众账号ID
+	SubMchID      string `xml:"sub_mch_id,omitempty"` // 子商户号
+	NonceStr      string `xml:"nonce_str"`            // 随机字符串
+	Sign          string `xml:"sign"`                 // 签名
+	SignType      string `xml:"sign_type,omitempty"`  // 签名类型
+	TransactionID string `xml:"transaction_id"`       // 微信订单号
+	OutTradeNo    string `xml:"out_trade_no"`         // 商户订单号
 }
 
 // QueryOrder 查询订单
 func (o *Order) QueryOrder(p *QueryParams) (paidResult notify.PaidResult, err error) {
 	nonceStr := util.RandomStr(32)
 	// 签名类型
-	if p.SignType == "" {
-		p.SignType = "MD5"
+	p.SignType = o.ResolveSignType(p.SignType)
+	p.SubAppID, p.SubMchID = o.ResolveSubMerchant(p.SubAppID, p.SubMchID)
+	key, err := o.SignKey()
+	if err != nil {
+		return
 	}
 
 	params := make(map[string]string)
@@ -43,8 +51,10 @@ func (o *Order) QueryOrder(p *QueryParams) (paidResult notify.PaidResult, err er
 	params["out_trade_no"] = p.OutTradeNo
 	params["sign_type"] = p.SignType
 	params["transaction_id"] = p.TransactionID
+	params["sub_appid"] = p.SubAppID
+	params["sub_mch_id"] = p.SubMchID
 
-	sign, err := util.ParamSign(params, o.Key)
+	sign, err := util.ParamSign(params, key)
 	if err != nil {
 		return
 	}
@@ -56,9 +66,11 @@ func (o *Order) QueryOrder(p *QueryParams) (paidResult notify.PaidResult, err er
 		OutTradeNo:    p.OutTradeNo,
 		TransactionID: p.TransactionID,
 		SignType:      p.SignType,
+		SubAppID:      p.SubAppID,
+		SubMchID:      p.SubMchID,
 	}
 
-	rawRet, err := util.PostXML(queryGateway, request)
+	rawRet, err := o.PostXML(o.GatewayURL(queryGateway), request)
 	if err != nil {
 		return
 	}
@@ -1,9 +1,14 @@
 package pay
 
 import (
+	"github.com/kuro-liang/wechat-go/pay/bill"
+	"github.com/kuro-liang/wechat-go/pay/combine"
 	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/facepay"
 	"github.com/kuro-liang/wechat-go/pay/notify"
 	"github.com/kuro-liang/wechat-go/pay/order"
+	"github.com/kuro-liang/wechat-go/pay/profitsharing"
+	"github.com/kuro-liang/wechat-go/pay/redpack"
 	"github.com/kuro-liang/wechat-go/pay/refund"
 	"github.com/kuro-liang/wechat-go/pay/transfer"
 )
@@ -37,3 +42,28 @@ func (pay *Pay) GetRefund() *refund.Refund {
 func (pay *Pay) GetTransfer() *transfer.Transfer {
 	return transfer.NewTransfer(pay.cfg)
 }
+
+// GetBill 对账单
+func (pay *Pay) GetBill() *bill.Bill {
+	return bill.NewBill(pay.cfg)
+}
+
+// GetRedPack 现金红包
+func (pay *Pay) GetRedPack() *redpack.RedPack {
+	return redpack.NewRedPack(pay.cfg)
+}
+
+// GetProfitSharing 分账
+func (pay *Pay) GetProfitSharing() *profitsharing.ProfitSharing {
+	return profitsharing.NewProfitSharing(pay.cfg)
+}
+
+// GetCombine 合单支付
+func (pay *Pay) GetCombine() *combine.Combine {
+	return combine.NewCombine(pay.cfg)
+}
+
+// GetFacePay 刷脸支付
+func (pay *Pay) GetFacePay() *facepay.FacePay {
+	return facepay.NewFacePay(pay.cfg)
+}
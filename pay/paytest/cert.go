@@ -0,0 +1,79 @@
+package paytest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// Cert 自签名证书，可用作测试中的商户证书或微信支付平台证书
+type Cert struct {
+	Key      *rsa.PrivateKey
+	CertPEM  []byte
+	KeyPEM   []byte
+	SerialNo string // 证书序列号，十六进制大写
+}
+
+// NewCert 生成自签名证书，commonName 通常为商户号
+func NewCert(commonName string) (*Cert, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Cert{
+		Key:      key,
+		CertPEM:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		KeyPEM:   pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		SerialNo: fmt.Sprintf("%X", tpl.SerialNumber),
+	}, nil
+}
+
+// SignV3 以微信支付平台的身份为 APIv3 应答或回调通知签名，设置 Wechatpay-* 头
+func (c *Cert) SignV3(header http.Header, body []byte) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := util.RandomStr(32)
+	signature, err := util.RSASignSHA256(string(c.KeyPEM), []byte(timestamp+"\n"+nonce+"\n"+string(body)+"\n"))
+	if err != nil {
+		return err
+	}
+	header.Set("Wechatpay-Serial", c.SerialNo)
+	header.Set("Wechatpay-Timestamp", timestamp)
+	header.Set("Wechatpay-Nonce", nonce)
+	header.Set("Wechatpay-Signature", signature)
+	return nil
+}
+
+// TB testing.TB 中 NewTestCert 用到的方法，paytest 不引入 testing 包
+type TB interface {
+	Helper()
+	Fatal(args ...interface{})
+}
+
+// NewTestCert 在测试中生成自签名证书，生成失败时终止测试
+func NewTestCert(tb TB, commonName string) *Cert {
+	tb.Helper()
+	cert, err := NewCert(commonName)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return cert
+}
@@ -0,0 +1,273 @@
+package paytest
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+const (
+	unifiedOrderPath = "/pay/unifiedorder"
+	orderQueryPath   = "/pay/orderquery"
+	closeOrderPath   = "/pay/closeorder"
+)
+
+// 交易状态
+const (
+	TradeStateNotPay  = "NOTPAY"
+	TradeStateSuccess = "SUCCESS"
+	TradeStateRefund  = "REFUND"
+	TradeStateClosed  = "CLOSED"
+)
+
+// Order 模拟网关中保存的订单
+type Order struct {
+	OutTradeNo    string
+	TransactionID string
+	PrepayID      string
+	TradeType     string
+	TradeState    string
+	Body          string
+	Attach        string
+	TotalFee      money.Fen
+	RefundFee     money.Fen // 已退款金额
+	OpenID        string
+	SubAppID      string
+	SubMchID      string
+	SubOpenID     string
+	NotifyURL     string
+	SignType      string
+	TimeEnd       string
+}
+
+// Order 返回订单的副本，订单不存在时返回 false
+func (s *Server) Order(outTradeNo string) (Order, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.orders[outTradeNo]
+	if !ok {
+		return Order{}, false
+	}
+	return *o, true
+}
+
+// Pay 模拟用户完成支付，并向下单时的 notify_url 发送签名后的支付结果通知
+// 商户未返回 SUCCESS 时返回 error，可再次调用以模拟微信的重复通知
+func (s *Server) Pay(outTradeNo, openID string) error {
+	s.mu.Lock()
+	o, ok := s.orders[outTradeNo]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("order %s not exist", outTradeNo)
+	}
+	switch o.TradeState {
+	case TradeStateNotPay:
+		o.TradeState = TradeStateSuccess
+		o.TransactionID = s.nextID("4200000")
+		o.TimeEnd = time.Now().Format("20060102150405")
+		// 非 JSAPI 支付下单时没有用户标识，以支付的用户为准
+		if o.OpenID == "" && o.SubOpenID == "" {
+			if o.SubAppID != "" {
+				o.SubOpenID = openID
+			} else {
+				o.OpenID = openID
+			}
+		}
+	case TradeStateSuccess, TradeStateRefund:
+		// 已支付的订单重复发送通知
+	default:
+		s.mu.Unlock()
+		return fmt.Errorf("order %s trade_state=%s", outTradeNo, o.TradeState)
+	}
+	param := s.sign(s.paidParams(o), o.SignType)
+	notifyURL := o.NotifyURL
+	s.mu.Unlock()
+
+	return s.deliver(notifyURL, param)
+}
+
+// deliver 发送支付或退款结果通知
+func (s *Server) deliver(notifyURL string, param map[string]string) error {
+	if notifyURL == "" {
+		return errors.New("notify_url is empty")
+	}
+	client := s.NotifyClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Post(notifyURL, "text/xml; charset=utf-8", strings.NewReader(string(encodeXML(param))))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("notify http status %d", response.StatusCode)
+	}
+	var resp struct {
+		ReturnCode string `xml:"return_code"`
+		ReturnMsg  string `xml:"return_msg"`
+	}
+	if err = xml.NewDecoder(response.Body).Decode(&resp); err != nil {
+		return err
+	}
+	if resp.ReturnCode != "SUCCESS" {
+		return fmt.Errorf("notify return_code=%s,return_msg=%s", resp.ReturnCode, resp.ReturnMsg)
+	}
+	return nil
+}
+
+// paidParams 支付结果通知及订单查询返回的订单字段
+func (s *Server) paidParams(o *Order) map[string]string {
+	param := map[string]string{
+		"return_code":    "SUCCESS",
+		"return_msg":     "OK",
+		"result_code":    "SUCCESS",
+		"appid":          s.AppID,
+		"mch_id":         s.MchID,
+		"nonce_str":      util.RandomStr(32),
+		"out_trade_no":   o.OutTradeNo,
+		"trade_type":     o.TradeType,
+		"total_fee":      o.TotalFee.String(),
+		"fee_type":       "CNY",
+		"attach":         o.Attach,
+		"sub_appid":      o.SubAppID,
+		"sub_mch_id":     o.SubMchID,
+		"openid":         o.OpenID,
+		"sub_openid":     o.SubOpenID,
+		"transaction_id": o.TransactionID,
+	}
+	if o.TransactionID != "" {
+		param["cash_fee"] = o.TotalFee.String()
+		param["bank_type"] = "OTHERS"
+		param["is_subscribe"] = "N"
+		param["time_end"] = o.TimeEnd
+	}
+	for k, v := range param {
+		if v == "" {
+			delete(param, k)
+		}
+	}
+	return param
+}
+
+// unifiedOrder 统一下单
+func (s *Server) unifiedOrder(param map[string]string) map[string]string {
+	if rsp := s.checkMerchant(param["appid"], param["mch_id"]); rsp != nil {
+		return rsp
+	}
+	for _, k := range []string{"body", "out_trade_no", "total_fee", "trade_type", "notify_url"} {
+		if param[k] == "" {
+			return fail("INVALID_REQUEST", "缺少参数"+k)
+		}
+	}
+	totalFee, err := money.ParseFen(param["total_fee"])
+	if err != nil || totalFee <= 0 {
+		return fail("INVALID_REQUEST", "total_fee 格式错误")
+	}
+	if param["trade_type"] == "JSAPI" && param["openid"] == "" && param["sub_openid"] == "" {
+		return fail("INVALID_REQUEST", "JSAPI支付必须传openid")
+	}
+
+	o, ok := s.orders[param["out_trade_no"]]
+	if ok {
+		switch {
+		case o.TradeState == TradeStateSuccess || o.TradeState == TradeStateRefund:
+			return fail("ORDERPAID", "该订单已支付")
+		case o.TradeState == TradeStateClosed:
+			return fail("ORDERCLOSED", "该订单已关")
+		case o.TotalFee != totalFee || o.Body != param["body"]:
+			return fail("INVALID_REQUEST", "201 商户订单号重复")
+		}
+	} else {
+		o = &Order{
+			OutTradeNo: param["out_trade_no"],
+			PrepayID:   s.nextID("wx"),
+			TradeState: TradeStateNotPay,
+		}
+		s.orders[o.OutTradeNo] = o
+	}
+	o.TradeType = param["trade_type"]
+	o.Body = param["body"]
+	o.Attach = param["attach"]
+	o.TotalFee = totalFee
+	o.OpenID = param["openid"]
+	o.SubAppID = param["sub_appid"]
+	o.SubMchID = param["sub_mch_id"]
+	o.SubOpenID = param["sub_openid"]
+	o.NotifyURL = param["notify_url"]
+	o.SignType = param["sign_type"]
+
+	rsp := map[string]string{
+		"appid":      s.AppID,
+		"mch_id":     s.MchID,
+		"sub_appid":  o.SubAppID,
+		"sub_mch_id": o.SubMchID,
+		"trade_type": o.TradeType,
+		"prepay_id":  o.PrepayID,
+	}
+	if o.TradeType == "NATIVE" {
+		rsp["code_url"] = "weixin://wxpay/bizpayurl?pr=" + o.PrepayID
+	}
+	if o.TradeType == "MWEB" {
+		rsp["mweb_url"] = "https://wx.tenpay.com/cgi-bin/mmpayweb-bin/checkmweb?prepay_id=" + o.PrepayID
+	}
+	return rsp
+}
+
+// findOrder 按商户订单号或微信订单号查找订单
+func (s *Server) findOrder(param map[string]string) *Order {
+	if outTradeNo := param["out_trade_no"]; outTradeNo != "" {
+		return s.orders[outTradeNo]
+	}
+	if transactionID := param["transaction_id"]; transactionID != "" {
+		for _, o := range s.orders {
+			if o.TransactionID == transactionID {
+				return o
+			}
+		}
+	}
+	return nil
+}
+
+// orderQuery 查询订单
+func (s *Server) orderQuery(param map[string]string) map[string]string {
+	if rsp := s.checkMerchant(param["appid"], param["mch_id"]); rsp != nil {
+		return rsp
+	}
+	o := s.findOrder(param)
+	if o == nil {
+		return fail("ORDERNOTEXIST", "订单不存在")
+	}
+	rsp := s.paidParams(o)
+	rsp["trade_state"] = o.TradeState
+	rsp["trade_state_desc"] = o.TradeState
+	return rsp
+}
+
+// closeOrder 关闭订单
+func (s *Server) closeOrder(param map[string]string) map[string]string {
+	if rsp := s.checkMerchant(param["appid"], param["mch_id"]); rsp != nil {
+		return rsp
+	}
+	o := s.orders[param["out_trade_no"]]
+	if o == nil {
+		return fail("ORDERNOTEXIST", "订单不存在")
+	}
+	switch o.TradeState {
+	case TradeStateSuccess, TradeStateRefund:
+		return fail("ORDERPAID", "订单已支付，不能发起关单")
+	case TradeStateClosed:
+		return fail("ORDERCLOSED", "订单已关闭，无法重复关闭")
+	}
+	o.TradeState = TradeStateClosed
+	return map[string]string{
+		"appid":  s.AppID,
+		"mch_id": s.MchID,
+	}
+}
@@ -0,0 +1,220 @@
+package paytest
+
+import (
+	"crypto/aes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+const (
+	refundPath      = "/secapi/pay/refund"
+	refundQueryPath = "/pay/refundquery"
+)
+
+// Refund 模拟网关中保存的退款单，退款申请后立即视为退款成功
+type Refund struct {
+	OutRefundNo string
+	RefundID    string
+	OutTradeNo  string
+	RefundFee   money.Fen
+	Status      string
+	NotifyURL   string // 申请退款时传入的 notify_url
+	SuccessTime string
+}
+
+// Refund 返回退款单的副本，退款单不存在时返回 false
+func (s *Server) Refund(outRefundNo string) (Refund, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.refunds[outRefundNo]
+	if !ok {
+		return Refund{}, false
+	}
+	return *r, true
+}
+
+// RefundNotify 向退款时传入的 notify_url（未传入时为 RefundNotifyURL）发送退款结果通知，req_info 按微信的方式加密
+// 商户未返回 SUCCESS 时返回 error，可再次调用以模拟微信的重复通知
+func (s *Server) RefundNotify(outRefundNo string) error {
+	s.mu.Lock()
+	r, ok := s.refunds[outRefundNo]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("refund %s not exist", outRefundNo)
+	}
+	o := s.orders[r.OutTradeNo]
+	reqInfo := map[string]string{
+		"transaction_id":        o.TransactionID,
+		"out_trade_no":          o.OutTradeNo,
+		"refund_id":             r.RefundID,
+		"out_refund_no":         r.OutRefundNo,
+		"total_fee":             o.TotalFee.String(),
+		"refund_fee":            r.RefundFee.String(),
+		"settlement_refund_fee": r.RefundFee.String(),
+		"refund_status":         r.Status,
+		"success_time":          r.SuccessTime,
+		"refund_recv_accout":    "支付用户的零钱",
+		"refund_account":        "REFUND_SOURCE_RECHARGE_FUNDS",
+		"refund_request_source": "API",
+	}
+	param := map[string]string{
+		"return_code": "SUCCESS",
+		"appid":       s.AppID,
+		"mch_id":      s.MchID,
+		"sub_appid":   o.SubAppID,
+		"sub_mch_id":  o.SubMchID,
+		"nonce_str":   util.RandomStr(32),
+	}
+	notifyURL := r.NotifyURL
+	if notifyURL == "" {
+		notifyURL = s.RefundNotifyURL
+	}
+	s.mu.Unlock()
+
+	encrypted, err := s.encryptReqInfo(reqInfo)
+	if err != nil {
+		return err
+	}
+	param["req_info"] = encrypted
+	for k, v := range param {
+		if v == "" {
+			delete(param, k)
+		}
+	}
+	return s.deliver(notifyURL, param)
+}
+
+// encryptReqInfo 使用 API 密钥的 md5 作为密钥，AES-256-ECB 加密退款结果
+func (s *Server) encryptReqInfo(reqInfo map[string]string) (string, error) {
+	hash := md5.Sum([]byte(s.Key))
+	block, err := aes.NewCipher([]byte(hex.EncodeToString(hash[:])))
+	if err != nil {
+		return "", err
+	}
+	plaintext := util.PKCS5Padding(encodeXML(reqInfo), block.BlockSize())
+	ciphertext := make([]byte, len(plaintext))
+	util.NewECBEncryptor(block).CryptBlocks(ciphertext, plaintext)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// refund 申请退款
+func (s *Server) refund(param map[string]string) map[string]string {
+	if rsp := s.checkMerchant(param["appid"], param["mch_id"]); rsp != nil {
+		return rsp
+	}
+	o := s.findOrder(param)
+	if o == nil {
+		return fail("ORDERNOTEXIST", "订单不存在")
+	}
+	if o.TradeState != TradeStateSuccess && o.TradeState != TradeStateRefund {
+		return fail("TRADE_STATE_ERROR", "订单状态错误")
+	}
+	totalFee, _ := money.ParseFen(param["total_fee"])
+	refundFee, err := money.ParseFen(param["refund_fee"])
+	if err != nil || refundFee <= 0 {
+		return fail("INVALID_REQUEST", "refund_fee 格式错误")
+	}
+	if totalFee != o.TotalFee {
+		return fail("INVALID_REQUEST", "订单金额或退款金额与之前请求不一致")
+	}
+
+	r, ok := s.refunds[param["out_refund_no"]]
+	if ok {
+		// 相同退款单号重复请求时返回原退款单
+		if r.OutTradeNo != o.OutTradeNo || r.RefundFee != refundFee {
+			return fail("INVALID_REQUEST", "订单金额或退款金额与之前请求不一致")
+		}
+	} else {
+		if o.RefundFee+refundFee > o.TotalFee {
+			return fail("NOTENOUGH", "订单可退金额不足")
+		}
+		r = &Refund{
+			OutRefundNo: param["out_refund_no"],
+			RefundID:    s.nextID("5030000"),
+			OutTradeNo:  o.OutTradeNo,
+			RefundFee:   refundFee,
+			Status:      "SUCCESS",
+			NotifyURL:   param["notify_url"],
+			SuccessTime: time.Now().Format("2006-01-02 15:04:05"),
+		}
+		s.refunds[r.OutRefundNo] = r
+		o.RefundFee += refundFee
+		o.TradeState = TradeStateRefund
+	}
+
+	return map[string]string{
+		"appid":          s.AppID,
+		"mch_id":         s.MchID,
+		"sub_appid":      o.SubAppID,
+		"sub_mch_id":     o.SubMchID,
+		"transaction_id": o.TransactionID,
+		"out_trade_no":   o.OutTradeNo,
+		"out_refund_no":  r.OutRefundNo,
+		"refund_id":      r.RefundID,
+		"refund_fee":     r.RefundFee.String(),
+		"total_fee":      o.TotalFee.String(),
+		"cash_fee":       o.TotalFee.String(),
+	}
+}
+
+// refundQuery 查询退款，返回订单下的全部退款单
+func (s *Server) refundQuery(param map[string]string) map[string]string {
+	if rsp := s.checkMerchant(param["appid"], param["mch_id"]); rsp != nil {
+		return rsp
+	}
+	var o *Order
+	switch {
+	case param["out_refund_no"] != "":
+		if r, ok := s.refunds[param["out_refund_no"]]; ok {
+			o = s.orders[r.OutTradeNo]
+		}
+	case param["refund_id"] != "":
+		for _, r := range s.refunds {
+			if r.RefundID == param["refund_id"] {
+				o = s.orders[r.OutTradeNo]
+			}
+		}
+	default:
+		o = s.findOrder(param)
+	}
+	if o == nil {
+		return fail("REFUNDNOTEXIST", "退款订单查询失败")
+	}
+
+	rsp := map[string]string{
+		"appid":          s.AppID,
+		"mch_id":         s.MchID,
+		"transaction_id": o.TransactionID,
+		"out_trade_no":   o.OutTradeNo,
+		"total_fee":      o.TotalFee.String(),
+		"cash_fee":       o.TotalFee.String(),
+	}
+	refunds := make([]*Refund, 0)
+	for _, r := range s.refunds {
+		if r.OutTradeNo == o.OutTradeNo {
+			refunds = append(refunds, r)
+		}
+	}
+	if len(refunds) == 0 {
+		return fail("REFUNDNOTEXIST", "退款订单查询失败")
+	}
+	// 退款单号按申请顺序递增
+	sort.Slice(refunds, func(i, j int) bool { return refunds[i].RefundID < refunds[j].RefundID })
+	for n, r := range refunds {
+		idx := strconv.Itoa(n)
+		rsp["out_refund_no_"+idx] = r.OutRefundNo
+		rsp["refund_id_"+idx] = r.RefundID
+		rsp["refund_fee_"+idx] = r.RefundFee.String()
+		rsp["refund_status_"+idx] = r.Status
+	}
+	rsp["refund_count"] = strconv.Itoa(len(refunds))
+	return rsp
+}
@@ -0,0 +1,231 @@
+// Package paytest 提供模拟的微信支付网关，用于在不访问微信服务器的情况下测试支付流程
+//
+//	srv, err := paytest.NewServer(&config.Config{AppID: "wx...", MchID: "1900000109", Key: "...", NotifyURL: "http://127.0.0.1/notify"})
+//	defer srv.Close()
+//	cfg := srv.Config() // 指向模拟网关的配置
+//	order.NewOrder(cfg).PrePayOrder(...)
+//	srv.Pay("out_trade_no", "openid") // 模拟用户支付并发送支付结果通知
+package paytest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+const (
+	sandboxPrefix  = "/sandboxnew"
+	getSignKeyPath = "/pay/getsignkey"
+)
+
+// Server 模拟的微信支付网关，订单、退款、付款等状态保存在内存中
+type Server struct {
+	*httptest.Server
+
+	AppID string
+	MchID string
+	Key   string
+
+	// NotifyClient 发送支付及退款结果通知使用的 http.Client，为空时使用 http.DefaultClient
+	NotifyClient *http.Client
+	// RefundNotifyURL 申请退款时未传 notify_url 时使用的退款结果通知地址，对应商户平台上配置的地址
+	RefundNotifyURL string
+
+	cfg *config.Config
+
+	mu        sync.Mutex
+	seq       int
+	orders    map[string]*Order
+	refunds   map[string]*Refund
+	transfers map[string]*Transfer
+	handlers  map[string]func(param map[string]string) map[string]string
+}
+
+// NewServer 启动模拟网关，请求使用 cfg 中的 AppID、MchID、Key 校验
+// 不会修改 cfg，调用接口时使用 Config 返回的指向模拟网关的配置
+func NewServer(cfg *config.Config) (*Server, error) {
+	s := &Server{
+		AppID:     cfg.AppID,
+		MchID:     cfg.MchID,
+		Key:       cfg.Key,
+		orders:    make(map[string]*Order),
+		refunds:   make(map[string]*Refund),
+		transfers: make(map[string]*Transfer),
+	}
+	s.handlers = map[string]func(map[string]string) map[string]string{
+		unifiedOrderPath:    s.unifiedOrder,
+		orderQueryPath:      s.orderQuery,
+		closeOrderPath:      s.closeOrder,
+		refundPath:          s.refund,
+		refundQueryPath:     s.refundQuery,
+		transfersPath:       s.transfer,
+		getTransferInfoPath: s.getTransferInfo,
+	}
+
+	s.cfg = &config.Config{
+		AppID:           cfg.AppID,
+		MchID:           cfg.MchID,
+		Key:             cfg.Key,
+		NotifyURL:       cfg.NotifyURL,
+		SignType:        cfg.SignType,
+		Sandbox:         cfg.Sandbox,
+		SubAppID:        cfg.SubAppID,
+		SubMchID:        cfg.SubMchID,
+		CertFile:        cfg.CertFile,
+		CertP12:         cfg.CertP12,
+		CertPEM:         cfg.CertPEM,
+		KeyPEM:          cfg.KeyPEM,
+		SerialNo:        cfg.SerialNo,
+		APIv3Key:        cfg.APIv3Key,
+		PlatformCertPEM: cfg.PlatformCertPEM,
+		HTTPClient:      cfg.HTTPClient,
+	}
+	// 未配置商户证书时生成一张自签名证书，以便调用退款、付款等接口
+	if cfg.CertFile == "" && len(cfg.CertP12) == 0 && len(cfg.CertPEM) == 0 {
+		cert, err := NewCert(cfg.MchID)
+		if err != nil {
+			return nil, err
+		}
+		s.cfg.CertPEM, s.cfg.KeyPEM = cert.CertPEM, cert.KeyPEM
+	}
+
+	s.Server = httptest.NewServer(s)
+	s.cfg.BaseURL = s.URL
+	return s, nil
+}
+
+// Config 返回指向模拟网关的配置，复制自 NewServer 传入的配置，多次调用返回同一个实例
+func (s *Server) Config() *config.Config {
+	return s.cfg
+}
+
+// ServeHTTP 实现 http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, sandboxPrefix)
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeFail(w, err.Error())
+		return
+	}
+	param, err := util.XMLToMap(body)
+	if err != nil {
+		s.writeFail(w, "XML格式错误")
+		return
+	}
+
+	if path == getSignKeyPath {
+		// 仿真测试系统的验签密钥与 API 密钥相同
+		if !s.verifySign(param) {
+			s.writeFail(w, "签名错误")
+			return
+		}
+		s.writeXML(w, map[string]string{
+			"return_code":     "SUCCESS",
+			"return_msg":      "ok",
+			"mch_id":          s.MchID,
+			"sandbox_signkey": s.Key,
+		})
+		return
+	}
+
+	handler, ok := s.handlers[path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if !s.verifySign(param) {
+		s.writeFail(w, "签名错误")
+		return
+	}
+
+	s.mu.Lock()
+	result := handler(param)
+	s.mu.Unlock()
+
+	if _, ok := result["result_code"]; !ok {
+		result["result_code"] = "SUCCESS"
+	}
+	result["return_code"] = "SUCCESS"
+	result["return_msg"] = "OK"
+	result["nonce_str"] = util.RandomStr(32)
+	s.writeSigned(w, result, param["sign_type"])
+}
+
+// verifySign 使用 API 密钥校验请求签名
+func (s *Server) verifySign(param map[string]string) bool {
+	expected, err := util.ParamSign(param, s.Key)
+	if err != nil || expected != param["sign"] {
+		return false
+	}
+	return true
+}
+
+// checkMerchant 校验请求中的 appid 与商户号，字段名因接口而异
+func (s *Server) checkMerchant(appID, mchID string) map[string]string {
+	if appID != s.AppID || mchID != s.MchID {
+		return fail("APPID_MCHID_NOT_MATCH", "appid和mch_id不匹配")
+	}
+	return nil
+}
+
+// nextID 生成递增的微信侧单号
+func (s *Server) nextID(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s%s%06d", prefix, time.Now().Format("20060102"), s.seq)
+}
+
+func (s *Server) sign(param map[string]string, signType string) map[string]string {
+	if signType != "" {
+		param["sign_type"] = signType
+	}
+	param["sign"], _ = util.ParamSign(param, s.Key)
+	return param
+}
+
+func (s *Server) writeSigned(w http.ResponseWriter, param map[string]string, signType string) {
+	s.writeXML(w, s.sign(param, signType))
+}
+
+func (s *Server) writeFail(w http.ResponseWriter, msg string) {
+	s.writeXML(w, map[string]string{"return_code": "FAIL", "return_msg": msg})
+}
+
+func (s *Server) writeXML(w http.ResponseWriter, param map[string]string) {
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	_, _ = w.Write(encodeXML(param))
+}
+
+// fail 业务失败的返回，通信标识仍为 SUCCESS
+func fail(errCode, errCodeDes string) map[string]string {
+	return map[string]string{
+		"result_code":  "FAIL",
+		"err_code":     errCode,
+		"err_code_des": errCodeDes,
+	}
+}
+
+// encodeXML 按 key 排序输出微信支付格式的 xml
+func encodeXML(param map[string]string) []byte {
+	keys := make([]string, 0, len(param))
+	for k := range param {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString("<xml>")
+	for _, k := range keys {
+		buf.WriteString("<" + k + "><![CDATA[" + param[k] + "]]></" + k + ">")
+	}
+	buf.WriteString("</xml>")
+	return buf.Bytes()
+}
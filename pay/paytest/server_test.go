@@ -0,0 +1,200 @@
+package paytest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/pay/notify"
+	"github.com/kuro-liang/wechat-go/pay/order"
+	"github.com/kuro-liang/wechat-go/pay/refund"
+	"github.com/kuro-liang/wechat-go/pay/transfer"
+	"github.com/kuro-liang/wechat-go/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestConfig() *config.Config {
+	return &config.Config{
+		AppID:    "wx2421b1c4370ec43b",
+		MchID:    "10000100",
+		Key:      "ziR0QKsTUfMOuochC9RfCdmfHECorQAP",
+		SignType: util.SignTypeHMACSHA256,
+	}
+}
+
+func newTestServer(t *testing.T, cfg *config.Config) *Server {
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+func TestNewServer(t *testing.T) {
+	caller := newTestConfig()
+	srv := newTestServer(t, caller)
+	defer srv.Close()
+
+	assert.Empty(t, caller.BaseURL, "should not modify the caller's config")
+	assert.Empty(t, caller.CertPEM)
+	cfg := srv.Config()
+	assert.Equal(t, srv.URL, cfg.BaseURL)
+	assert.Equal(t, caller.MchID, cfg.MchID)
+	assert.NotEmpty(t, cfg.CertPEM)
+	assert.True(t, cfg == srv.Config())
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	srv := newTestServer(t, newTestConfig())
+	defer srv.Close()
+	cfg := srv.Config()
+
+	// 商户的支付及退款结果通知地址，验签或解密后记录通知
+	paid := make(chan *notify.PaidResult, 1)
+	refunded := make(chan *notify.RefundedReqInfo, 1)
+	merchant := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path == "/refund" {
+			var result notify.RefundedResult
+			if err := xml.Unmarshal(body, &result); err != nil {
+				fmt.Fprint(w, `<xml><return_code>FAIL</return_code><return_msg>xml error</return_msg></xml>`)
+				return
+			}
+			info, err := notify.NewNotify(cfg).DecryptReqInfo(&result)
+			if err != nil {
+				fmt.Fprint(w, `<xml><return_code>FAIL</return_code><return_msg>decrypt error</return_msg></xml>`)
+				return
+			}
+			refunded <- info
+			fmt.Fprint(w, `<xml><return_code>SUCCESS</return_code><return_msg>OK</return_msg></xml>`)
+			return
+		}
+		res, err := notify.NewNotify(cfg).ParsePaidNotify(body)
+		if err != nil {
+			fmt.Fprint(w, `<xml><return_code>FAIL</return_code><return_msg>sign error</return_msg></xml>`)
+			return
+		}
+		paid <- res
+		fmt.Fprint(w, `<xml><return_code>SUCCESS</return_code><return_msg>OK</return_msg></xml>`)
+	}))
+	defer merchant.Close()
+	cfg.NotifyURL = merchant.URL
+
+	o := order.NewOrder(cfg)
+	preOrder, err := o.PrePayOrder(&order.Params{
+		TotalFee:   101,
+		CreateIP:   "127.0.0.1",
+		Body:       "test",
+		OutTradeNo: "1415659990",
+		OpenID:     "oUpF8uMuAJO_M2pxb1Q9zNjWeS6o",
+		TradeType:  "JSAPI",
+	})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, preOrder.PrePayID)
+
+	_, err = o.CloseOrder(&order.CloseParams{OutTradeNo: "not-exist"})
+	assert.EqualError(t, err, "ORDERNOTEXIST订单不存在")
+
+	assert.Nil(t, srv.Pay("1415659990", ""))
+	res := <-paid
+	assert.Equal(t, "1415659990", *res.OutTradeNo)
+	assert.Equal(t, money.Fen(101), *res.TotalFee)
+
+	queryRes, err := o.QueryOrder(&order.QueryParams{OutTradeNo: "1415659990"})
+	assert.Nil(t, err)
+	assert.Equal(t, TradeStateSuccess, *queryRes.TradeState)
+	assert.Equal(t, *res.TransactionID, *queryRes.TransactionID)
+
+	_, err = o.CloseOrder(&order.CloseParams{OutTradeNo: "1415659990"})
+	assert.EqualError(t, err, "ORDERPAID订单已支付，不能发起关单")
+
+	r := refund.NewRefund(cfg)
+	for _, no := range []string{"r1", "r2"} {
+		_, err = r.Refund(&refund.Params{OutTradeNo: "1415659990", OutRefundNo: no, TotalFee: 101, RefundFee: 50})
+		assert.Nil(t, err)
+	}
+	_, err = r.Refund(&refund.Params{OutTradeNo: "1415659990", OutRefundNo: "r3", TotalFee: 101, RefundFee: 2})
+	assert.EqualError(t, err, "refund error, errcode=NOTENOUGH,errmsg=订单可退金额不足")
+
+	refundRes, err := r.Query(&refund.QueryParams{OutTradeNo: "1415659990"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, refundRes.RefundCount)
+	assert.Equal(t, "r1", refundRes.Refunds[0].OutRefundNo)
+
+	assert.EqualError(t, srv.RefundNotify("r1"), "notify_url is empty")
+	srv.RefundNotifyURL = merchant.URL + "/refund"
+	assert.Nil(t, srv.RefundNotify("r1"))
+	info := <-refunded
+	assert.Equal(t, "r1", *info.OutRefundNO)
+	assert.Equal(t, *res.TransactionID, *info.TransactionID)
+	assert.Equal(t, money.Fen(50), *info.RefundFee)
+	assert.Equal(t, "SUCCESS", *info.RefundStatus)
+
+	stored, ok := srv.Order("1415659990")
+	assert.True(t, ok)
+	assert.Equal(t, TradeStateRefund, stored.TradeState)
+	assert.Equal(t, money.Fen(100), stored.RefundFee)
+}
+
+func TestCloseOrder(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.NotifyURL = "http://127.0.0.1/notify"
+	srv := newTestServer(t, cfg)
+	defer srv.Close()
+	cfg = srv.Config()
+
+	o := order.NewOrder(cfg)
+	_, err := o.PrePayOrder(&order.Params{TotalFee: 1, Body: "test", OutTradeNo: "native-1", TradeType: "NATIVE"})
+	assert.Nil(t, err)
+	_, err = o.CloseOrder(&order.CloseParams{OutTradeNo: "native-1"})
+	assert.Nil(t, err)
+	assert.Error(t, srv.Pay("native-1", "openid"))
+
+	_, err = o.PrePayOrder(&order.Params{TotalFee: 1, Body: "test", OutTradeNo: "native-1", TradeType: "NATIVE"})
+	assert.EqualError(t, err, "ORDERCLOSED该订单已关")
+}
+
+func TestSignError(t *testing.T) {
+	srv := newTestServer(t, newTestConfig())
+	defer srv.Close()
+	cfg := srv.Config()
+
+	cfg.Key = "wrong-key"
+	_, err := order.NewOrder(cfg).PrePayOrder(&order.Params{TotalFee: 1, Body: "test", OutTradeNo: "1", TradeType: "NATIVE", NotifyURL: "http://127.0.0.1"})
+	assert.Error(t, err)
+	_, ok := srv.Order("1")
+	assert.False(t, ok)
+}
+
+func TestTransfer(t *testing.T) {
+	srv := newTestServer(t, newTestConfig())
+	defer srv.Close()
+	cfg := srv.Config()
+
+	tr := transfer.NewTransfer(cfg)
+	p := &transfer.Params{PartnerTradeNo: "10000098201411111234567890", OpenID: "oxTWIuGaIt6gTKsQRLau2M0yL16E", Amount: 100, Desc: "test"}
+	rsp, err := tr.WalletTransfer(p)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, rsp.PaymentNo)
+
+	// 重复付款返回原付款单
+	again, err := tr.SafeWalletTransfer(p, 1, time.Millisecond)
+	assert.Nil(t, err)
+	assert.Equal(t, rsp.PaymentNo, again.PaymentNo)
+
+	info, err := tr.QueryTransfer(&transfer.QueryParams{PartnerTradeNo: p.PartnerTradeNo})
+	assert.Nil(t, err)
+	assert.Equal(t, transfer.StatusSuccess, info.Status)
+	assert.Equal(t, money.Fen(100), info.PaymentAmount)
+
+	p.Amount = 1
+	p.PartnerTradeNo = "small"
+	_, err = tr.WalletTransfer(p)
+	assert.Error(t, err)
+}
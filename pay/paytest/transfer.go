@@ -0,0 +1,97 @@
+package paytest
+
+import (
+	"time"
+
+	"github.com/kuro-liang/wechat-go/pay/money"
+)
+
+const (
+	transfersPath       = "/mmpaymkttransfers/promotion/transfers"
+	getTransferInfoPath = "/mmpaymkttransfers/gettransferinfo"
+)
+
+// Transfer 模拟网关中保存的付款到零钱记录
+type Transfer struct {
+	PartnerTradeNo string
+	PaymentNo      string
+	OpenID         string
+	Amount         money.Fen
+	Desc           string
+	PaymentTime    string
+}
+
+// Transfer 返回付款记录的副本，记录不存在时返回 false
+func (s *Server) Transfer(partnerTradeNo string) (Transfer, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.transfers[partnerTradeNo]
+	if !ok {
+		return Transfer{}, false
+	}
+	return *t, true
+}
+
+// transfer 付款到零钱，相同商户订单号重复请求时返回原付款结果
+func (s *Server) transfer(param map[string]string) map[string]string {
+	if rsp := s.checkMerchant(param["mch_appid"], param["mchid"]); rsp != nil {
+		return rsp
+	}
+	amount, err := money.ParseFen(param["amount"])
+	if err != nil || amount < 30 {
+		return fail("AMOUNT_LIMIT", "付款金额不能小于最低限额")
+	}
+	if param["openid"] == "" {
+		return fail("PARAM_ERROR", "openid 不能为空")
+	}
+	if param["check_name"] == "FORCE_CHECK" && param["re_user_name"] == "" {
+		return fail("PARAM_ERROR", "re_user_name 不能为空")
+	}
+
+	t, ok := s.transfers[param["partner_trade_no"]]
+	if ok {
+		if t.OpenID != param["openid"] || t.Amount != amount {
+			return fail("PARAM_ERROR", "商户订单号重复，参数与原请求不一致")
+		}
+	} else {
+		t = &Transfer{
+			PartnerTradeNo: param["partner_trade_no"],
+			PaymentNo:      s.nextID("10000098"),
+			OpenID:         param["openid"],
+			Amount:         amount,
+			Desc:           param["desc"],
+			PaymentTime:    time.Now().Format("2006-01-02 15:04:05"),
+		}
+		s.transfers[t.PartnerTradeNo] = t
+	}
+	return map[string]string{
+		"mch_appid":        s.AppID,
+		"mchid":            s.MchID,
+		"partner_trade_no": t.PartnerTradeNo,
+		"payment_no":       t.PaymentNo,
+		"payment_time":     t.PaymentTime,
+	}
+}
+
+// getTransferInfo 查询付款到零钱
+func (s *Server) getTransferInfo(param map[string]string) map[string]string {
+	if rsp := s.checkMerchant(param["appid"], param["mch_id"]); rsp != nil {
+		return rsp
+	}
+	t, ok := s.transfers[param["partner_trade_no"]]
+	if !ok {
+		return fail("NOT_FOUND", "指定单号数据不存在")
+	}
+	return map[string]string{
+		"appid":            s.AppID,
+		"mch_id":           s.MchID,
+		"partner_trade_no": t.PartnerTradeNo,
+		"detail_id":        t.PaymentNo,
+		"status":           "SUCCESS",
+		"openid":           t.OpenID,
+		"payment_amount":   t.Amount.String(),
+		"transfer_time":    t.PaymentTime,
+		"payment_time":     t.PaymentTime,
+		"desc":             t.Desc,
+	}
+}
@@ -0,0 +1,343 @@
+package profitsharing
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// 分账
+// https://pay.weixin.qq.com/wiki/doc/api/allocation.php?chapter=26_1
+var (
+	addReceiverGateway    = "/pay/profitsharingaddreceiver"
+	removeReceiverGateway = "/pay/profitsharingremovereceiver"
+	profitSharingGateway  = "/secapi/pay/profitsharing"
+	multiSharingGateway   = "/secapi/pay/multiprofitsharing"
+	finishGateway         = "/secapi/pay/profitsharingfinish"
+	queryGateway          = "/pay/profitsharingquery"
+	returnGateway         = "/secapi/pay/profitsharingreturn"
+	returnQueryGateway    = "/pay/profitsharingreturnquery"
+)
+
+// 分账接收方类型
+const (
+	ReceiverTypeMerchant       = "MERCHANT_ID"         // 商户号
+	ReceiverTypePersonalOpenID = "PERSONAL_OPENID"     // 个人openid
+	ReceiverTypePersonalSubID  = "PERSONAL_SUB_OPENID" // 个人sub_openid
+)
+
+// 分账单状态
+const (
+	StatusAccepted   = "ACCEPTED"   // 受理成功
+	StatusProcessing = "PROCESSING" // 处理中
+	StatusFinished   = "FINISHED"   // 处理完成
+	StatusClosed     = "CLOSED"     // 处理失败，已关单
+)
+
+// 分账回退结果
+const (
+	ReturnResultProcessing = "PROCESSING"
+	ReturnResultSuccess    = "SUCCESS"
+	ReturnResultFailed     = "FAILED"
+)
+
+// ProfitSharing 分账，所有接口只支持 HMAC-SHA256 签名
+type ProfitSharing struct {
+	*config.Config
+}
+
+// NewProfitSharing return an instance of profitsharing package
+func NewProfitSharing(cfg *config.Config) *ProfitSharing {
+	return &ProfitSharing{cfg}
+}
+
+// Receiver 分账接收方
+type Receiver struct {
+	Type           string    `json:"type"`
+	Account        string    `json:"account"`
+	Name           string    `json:"name,omitempty"`
+	RelationType   string    `json:"relation_type,omitempty"`   // 添加接收方时必填，如 SERVICE_PROVIDER、STORE、PARTNER、CUSTOM
+	CustomRelation string    `json:"custom_relation,omitempty"` // RelationType 为 CUSTOM 时必填
+	Amount         money.Fen `json:"amount,omitempty"`          // 分账金额，单位分
+	Description    string    `json:"description,omitempty"`     // 分账描述
+	Result         string    `json:"result,omitempty"`          // 查询返回，PENDING/SUCCESS/CLOSED
+	FinishTime     string    `json:"finish_time,omitempty"`     // 查询返回
+	FailReason     string    `json:"fail_reason,omitempty"`     // 查询返回
+}
+
+// request 分账接口请求参数
+type request struct {
+	MchID             string     `xml:"mch_id"`
+	AppID             string     `xml:"appid,omitempty"`
+	NonceStr          string     `xml:"nonce_str"`
+	Sign              string     `xml:"sign"`
+	SignType          string     `xml:"sign_type"`
+	TransactionID     string     `xml:"transaction_id,omitempty"`
+	OutOrderNo        string     `xml:"out_order_no,omitempty"`
+	OrderID           string     `xml:"order_id,omitempty"`
+	OutReturnNo       string     `xml:"out_return_no,omitempty"`
+	ReturnAccountType string     `xml:"return_account_type,omitempty"`
+	ReturnAccount     string     `xml:"return_account,omitempty"`
+	ReturnAmount      money.Fen  `xml:"return_amount,omitempty"`
+	Amount            *money.Fen `xml:"amount,omitempty"` // 仅完结分账使用，固定为 0 且必须参与签名
+	Description       string     `xml:"description,omitempty"`
+	Receiver          string     `xml:"receiver,omitempty"`
+	Receivers         string     `xml:"receivers,omitempty"`
+	XMLName           struct{}   `xml:"xml"`
+}
+
+// params 参与签名的参数
+func (req *request) params() map[string]string {
+	param := map[string]string{
+		"mch_id":              req.MchID,
+		"appid":               req.AppID,
+		"nonce_str":           req.NonceStr,
+		"sign_type":           req.SignType,
+		"transaction_id":      req.TransactionID,
+		"out_order_no":        req.OutOrderNo,
+		"order_id":            req.OrderID,
+		"out_return_no":       req.OutReturnNo,
+		"return_account_type": req.ReturnAccountType,
+		"return_account":      req.ReturnAccount,
+		"description":         req.Description,
+		"receiver":            req.Receiver,
+		"receivers":           req.Receivers,
+	}
+	if req.ReturnAmount > 0 {
+		param["return_amount"] = req.ReturnAmount.String()
+	}
+	if req.Amount != nil {
+		param["amount"] = req.Amount.String()
+	}
+	return param
+}
+
+// Response 分账接口返回
+type Response struct {
+	ReturnCode        string    `xml:"return_code"`
+	ReturnMsg         string    `xml:"return_msg"`
+	ResultCode        string    `xml:"result_code,omitempty"`
+	ErrCode           string    `xml:"err_code,omitempty"`
+	ErrCodeDes        string    `xml:"err_code_des,omitempty"`
+	MchID             string    `xml:"mch_id,omitempty"`
+	AppID             string    `xml:"appid,omitempty"`
+	NonceStr          string    `xml:"nonce_str,omitempty"`
+	Sign              string    `xml:"sign,omitempty"`
+	TransactionID     string    `xml:"transaction_id,omitempty"`
+	OutOrderNo        string    `xml:"out_order_no,omitempty"`
+	OrderID           string    `xml:"order_id,omitempty"`
+	Status            string    `xml:"status,omitempty"`
+	CloseReason       string    `xml:"close_reason,omitempty"`
+	Amount            money.Fen `xml:"amount,omitempty"`
+	Description       string    `xml:"description,omitempty"`
+	Receiver          string    `xml:"receiver,omitempty"`  // 添加/删除接收方返回的 json
+	Receivers         string    `xml:"receivers,omitempty"` // 查询返回的 json，可使用 GetReceivers 解析
+	OutReturnNo       string    `xml:"out_return_no,omitempty"`
+	ReturnNo          string    `xml:"return_no,omitempty"`
+	ReturnAccountType string    `xml:"return_account_type,omitempty"`
+	ReturnAccount     string    `xml:"return_account,omitempty"`
+	ReturnAmount      money.Fen `xml:"return_amount,omitempty"`
+	Result            string    `xml:"result,omitempty"`
+	FailReason        string    `xml:"fail_reason,omitempty"`
+	FinishTime        string    `xml:"finish_time,omitempty"`
+}
+
+// GetReceivers 解析返回中的分账接收方列表
+func (rsp *Response) GetReceivers() ([]Receiver, error) {
+	var receivers []Receiver
+	if rsp.Receivers == "" {
+		return receivers, nil
+	}
+	err := json.Unmarshal([]byte(rsp.Receivers), &receivers)
+	return receivers, err
+}
+
+// AddReceiver 添加分账接收方
+func (ps *ProfitSharing) AddReceiver(receiver *Receiver) (*Response, error) {
+	data, err := json.Marshal(receiver)
+	if err != nil {
+		return nil, err
+	}
+	req := ps.newRequest()
+	req.Receiver = string(data)
+	return ps.send(addReceiverGateway, req, false, "")
+}
+
+// RemoveReceiver 删除分账接收方
+func (ps *ProfitSharing) RemoveReceiver(receiver *Receiver) (*Response, error) {
+	data, err := json.Marshal(&Receiver{Type: receiver.Type, Account: receiver.Account})
+	if err != nil {
+		return nil, err
+	}
+	req := ps.newRequest()
+	req.Receiver = string(data)
+	return ps.send(removeReceiverGateway, req, false, "")
+}
+
+// Params 请求单次分账/多次分账参数
+type Params struct {
+	TransactionID string     // 微信订单号
+	OutOrderNo    string     // 商户分账单号
+	Receivers     []Receiver // 分账接收方列表，最多 50 个
+	RootCa        string     // ca证书，Config 中已配置商户证书时可不传
+}
+
+// ProfitSharing 请求单次分账，分账后剩余资金自动解冻给商户
+func (ps *ProfitSharing) ProfitSharing(p *Params) (*Response, error) {
+	return ps.profitSharing(profitSharingGateway, p)
+}
+
+// MultiProfitSharing 请求多次分账，需要调用 Finish 完结分账才会解冻剩余资金
+func (ps *ProfitSharing) MultiProfitSharing(p *Params) (*Response, error) {
+	return ps.profitSharing(multiSharingGateway, p)
+}
+
+func (ps *ProfitSharing) profitSharing(gateway string, p *Params) (*Response, error) {
+	receivers := make([]Receiver, 0, len(p.Receivers))
+	for _, r := range p.Receivers {
+		receivers = append(receivers, Receiver{
+			Type:        r.Type,
+			Account:     r.Account,
+			Name:        r.Name,
+			Amount:      r.Amount,
+			Description: r.Description,
+		})
+	}
+	data, err := json.Marshal(receivers)
+	if err != nil {
+		return nil, err
+	}
+	req := ps.newRequest()
+	req.TransactionID = p.TransactionID
+	req.OutOrderNo = p.OutOrderNo
+	req.Receivers = string(data)
+	return ps.send(gateway, req, true, p.RootCa)
+}
+
+// FinishParams 完结分账参数
+type FinishParams struct {
+	TransactionID string // 微信订单号
+	OutOrderNo    string // 商户分账单号
+	Description   string // 分账完结描述
+	RootCa        string // ca证书，Config 中已配置商户证书时可不传
+}
+
+// Finish 完结分账，将剩余待分账资金全部解冻给商户
+func (ps *ProfitSharing) Finish(p *FinishParams) (*Response, error) {
+	req := ps.newRequest()
+	req.TransactionID = p.TransactionID
+	req.OutOrderNo = p.OutOrderNo
+	// 完结分账的分账金额固定为 0
+	req.Amount = new(money.Fen)
+	req.Description = p.Description
+	return ps.send(finishGateway, req, true, p.RootCa)
+}
+
+// QueryParams 查询分账结果参数
+type QueryParams struct {
+	TransactionID string // 微信订单号
+	OutOrderNo    string // 商户分账单号
+}
+
+// Query 查询分账结果
+func (ps *ProfitSharing) Query(p *QueryParams) (*Response, error) {
+	req := ps.newRequest()
+	// 查询接口不需要 appid
+	req.AppID = ""
+	req.TransactionID = p.TransactionID
+	req.OutOrderNo = p.OutOrderNo
+	return ps.send(queryGateway, req, false, "")
+}
+
+// ReturnParams 分账回退参数，OrderID 与 OutOrderNo 二选一
+type ReturnParams struct {
+	OrderID       string    // 微信分账单号
+	OutOrderNo    string    // 商户分账单号
+	OutReturnNo   string    // 商户回退单号
+	ReturnAccount string    // 回退方商户号
+	ReturnAmount  money.Fen // 回退金额，单位分
+	Description   string    // 回退描述
+	RootCa        string    // ca证书，Config 中已配置商户证书时可不传
+}
+
+// Return 分账回退，从分账接收方回退资金给分账方
+func (ps *ProfitSharing) Return(p *ReturnParams) (*Response, error) {
+	req := ps.newRequest()
+	req.OrderID = p.OrderID
+	if p.OrderID == "" {
+		req.OutOrderNo = p.OutOrderNo
+	}
+	req.OutReturnNo = p.OutReturnNo
+	req.ReturnAccountType = ReceiverTypeMerchant
+	req.ReturnAccount = p.ReturnAccount
+	req.ReturnAmount = p.ReturnAmount
+	req.Description = p.Description
+	return ps.send(returnGateway, req, true, p.RootCa)
+}
+
+// ReturnQueryParams 回退结果查询参数，OrderID 与 OutOrderNo 二选一
+type ReturnQueryParams struct {
+	OrderID     string // 微信分账单号
+	OutOrderNo  string // 商户分账单号
+	OutReturnNo string // 商户回退单号
+}
+
+// ReturnQuery 回退结果查询
+func (ps *ProfitSharing) ReturnQuery(p *ReturnQueryParams) (*Response, error) {
+	req := ps.newRequest()
+	req.OrderID = p.OrderID
+	if p.OrderID == "" {
+		req.OutOrderNo = p.OutOrderNo
+	}
+	req.OutReturnNo = p.OutReturnNo
+	return ps.send(returnQueryGateway, req, false, "")
+}
+
+func (ps *ProfitSharing) newRequest() *request {
+	return &request{
+		MchID:    ps.MchID,
+		AppID:    ps.AppID,
+		NonceStr: util.RandomStr(32),
+		SignType: util.SignTypeHMACSHA256,
+	}
+}
+
+// send 使用 HMAC-SHA256 签名并发送请求，withCert 为 true 时使用商户证书
+func (ps *ProfitSharing) send(gateway string, req *request, withCert bool, rootCa string) (rsp *Response, err error) {
+	key, err := ps.SignKey()
+	if err != nil {
+		return
+	}
+	req.Sign, err = util.ParamSign(req.params(), key)
+	if err != nil {
+		return
+	}
+
+	var rawRet []byte
+	if withCert {
+		rawRet, err = ps.PostXMLWithCert(ps.GatewayURL(gateway), req, rootCa)
+	} else {
+		rawRet, err = ps.PostXML(ps.GatewayURL(gateway), req)
+	}
+	if err != nil {
+		return
+	}
+	err = xml.Unmarshal(rawRet, &rsp)
+	if err != nil {
+		return
+	}
+	if rsp.ReturnCode == "SUCCESS" {
+		if rsp.ResultCode == "SUCCESS" {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("profit sharing error, errcode=%s,errmsg=%s", rsp.ErrCode, rsp.ErrCodeDes)
+		return
+	}
+	err = fmt.Errorf("[msg : xmlUnmarshalError] [rawReturn : %s] [sign : %s]", string(rawRet), req.Sign)
+	return
+}
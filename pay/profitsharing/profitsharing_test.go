@@ -0,0 +1,154 @@
+package profitsharing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/paytest"
+	"github.com/kuro-liang/wechat-go/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const testKey = "ziR0QKsTUfMOuochC9RfCdmfHECorQAP"
+
+// fakeGateway 模拟分账接口，校验 HMAC-SHA256 签名并在内存中保存分账单
+type fakeGateway struct {
+	mu        sync.Mutex
+	receivers map[string]bool
+	orders    map[string]string // out_order_no => receivers json
+	returns   map[string]bool
+}
+
+func (g *fakeGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	param, err := util.XMLToMap(body)
+	if err != nil || param["sign_type"] != util.SignTypeHMACSHA256 {
+		fmt.Fprint(w, `<xml><return_code>FAIL</return_code><return_msg>invalid request</return_msg></xml>`)
+		return
+	}
+	if sign, _ := util.ParamSign(param, testKey); sign != param["sign"] {
+		fmt.Fprint(w, `<xml><return_code>SUCCESS</return_code><result_code>FAIL</result_code><err_code>SIGNERROR</err_code></xml>`)
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	reply := func(fields string) {
+		fmt.Fprintf(w, `<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code>%s</xml>`, fields)
+	}
+	switch r.URL.Path {
+	case addReceiverGateway:
+		var receiver Receiver
+		_ = json.Unmarshal([]byte(param["receiver"]), &receiver)
+		g.receivers[receiver.Account] = true
+		reply("<receiver><![CDATA[" + param["receiver"] + "]]></receiver>")
+	case removeReceiverGateway:
+		var receiver Receiver
+		_ = json.Unmarshal([]byte(param["receiver"]), &receiver)
+		delete(g.receivers, receiver.Account)
+		reply("<receiver><![CDATA[" + param["receiver"] + "]]></receiver>")
+	case multiSharingGateway, profitSharingGateway:
+		var receivers []Receiver
+		_ = json.Unmarshal([]byte(param["receivers"]), &receivers)
+		for i := range receivers {
+			if !g.receivers[receivers[i].Account] {
+				fmt.Fprint(w, `<xml><return_code>SUCCESS</return_code><result_code>FAIL</result_code><err_code>RECEIVER_INVALID</err_code></xml>`)
+				return
+			}
+			receivers[i].Result = "SUCCESS"
+		}
+		data, _ := json.Marshal(receivers)
+		g.orders[param["out_order_no"]] = string(data)
+		reply("<order_id>3008450740201411110007820472</order_id><out_order_no>" + param["out_order_no"] + "</out_order_no>")
+	case finishGateway:
+		if amount, ok := param["amount"]; !ok || amount != "0" {
+			fmt.Fprint(w, `<xml><return_code>SUCCESS</return_code><result_code>FAIL</result_code><err_code>PARAM_ERROR</err_code></xml>`)
+			return
+		}
+		reply("<order_id>3008450740201411110007820473</order_id>")
+	case queryGateway:
+		reply("<status>FINISHED</status><out_order_no>" + param["out_order_no"] + "</out_order_no><receivers><![CDATA[" + g.orders[param["out_order_no"]] + "]]></receivers>")
+	case returnGateway:
+		g.returns[param["out_return_no"]] = true
+		reply("<out_return_no>" + param["out_return_no"] + "</out_return_no><result>PROCESSING</result>")
+	case returnQueryGateway:
+		if g.returns[param["out_return_no"]] {
+			reply("<out_return_no>" + param["out_return_no"] + "</out_return_no><result>SUCCESS</result>")
+			return
+		}
+		fmt.Fprint(w, `<xml><return_code>SUCCESS</return_code><result_code>FAIL</result_code><err_code>ORDER_NOT_EXIST</err_code></xml>`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestConfig(t *testing.T, baseURL string) *config.Config {
+	cert := paytest.NewTestCert(t, "10000100")
+	return &config.Config{
+		AppID:   "wx2421b1c4370ec43b",
+		MchID:   "10000100",
+		Key:     testKey,
+		BaseURL: baseURL,
+		CertPEM: cert.CertPEM,
+		KeyPEM:  cert.KeyPEM,
+	}
+}
+
+func TestProfitSharingFlow(t *testing.T) {
+	gateway := &fakeGateway{receivers: map[string]bool{}, orders: map[string]string{}, returns: map[string]bool{}}
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	ps := NewProfitSharing(newTestConfig(t, server.URL))
+
+	_, err := ps.MultiProfitSharing(&Params{
+		TransactionID: "4208450740201411110007820472",
+		OutOrderNo:    "P001",
+		Receivers:     []Receiver{{Type: ReceiverTypeMerchant, Account: "190001001", Amount: 100, Description: "分到商户"}},
+	})
+	assert.EqualError(t, err, "profit sharing error, errcode=RECEIVER_INVALID,errmsg=")
+
+	_, err = ps.AddReceiver(&Receiver{Type: ReceiverTypeMerchant, Account: "190001001", Name: "示例商户全称", RelationType: "STORE_OWNER"})
+	assert.Nil(t, err)
+
+	rsp, err := ps.MultiProfitSharing(&Params{
+		TransactionID: "4208450740201411110007820472",
+		OutOrderNo:    "P001",
+		Receivers:     []Receiver{{Type: ReceiverTypeMerchant, Account: "190001001", Amount: 100, Description: "分到商户"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "P001", rsp.OutOrderNo)
+
+	_, err = ps.Finish(&FinishParams{TransactionID: "4208450740201411110007820472", OutOrderNo: "P002", Description: "分账完结"})
+	assert.Nil(t, err)
+
+	rsp, err = ps.Query(&QueryParams{TransactionID: "4208450740201411110007820472", OutOrderNo: "P001"})
+	assert.Nil(t, err)
+	assert.Equal(t, StatusFinished, rsp.Status)
+	receivers, err := rsp.GetReceivers()
+	assert.Nil(t, err)
+	assert.Equal(t, []Receiver{{Type: ReceiverTypeMerchant, Account: "190001001", Amount: 100, Description: "分到商户", Result: "SUCCESS"}}, receivers)
+
+	rsp, err = ps.Return(&ReturnParams{OutOrderNo: "P001", OutReturnNo: "R001", ReturnAccount: "190001001", ReturnAmount: 100, Description: "用户退款"})
+	assert.Nil(t, err)
+	assert.Equal(t, ReturnResultProcessing, rsp.Result)
+
+	rsp, err = ps.ReturnQuery(&ReturnQueryParams{OutOrderNo: "P001", OutReturnNo: "R001"})
+	assert.Nil(t, err)
+	assert.Equal(t, ReturnResultSuccess, rsp.Result)
+
+	_, err = ps.RemoveReceiver(&Receiver{Type: ReceiverTypeMerchant, Account: "190001001"})
+	assert.Nil(t, err)
+	_, err = ps.ProfitSharing(&Params{
+		TransactionID: "4208450740201411110007820473",
+		OutOrderNo:    "P003",
+		Receivers:     []Receiver{{Type: ReceiverTypeMerchant, Account: "190001001", Amount: 100, Description: "分到商户"}},
+	})
+	assert.NotNil(t, err, "removed receiver should be rejected")
+}
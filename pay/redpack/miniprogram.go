@@ -0,0 +1,82 @@
+package redpack
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// 小程序红包
+// https://pay.weixin.qq.com/wiki/doc/api/tools/cash_coupon.php?chapter=18_2&index=3
+var sendMiniProgramHBGateway = "/mmpaymkttransfers/sendminiprogramhb"
+
+// MiniProgramParams 发放小程序红包参数
+type MiniProgramParams struct {
+	MchBillNo   string    // 商户订单号
+	SendName    string    // 商户名称
+	ReOpenID    string    // 接收红包的用户在小程序下的 openid
+	TotalAmount money.Fen // 付款金额
+	Wishing     string    // 红包祝福语
+	ActName     string    // 活动名称
+	Remark      string    // 备注
+	SceneID     string    // 场景id
+	RootCa      string    // ca证书，Config 中已配置商户证书时可不传
+}
+
+// BizRedPacketConfig 用于小程序调用 wx.sendBizRedPacket 的参数
+type BizRedPacketConfig struct {
+	TimeStamp string `json:"timeStamp"`
+	NonceStr  string `json:"nonceStr"`
+	Package   string `json:"package"`
+	SignType  string `json:"signType"`
+	PaySign   string `json:"paySign"`
+}
+
+// SendMiniProgramRedPack 发放小程序红包，返回的 Package 可用于 BizRedPacketConfig 生成小程序端参数
+func (redPack *RedPack) SendMiniProgramRedPack(p *MiniProgramParams) (rsp *Response, err error) {
+	if err = checkAmount(p.TotalAmount, 1, p.SceneID); err != nil {
+		return
+	}
+	req := &request{
+		NonceStr:    util.RandomStr(32),
+		MchBillNo:   p.MchBillNo,
+		MchID:       redPack.MchID,
+		WxAppID:     redPack.AppID,
+		SendName:    p.SendName,
+		ReOpenID:    p.ReOpenID,
+		TotalAmount: p.TotalAmount,
+		TotalNum:    1,
+		Wishing:     p.Wishing,
+		ActName:     p.ActName,
+		Remark:      p.Remark,
+		NotifyWay:   "MINI_PROGRAM_JSAPI",
+		SceneID:     p.SceneID,
+	}
+	return redPack.send(sendMiniProgramHBGateway, req, p.RootCa)
+}
+
+// BizRedPacketConfig 根据发放小程序红包返回的 package 生成 wx.sendBizRedPacket 的参数
+func (redPack *RedPack) BizRedPacketConfig(pkg string) (cfg BizRedPacketConfig, err error) {
+	key, err := redPack.SignKey()
+	if err != nil {
+		return
+	}
+	cfg = BizRedPacketConfig{
+		TimeStamp: strconv.FormatInt(time.Now().Unix(), 10),
+		NonceStr:  util.RandomStr(32),
+		Package:   url.QueryEscape(pkg),
+		SignType:  util.SignTypeMD5,
+	}
+	// 参与签名的字段为 appId、nonceStr、package、timeStamp，其中 package 需要 urlencode
+	param := map[string]string{
+		"appId":     redPack.AppID,
+		"nonceStr":  cfg.NonceStr,
+		"package":   cfg.Package,
+		"timeStamp": cfg.TimeStamp,
+	}
+	cfg.PaySign, err = util.ParamSign(param, key)
+	return
+}
@@ -0,0 +1,112 @@
+package redpack
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// 查询红包记录
+// https://pay.weixin.qq.com/wiki/doc/api/tools/cash_coupon.php?chapter=13_6&index=5
+var getHBInfoGateway = "/mmpaymkttransfers/gethbinfo"
+
+// 红包状态
+const (
+	StatusSending   = "SENDING"   // 发放中
+	StatusSent      = "SENT"      // 已发放待领取
+	StatusFailed    = "FAILED"    // 发放失败
+	StatusReceived  = "RECEIVED"  // 已领取
+	StatusRefunding = "RFUND_ING" // 退款中
+	StatusRefund    = "REFUND"    // 已退款
+)
+
+// QueryParams 查询红包记录参数
+type QueryParams struct {
+	MchBillNo string // 商户订单号
+	RootCa    string // ca证书，Config 中已配置商户证书时可不传
+}
+
+// queryRequest 查询红包记录请求参数
+type queryRequest struct {
+	NonceStr  string   `xml:"nonce_str"`
+	Sign      string   `xml:"sign"`
+	MchBillNo string   `xml:"mch_billno"`
+	MchID     string   `xml:"mch_id"`
+	AppID     string   `xml:"appid"`
+	BillType  string   `xml:"bill_type"`
+	XMLName   struct{} `xml:"xml"`
+}
+
+// HBInfo 裂变红包的领取记录
+type HBInfo struct {
+	OpenID  string    `xml:"openid"`
+	Amount  money.Fen `xml:"amount"`
+	RcvTime string    `xml:"rcv_time"`
+}
+
+// QueryResponse 查询红包记录返回
+type QueryResponse struct {
+	ReturnCode   string    `xml:"return_code"`
+	ReturnMsg    string    `xml:"return_msg"`
+	ResultCode   string    `xml:"result_code,omitempty"`
+	ErrCode      string    `xml:"err_code,omitempty"`
+	ErrCodeDes   string    `xml:"err_code_des,omitempty"`
+	MchBillNo    string    `xml:"mch_billno,omitempty"`
+	MchID        string    `xml:"mch_id,omitempty"`
+	DetailID     string    `xml:"detail_id,omitempty"`
+	Status       string    `xml:"status,omitempty"`
+	SendType     string    `xml:"send_type,omitempty"`
+	HBType       string    `xml:"hb_type,omitempty"`
+	TotalNum     int       `xml:"total_num,omitempty"`
+	TotalAmount  money.Fen `xml:"total_amount,omitempty"`
+	Reason       string    `xml:"reason,omitempty"`
+	SendTime     string    `xml:"send_time,omitempty"`
+	RefundTime   string    `xml:"refund_time,omitempty"`
+	RefundAmount money.Fen `xml:"refund_amount,omitempty"`
+	Wishing      string    `xml:"wishing,omitempty"`
+	Remark       string    `xml:"remark,omitempty"`
+	ActName      string    `xml:"act_name,omitempty"`
+	HBList       []HBInfo  `xml:"hblist>hbinfo"`
+}
+
+// GetHBInfo 查询红包记录
+func (redPack *RedPack) GetHBInfo(p *QueryParams) (rsp *QueryResponse, err error) {
+	req := queryRequest{
+		NonceStr:  util.RandomStr(32),
+		MchBillNo: p.MchBillNo,
+		MchID:     redPack.MchID,
+		AppID:     redPack.AppID,
+		BillType:  "MCHT",
+	}
+	req.Sign, err = redPack.sign(map[string]string{
+		"nonce_str":  req.NonceStr,
+		"mch_billno": req.MchBillNo,
+		"mch_id":     req.MchID,
+		"appid":      req.AppID,
+		"bill_type":  req.BillType,
+	})
+	if err != nil {
+		return
+	}
+
+	rawRet, err := redPack.PostXMLWithCert(redPack.GatewayURL(getHBInfoGateway), req, p.RootCa)
+	if err != nil {
+		return
+	}
+	err = xml.Unmarshal(rawRet, &rsp)
+	if err != nil {
+		return
+	}
+	if rsp.ReturnCode == "SUCCESS" {
+		if rsp.ResultCode == "SUCCESS" {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("get hbinfo error, errcode=%s,errmsg=%s", rsp.ErrCode, rsp.ErrCodeDes)
+		return
+	}
+	err = fmt.Errorf("[msg : xmlUnmarshalError] [rawReturn : %s] [sign : %s]", string(rawRet), req.Sign)
+	return
+}
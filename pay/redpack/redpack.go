@@ -0,0 +1,202 @@
+package redpack
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+var (
+	// 发放普通红包
+	// https://pay.weixin.qq.com/wiki/doc/api/tools/cash_coupon.php?chapter=13_4&index=3
+	sendRedPackGateway = "/mmpaymkttransfers/sendredpack"
+	// 发放裂变红包
+	// https://pay.weixin.qq.com/wiki/doc/api/tools/cash_coupon.php?chapter=13_5&index=4
+	sendGroupRedPackGateway = "/mmpaymkttransfers/sendgroupredpack"
+)
+
+// 红包发放场景，金额大于 200 元或小于 1 元时必传
+const (
+	SceneProduct1 = "PRODUCT_1" // 商品促销
+	SceneProduct2 = "PRODUCT_2" // 抽奖
+	SceneProduct3 = "PRODUCT_3" // 虚拟物品兑奖
+	SceneProduct4 = "PRODUCT_4" // 企业内部福利
+	SceneProduct5 = "PRODUCT_5" // 渠道分润
+	SceneProduct6 = "PRODUCT_6" // 保险回馈
+	SceneProduct7 = "PRODUCT_7" // 彩票派奖
+	SceneProduct8 = "PRODUCT_8" // 税务刮奖
+)
+
+// RedPack 现金红包
+type RedPack struct {
+	*config.Config
+}
+
+// NewRedPack return an instance of redpack package
+func NewRedPack(cfg *config.Config) *RedPack {
+	return &RedPack{cfg}
+}
+
+// Params 发放普通红包/裂变红包参数
+type Params struct {
+	MchBillNo   string    // 商户订单号
+	SendName    string    // 商户名称
+	ReOpenID    string    // 接收红包的用户，裂变红包为种子用户
+	TotalAmount money.Fen // 付款金额
+	TotalNum    int       // 红包发放总人数，普通红包固定为 1
+	Wishing     string    // 红包祝福语
+	ClientIP    string    // 调用接口的机器 IP，普通红包使用
+	ActName     string    // 活动名称
+	Remark      string    // 备注
+	SceneID     string    // 场景id
+	RiskInfo    string    // 活动信息，urlencode 后的 posttime=xx&mobile=xx&deviceid=xx
+	RootCa      string    // ca证书，Config 中已配置商户证书时可不传
+}
+
+// request 发放红包请求参数
+type request struct {
+	NonceStr    string    `xml:"nonce_str"`
+	Sign        string    `xml:"sign"`
+	MchBillNo   string    `xml:"mch_billno"`
+	MchID       string    `xml:"mch_id"`
+	WxAppID     string    `xml:"wxappid"`
+	SendName    string    `xml:"send_name"`
+	ReOpenID    string    `xml:"re_openid"`
+	TotalAmount money.Fen `xml:"total_amount"`
+	TotalNum    int       `xml:"total_num"`
+	AmtType     string    `xml:"amt_type,omitempty"`
+	Wishing     string    `xml:"wishing"`
+	ClientIP    string    `xml:"client_ip,omitempty"`
+	ActName     string    `xml:"act_name"`
+	Remark      string    `xml:"remark"`
+	NotifyWay   string    `xml:"notify_way,omitempty"`
+	SceneID     string    `xml:"scene_id,omitempty"`
+	RiskInfo    string    `xml:"risk_info,omitempty"`
+	XMLName     struct{}  `xml:"xml"`
+}
+
+// Response 发放红包返回
+type Response struct {
+	ReturnCode  string    `xml:"return_code"`
+	ReturnMsg   string    `xml:"return_msg"`
+	ResultCode  string    `xml:"result_code,omitempty"`
+	ErrCode     string    `xml:"err_code,omitempty"`
+	ErrCodeDes  string    `xml:"err_code_des,omitempty"`
+	MchBillNo   string    `xml:"mch_billno,omitempty"`
+	MchID       string    `xml:"mch_id,omitempty"`
+	WxAppID     string    `xml:"wxappid,omitempty"`
+	ReOpenID    string    `xml:"re_openid,omitempty"`
+	TotalAmount money.Fen `xml:"total_amount,omitempty"`
+	SendListID  string    `xml:"send_listid,omitempty"`
+	Package     string    `xml:"package,omitempty"` // 小程序红包返回，用于拼接 wx.sendBizRedPacket 的参数
+}
+
+// SendRedPack 发放普通红包
+func (redPack *RedPack) SendRedPack(p *Params) (rsp *Response, err error) {
+	if err = checkAmount(p.TotalAmount, 1, p.SceneID); err != nil {
+		return
+	}
+	req := redPack.newRequest(p)
+	req.TotalNum = 1
+	req.ClientIP = p.ClientIP
+	return redPack.send(sendRedPackGateway, req, p.RootCa)
+}
+
+// SendGroupRedPack 发放裂变红包，红包金额随机分配给 TotalNum 个用户
+func (redPack *RedPack) SendGroupRedPack(p *Params) (rsp *Response, err error) {
+	if err = checkAmount(p.TotalAmount, p.TotalNum, p.SceneID); err != nil {
+		return
+	}
+	req := redPack.newRequest(p)
+	req.AmtType = "ALL_RAND"
+	return redPack.send(sendGroupRedPackGateway, req, p.RootCa)
+}
+
+// checkAmount 校验平均每个红包的金额，传入场景 id 时使用 money.RedPackSceneLimit
+func checkAmount(total money.Fen, num int, sceneID string) error {
+	if num <= 0 {
+		return fmt.Errorf("invalid redpack total_num %d", num)
+	}
+	limit := money.RedPackLimit
+	if sceneID != "" {
+		limit = money.RedPackSceneLimit
+	}
+	n := money.Fen(num)
+	return money.Limit{Min: limit.Min * n, Max: limit.Max * n}.Check(total)
+}
+
+func (redPack *RedPack) newRequest(p *Params) *request {
+	return &request{
+		NonceStr:    util.RandomStr(32),
+		MchBillNo:   p.MchBillNo,
+		MchID:       redPack.MchID,
+		WxAppID:     redPack.AppID,
+		SendName:    p.SendName,
+		ReOpenID:    p.ReOpenID,
+		TotalAmount: p.TotalAmount,
+		TotalNum:    p.TotalNum,
+		Wishing:     p.Wishing,
+		ActName:     p.ActName,
+		Remark:      p.Remark,
+		SceneID:     p.SceneID,
+		RiskInfo:    p.RiskInfo,
+	}
+}
+
+// send 签名并发送红包请求
+func (redPack *RedPack) send(gateway string, req *request, rootCa string) (rsp *Response, err error) {
+	param := map[string]string{
+		"nonce_str":    req.NonceStr,
+		"mch_billno":   req.MchBillNo,
+		"mch_id":       req.MchID,
+		"wxappid":      req.WxAppID,
+		"send_name":    req.SendName,
+		"re_openid":    req.ReOpenID,
+		"total_amount": req.TotalAmount.String(),
+		"total_num":    strconv.Itoa(req.TotalNum),
+		"amt_type":     req.AmtType,
+		"wishing":      req.Wishing,
+		"client_ip":    req.ClientIP,
+		"act_name":     req.ActName,
+		"remark":       req.Remark,
+		"notify_way":   req.NotifyWay,
+		"scene_id":     req.SceneID,
+		"risk_info":    req.RiskInfo,
+	}
+	req.Sign, err = redPack.sign(param)
+	if err != nil {
+		return
+	}
+
+	rawRet, err := redPack.PostXMLWithCert(redPack.GatewayURL(gateway), req, rootCa)
+	if err != nil {
+		return
+	}
+	err = xml.Unmarshal(rawRet, &rsp)
+	if err != nil {
+		return
+	}
+	if rsp.ReturnCode == "SUCCESS" {
+		if rsp.ResultCode == "SUCCESS" {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("send redpack error, errcode=%s,errmsg=%s", rsp.ErrCode, rsp.ErrCodeDes)
+		return
+	}
+	err = fmt.Errorf("[msg : xmlUnmarshalError] [rawReturn : %s] [sign : %s]", string(rawRet), req.Sign)
+	return
+}
+
+// sign 红包接口只支持 MD5 签名
+func (redPack *RedPack) sign(param map[string]string) (string, error) {
+	key, err := redPack.SignKey()
+	if err != nil {
+		return "", err
+	}
+	return util.ParamSign(param, key)
+}
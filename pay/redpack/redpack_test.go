@@ -0,0 +1,158 @@
+package redpack
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/pay/paytest"
+	"github.com/kuro-liang/wechat-go/util"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+const testKey = "ziR0QKsTUfMOuochC9RfCdmfHECorQAP"
+
+// newTestConfig 使用本地生成的证书创建配置，并拦截证书 client 的请求
+func newTestConfig(t *testing.T) *config.Config {
+	cert := paytest.NewTestCert(t, "10000100")
+	cfg := &config.Config{
+		AppID:   "wx2421b1c4370ec43b",
+		MchID:   "10000100",
+		Key:     testKey,
+		CertPEM: cert.CertPEM,
+		KeyPEM:  cert.KeyPEM,
+	}
+	client, err := cfg.TLSClient("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gock.InterceptClient(client)
+	return cfg
+}
+
+// mockGateway 校验请求签名，并将请求参数保存到 sent
+func mockGateway(t *testing.T, gateway string, sent map[string]string, reply string) {
+	gock.New(config.DefaultBaseURL).Post(gateway).
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return false, err
+			}
+			param, err := util.XMLToMap(body)
+			if err != nil {
+				return false, err
+			}
+			sign, err := util.ParamSign(param, testKey)
+			assert.Nil(t, err)
+			assert.Equal(t, sign, param["sign"], "request sign mismatch")
+			for k, v := range param {
+				sent[k] = v
+			}
+			return true, nil
+		}).
+		Reply(200).BodyString(reply)
+}
+
+func TestSendRedPack(t *testing.T) {
+	defer gock.Off()
+	redPack := NewRedPack(newTestConfig(t))
+
+	sent := map[string]string{}
+	mockGateway(t, sendRedPackGateway, sent, `<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><mch_billno>R001</mch_billno><total_amount>100</total_amount><send_listid>100000000020150520314766074200</send_listid></xml>`)
+	rsp, err := redPack.SendRedPack(&Params{
+		MchBillNo:   "R001",
+		SendName:    "商户",
+		ReOpenID:    "oxTWIuGaIt6gTKsQRLau2M0yL16E",
+		TotalAmount: 100,
+		Wishing:     "感谢参与",
+		ClientIP:    "127.0.0.1",
+		ActName:     "活动",
+		Remark:      "备注",
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, money.Fen(100), rsp.TotalAmount)
+	}
+	assert.True(t, gock.IsDone())
+	assert.Equal(t, "100", sent["total_amount"])
+	assert.Equal(t, "1", sent["total_num"])
+	assert.Equal(t, "127.0.0.1", sent["client_ip"])
+	assert.Equal(t, "wx2421b1c4370ec43b", sent["wxappid"])
+
+	// 未传场景 id 时金额需在 1 至 200 元之间
+	_, err = redPack.SendRedPack(&Params{MchBillNo: "R002", TotalAmount: 50})
+	assert.EqualError(t, err, "amount 0.50 yuan out of range [1.00, 200.00]")
+}
+
+func TestSendGroupRedPack(t *testing.T) {
+	defer gock.Off()
+	redPack := NewRedPack(newTestConfig(t))
+
+	sent := map[string]string{}
+	mockGateway(t, sendGroupRedPackGateway, sent, `<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><mch_billno>G001</mch_billno><total_amount>300</total_amount></xml>`)
+	_, err := redPack.SendGroupRedPack(&Params{MchBillNo: "G001", ReOpenID: "o1", TotalAmount: 300, TotalNum: 3, SceneID: SceneProduct2})
+	assert.Nil(t, err)
+	assert.True(t, gock.IsDone())
+	assert.Equal(t, "ALL_RAND", sent["amt_type"])
+	assert.Equal(t, "3", sent["total_num"])
+	assert.Equal(t, SceneProduct2, sent["scene_id"])
+	_, ok := sent["client_ip"]
+	assert.False(t, ok)
+
+	// 按人均金额校验
+	_, err = redPack.SendGroupRedPack(&Params{MchBillNo: "G002", TotalAmount: 300, TotalNum: 4})
+	assert.EqualError(t, err, "amount 3.00 yuan out of range [4.00, 800.00]")
+	_, err = redPack.SendGroupRedPack(&Params{MchBillNo: "G003", TotalAmount: 300})
+	assert.EqualError(t, err, "invalid redpack total_num 0")
+}
+
+func TestMiniProgramRedPack(t *testing.T) {
+	defer gock.Off()
+	redPack := NewRedPack(newTestConfig(t))
+
+	sent := map[string]string{}
+	mockGateway(t, sendMiniProgramHBGateway, sent, `<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><mch_billno>M001</mch_billno><total_amount>100</total_amount><package><![CDATA[sendid=242e8abd163d300019b2cae74ba8e8c06e3f0e51ab84d16b3c80decd22a5b672&ver=8&sign=4110d649a5aef52dd6b95654ddf91ca7d5411ac159ace4e1a766b7d3967a1c3dfe1d256811445a4abda2d9cfa4a9b377a829258bd00d90313c6c346f2349fe5d&mchid=11475856&spid=11475856]]></package></xml>`)
+	rsp, err := redPack.SendMiniProgramRedPack(&MiniProgramParams{MchBillNo: "M001", ReOpenID: "o1", TotalAmount: 100})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, gock.IsDone())
+	assert.Equal(t, "MINI_PROGRAM_JSAPI", sent["notify_way"])
+	assert.Equal(t, "1", sent["total_num"])
+
+	cfg, err := redPack.BizRedPacketConfig(rsp.Package)
+	assert.Nil(t, err)
+	assert.Equal(t, url.QueryEscape(rsp.Package), cfg.Package)
+	assert.Equal(t, util.SignTypeMD5, cfg.SignType)
+	sign, err := util.ParamSign(map[string]string{
+		"appId":     "wx2421b1c4370ec43b",
+		"nonceStr":  cfg.NonceStr,
+		"package":   cfg.Package,
+		"timeStamp": cfg.TimeStamp,
+	}, testKey)
+	assert.Nil(t, err)
+	assert.Equal(t, sign, cfg.PaySign)
+}
+
+func TestGetHBInfo(t *testing.T) {
+	defer gock.Off()
+	redPack := NewRedPack(newTestConfig(t))
+
+	sent := map[string]string{}
+	mockGateway(t, getHBInfoGateway, sent, `<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><mch_billno>G001</mch_billno><status>RECEIVED</status><total_num>3</total_num><total_amount>300</total_amount><refund_amount>0</refund_amount><hblist><hbinfo><openid>o1</openid><amount>120</amount><rcv_time>2015-04-21 20:00:00</rcv_time></hbinfo><hbinfo><openid>o2</openid><amount>180</amount><rcv_time>2015-04-21 20:01:00</rcv_time></hbinfo></hblist></xml>`)
+	rsp, err := redPack.GetHBInfo(&QueryParams{MchBillNo: "G001"})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, gock.IsDone())
+	assert.Equal(t, "MCHT", sent["bill_type"])
+	assert.Equal(t, StatusReceived, rsp.Status)
+	assert.Equal(t, money.Fen(300), rsp.TotalAmount)
+	assert.Equal(t, []HBInfo{
+		{OpenID: "o1", Amount: 120, RcvTime: "2015-04-21 20:00:00"},
+		{OpenID: "o2", Amount: 180, RcvTime: "2015-04-21 20:01:00"},
+	}, rsp.HBList)
+}
@@ -0,0 +1,298 @@
+package refund
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/pay/notify"
+	"github.com/kuro-liang/wechat-go/util"
+	"github.com/spf13/cast"
+)
+
+// 查询退款
+// https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_5
+var queryGateway = "/pay/refundquery"
+
+// QueryParams 查询退款参数，四个单号任选一个，优先级 RefundID > OutRefundNo > TransactionID > OutTradeNo
+type QueryParams struct {
+	TransactionID string // 微信订单号
+	OutTradeNo    string // 商户订单号
+	OutRefundNo   string // 商户退款单号
+	RefundID      string // 微信退款单号
+	Offset        int    // 偏移量，订单总退款次数超过10次时可使用
+	SignType      string
+	SubAppID      string // 服务商模式下的子商户公众账号ID，为空时使用 Config 中的配置
+	SubMchID      string // 服务商模式下的子商户号，为空时使用 Config 中的配置
+}
+
+// queryRequest 查询退款请求参数
+type queryRequest struct {
+	AppID         string   `xml:"appid"`
+	MchID         string   `xml:"mch_id"`
+	SubAppID      string   `xml:"sub_appid,omitempty"`
+	SubMchID      string   `xml:"sub_mch_id,omitempty"`
+	NonceStr      string   `xml:"nonce_str"`
+	Sign          string   `xml:"sign"`
+	SignType      string   `xml:"sign_type,omitempty"`
+	TransactionID string   `xml:"transaction_id,omitempty"`
+	OutTradeNo    string   `xml:"out_trade_no,omitempty"`
+	OutRefundNo   string   `xml:"out_refund_no,omitempty"`
+	RefundID      string   `xml:"refund_id,omitempty"`
+	Offset        string   `xml:"offset,omitempty"`
+	XMLName       struct{} `xml:"xml"`
+}
+
+// QueryCoupon 单笔退款中的代金券退款信息
+type QueryCoupon struct {
+	CouponType      string    // coupon_type_$n_$m
+	CouponRefundID  string    // coupon_refund_id_$n_$m
+	CouponRefundFee money.Fen // coupon_refund_fee_$n_$m
+}
+
+// QueryRefundItem 单笔退款记录，对应返回中下标为 $n 的字段
+type QueryRefundItem struct {
+	OutRefundNo         string        // out_refund_no_$n
+	RefundID            string        // refund_id_$n
+	RefundChannel       string        // refund_channel_$n
+	RefundFee           money.Fen     // refund_fee_$n
+	SettlementRefundFee money.Fen     // settlement_refund_fee_$n
+	CouponRefundFee     money.Fen     // coupon_refund_fee_$n
+	CouponRefundCount   int           // coupon_refund_count_$n
+	Coupons             []QueryCoupon // coupon_*_$n_$m
+	RefundStatus        string        // refund_status_$n
+	RefundAccount       string        // refund_account_$n
+	RefundRecvAccount   string        // refund_recv_accout_$n
+	RefundSuccessTime   string        // refund_success_time_$n
+}
+
+// QueryResponse 查询退款返回
+type QueryResponse struct {
+	ReturnCode         string
+	ReturnMsg          string
+	ResultCode         string
+	ErrCode            string
+	ErrCodeDes         string
+	AppID              string
+	MchID              string
+	SubAppID           string
+	SubMchID           string
+	NonceStr           string
+	Sign               string
+	TotalRefundCount   int // 订单总共已发生的部分退款次数，传入 offset 时返回
+	TransactionID      string
+	OutTradeNo         string
+	TotalFee           money.Fen
+	SettlementTotalFee money.Fen
+	FeeType            string
+	CashFee            money.Fen
+	RefundCount        int // 当前返回的退款笔数
+	Refunds            []QueryRefundItem
+}
+
+// Query 查询退款
+func (refund *Refund) Query(p *QueryParams) (rsp QueryResponse, err error) {
+	return refund.query(p, p.Offset > 0)
+}
+
+// query 查询退款，paged 为 true 时总是传入 offset（包括 0），微信只在传入 offset 时返回 total_refund_count
+func (refund *Refund) query(p *QueryParams, paged bool) (rsp QueryResponse, err error) {
+	nonceStr := util.RandomStr(32)
+	signType := refund.ResolveSignType(p.SignType)
+	key, err := refund.SignKey()
+	if err != nil {
+		return
+	}
+
+	param := make(map[string]string)
+	param["appid"] = refund.AppID
+	param["mch_id"] = refund.MchID
+	param["nonce_str"] = nonceStr
+	param["sign_type"] = signType
+	param["sub_appid"], param["sub_mch_id"] = refund.ResolveSubMerchant(p.SubAppID, p.SubMchID)
+	switch {
+	case p.RefundID != "":
+		param["refund_id"] = p.RefundID
+	case p.OutRefundNo != "":
+		param["out_refund_no"] = p.OutRefundNo
+	case p.TransactionID != "":
+		param["transaction_id"] = p.TransactionID
+	case p.OutTradeNo != "":
+		param["out_trade_no"] = p.OutTradeNo
+	default:
+		err = errors.New("refund query needs one of refund_id, out_refund_no, transaction_id, out_trade_no")
+		return
+	}
+	if paged {
+		param["offset"] = strconv.Itoa(p.Offset)
+	}
+
+	sign, err := util.ParamSign(param, key)
+	if err != nil {
+		return
+	}
+
+	req := queryRequest{
+		AppID:         param["appid"],
+		MchID:         param["mch_id"],
+		SubAppID:      param["sub_appid"],
+		SubMchID:      param["sub_mch_id"],
+		NonceStr:      param["nonce_str"],
+		Sign:          sign,
+		SignType:      param["sign_type"],
+		TransactionID: param["transaction_id"],
+		OutTradeNo:    param["out_trade_no"],
+		OutRefundNo:   param["out_refund_no"],
+		RefundID:      param["refund_id"],
+		Offset:        param["offset"],
+	}
+
+	rawRet, err := refund.PostXML(refund.GatewayURL(queryGateway), req)
+	if err != nil {
+		return
+	}
+	rsp, err = ParseQueryResponse(rawRet)
+	if err != nil {
+		return
+	}
+	if rsp.ReturnCode == "SUCCESS" {
+		if rsp.ResultCode == "SUCCESS" {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("refund query error, errcode=%s,errmsg=%s", rsp.ErrCode, rsp.ErrCodeDes)
+		return
+	}
+	err = fmt.Errorf("[msg : xmlUnmarshalError] [rawReturn : %s] [sign : %s]", string(rawRet), sign)
+	return
+}
+
+// QueryAll 按 transaction_id 或 out_trade_no 分页查询订单下的全部退款记录
+func (refund *Refund) QueryAll(p *QueryParams) (rsp QueryResponse, err error) {
+	if p.TransactionID == "" && p.OutTradeNo == "" {
+		err = errors.New("refund query all needs transaction_id or out_trade_no")
+		return
+	}
+	page := *p
+	page.RefundID = ""
+	page.OutRefundNo = ""
+	for first := true; ; first = false {
+		var cur QueryResponse
+		cur, err = refund.query(&page, true)
+		if err != nil {
+			return
+		}
+		if first {
+			rsp = cur
+		} else {
+			rsp.Refunds = append(rsp.Refunds, cur.Refunds...)
+			rsp.RefundCount = len(rsp.Refunds)
+		}
+		if cur.RefundCount == 0 || p.Offset+len(rsp.Refunds) >= cur.TotalRefundCount {
+			return
+		}
+		page.Offset = p.Offset + len(rsp.Refunds)
+	}
+}
+
+// QueryByReqInfo 根据退款结果通知（解密后的 req_info）查询对应的退款记录
+// 返回整体查询结果以及与通知中 out_refund_no / refund_id 匹配的那一笔退款
+func (refund *Refund) QueryByReqInfo(info *notify.RefundedReqInfo) (rsp QueryResponse, item *QueryRefundItem, err error) {
+	if info == nil {
+		err = errors.New("empty refunded req_info")
+		return
+	}
+	p := &QueryParams{}
+	if info.RefundID != nil {
+		p.RefundID = *info.RefundID
+	}
+	if info.OutRefundNO != nil {
+		p.OutRefundNo = *info.OutRefundNO
+	}
+	rsp, err = refund.Query(p)
+	if err != nil {
+		return
+	}
+	item = rsp.FindRefund(p.OutRefundNo, p.RefundID)
+	if item == nil {
+		err = fmt.Errorf("refund not found, out_refund_no=%s,refund_id=%s", p.OutRefundNo, p.RefundID)
+	}
+	return
+}
+
+// FindRefund 按商户退款单号或微信退款单号查找退款记录，找不到时返回 nil
+func (rsp *QueryResponse) FindRefund(outRefundNo, refundID string) *QueryRefundItem {
+	for i := range rsp.Refunds {
+		item := &rsp.Refunds[i]
+		if refundID != "" && item.RefundID == refundID {
+			return item
+		}
+		if outRefundNo != "" && item.OutRefundNo == outRefundNo {
+			return item
+		}
+	}
+	return nil
+}
+
+// ParseQueryResponse 解析查询退款的返回，将 refund_fee_$n 等带下标的字段展开为切片
+func ParseQueryResponse(data []byte) (rsp QueryResponse, err error) {
+	m, err := util.XMLToMap(data)
+	if err != nil {
+		return
+	}
+	rsp = QueryResponse{
+		ReturnCode:         m["return_code"],
+		ReturnMsg:          m["return_msg"],
+		ResultCode:         m["result_code"],
+		ErrCode:            m["err_code"],
+		ErrCodeDes:         m["err_code_des"],
+		AppID:              m["appid"],
+		MchID:              m["mch_id"],
+		SubAppID:           m["sub_appid"],
+		SubMchID:           m["sub_mch_id"],
+		NonceStr:           m["nonce_str"],
+		Sign:               m["sign"],
+		TotalRefundCount:   cast.ToInt(m["total_refund_count"]),
+		TransactionID:      m["transaction_id"],
+		OutTradeNo:         m["out_trade_no"],
+		TotalFee:           money.Fen(cast.ToInt64(m["total_fee"])),
+		SettlementTotalFee: money.Fen(cast.ToInt64(m["settlement_total_fee"])),
+		FeeType:            m["fee_type"],
+		CashFee:            money.Fen(cast.ToInt64(m["cash_fee"])),
+		RefundCount:        cast.ToInt(m["refund_count"]),
+	}
+
+	// 传入 offset 时返回的下标不一定从 0 开始，这里按实际出现的下标顺序收集
+	for n := 0; len(rsp.Refunds) < rsp.RefundCount && n < rsp.RefundCount+rsp.TotalRefundCount+1; n++ {
+		idx := strconv.Itoa(n)
+		if _, ok := m["refund_id_"+idx]; !ok {
+			if _, ok = m["out_refund_no_"+idx]; !ok {
+				continue
+			}
+		}
+		item := QueryRefundItem{
+			OutRefundNo:         m["out_refund_no_"+idx],
+			RefundID:            m["refund_id_"+idx],
+			RefundChannel:       m["refund_channel_"+idx],
+			RefundFee:           money.Fen(cast.ToInt64(m["refund_fee_"+idx])),
+			SettlementRefundFee: money.Fen(cast.ToInt64(m["settlement_refund_fee_"+idx])),
+			CouponRefundFee:     money.Fen(cast.ToInt64(m["coupon_refund_fee_"+idx])),
+			CouponRefundCount:   cast.ToInt(m["coupon_refund_count_"+idx]),
+			RefundStatus:        m["refund_status_"+idx],
+			RefundAccount:       m["refund_account_"+idx],
+			RefundRecvAccount:   m["refund_recv_accout_"+idx],
+			RefundSuccessTime:   m["refund_success_time_"+idx],
+		}
+		for j := 0; j < item.CouponRefundCount; j++ {
+			sub := idx + "_" + strconv.Itoa(j)
+			item.Coupons = append(item.Coupons, QueryCoupon{
+				CouponType:      m["coupon_type_"+sub],
+				CouponRefundID:  m["coupon_refund_id_"+sub],
+				CouponRefundFee: money.Fen(cast.ToInt64(m["coupon_refund_fee_"+sub])),
+			})
+		}
+		rsp.Refunds = append(rsp.Refunds, item)
+	}
+	return
+}
@@ -0,0 +1,114 @@
+package refund
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestParseQueryResponse(t *testing.T) {
+	raw := `<xml>
+<appid><![CDATA[wx2421b1c4370ec43b]]></appid>
+<mch_id><![CDATA[10000100]]></mch_id>
+<nonce_str><![CDATA[TeqClE3i0mvn3DrK]]></nonce_str>
+<out_refund_no_0><![CDATA[1415701182]]></out_refund_no_0>
+<out_trade_no><![CDATA[1415757673]]></out_trade_no>
+<refund_count>2</refund_count>
+<refund_fee_0>1</refund_fee_0>
+<refund_id_0><![CDATA[2008450740201411110000174436]]></refund_id_0>
+<refund_status_0><![CDATA[PROCESSING]]></refund_status_0>
+<coupon_refund_count_0>2</coupon_refund_count_0>
+<coupon_refund_fee_0>3</coupon_refund_fee_0>
+<coupon_type_0_0><![CDATA[CASH]]></coupon_type_0_0>
+<coupon_refund_id_0_0><![CDATA[10000]]></coupon_refund_id_0_0>
+<coupon_refund_fee_0_0>1</coupon_refund_fee_0_0>
+<coupon_type_0_1><![CDATA[NO_CASH]]></coupon_type_0_1>
+<coupon_refund_id_0_1><![CDATA[10001]]></coupon_refund_id_0_1>
+<coupon_refund_fee_0_1>2</coupon_refund_fee_0_1>
+<out_refund_no_1><![CDATA[1415701183]]></out_refund_no_1>
+<refund_fee_1>5</refund_fee_1>
+<refund_id_1><![CDATA[2008450740201411110000174437]]></refund_id_1>
+<refund_status_1><![CDATA[SUCCESS]]></refund_status_1>
+<refund_recv_accout_1><![CDATA[支付用户的零钱]]></refund_recv_accout_1>
+<result_code><![CDATA[SUCCESS]]></result_code>
+<return_code><![CDATA[SUCCESS]]></return_code>
+<return_msg><![CDATA[OK]]></return_msg>
+<sign><![CDATA[1F2841558E233C33ABA71A961D27561C]]></sign>
+<total_fee>101</total_fee>
+<transaction_id><![CDATA[1008450740201411110005820873]]></transaction_id>
+</xml>`
+	rsp, err := ParseQueryResponse([]byte(raw))
+	assert.Nil(t, err)
+	assert.Equal(t, "SUCCESS", rsp.ResultCode)
+	assert.Equal(t, money.Fen(101), rsp.TotalFee)
+	assert.Equal(t, 2, rsp.RefundCount)
+	assert.Len(t, rsp.Refunds, 2)
+
+	first := rsp.Refunds[0]
+	assert.Equal(t, "1415701182", first.OutRefundNo)
+	assert.Equal(t, money.Fen(1), first.RefundFee)
+	assert.Equal(t, "PROCESSING", first.RefundStatus)
+	assert.Equal(t, []QueryCoupon{
+		{CouponType: "CASH", CouponRefundID: "10000", CouponRefundFee: 1},
+		{CouponType: "NO_CASH", CouponRefundID: "10001", CouponRefundFee: 2},
+	}, first.Coupons)
+
+	item := rsp.FindRefund("", "2008450740201411110000174437")
+	if assert.NotNil(t, item) {
+		assert.Equal(t, money.Fen(5), item.RefundFee)
+		assert.Equal(t, "支付用户的零钱", item.RefundRecvAccount)
+	}
+	assert.Nil(t, rsp.FindRefund("not-exist", ""))
+}
+
+// refundQueryPage 返回下标为 [from, to) 的退款记录
+func refundQueryPage(total, from, to int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code>"+
+		"<out_trade_no>1415757673</out_trade_no><total_fee>%d</total_fee><total_refund_count>%d</total_refund_count><refund_count>%d</refund_count>",
+		total*10, total, to-from)
+	for n := from; n < to; n++ {
+		fmt.Fprintf(&b, "<out_refund_no_%d>R%02d</out_refund_no_%d><refund_id_%d>%d</refund_id_%d><refund_fee_%d>10</refund_fee_%d>", n, n, n, n, 5000+n, n, n, n)
+	}
+	b.WriteString("</xml>")
+	return b.String()
+}
+
+func TestQueryAll(t *testing.T) {
+	defer gock.Off()
+	cfg := &config.Config{AppID: "wx2421b1c4370ec43b", MchID: "10000100", Key: "ziR0QKsTUfMOuochC9RfCdmfHECorQAP"}
+
+	// 第一页也需要传入 offset，否则不会返回 total_refund_count
+	gock.New(config.DefaultBaseURL).Post(queryGateway).BodyString("<offset>0</offset>").Times(1).Reply(200).
+		BodyString(refundQueryPage(12, 0, 10))
+	gock.New(config.DefaultBaseURL).Post(queryGateway).BodyString("<offset>10</offset>").Times(1).Reply(200).
+		BodyString(refundQueryPage(12, 10, 12))
+
+	p := &QueryParams{OutTradeNo: "1415757673"}
+	rsp, err := NewRefund(cfg).QueryAll(p)
+	assert.Nil(t, err)
+	assert.True(t, gock.IsDone())
+	assert.Equal(t, "1415757673", rsp.OutTradeNo)
+	assert.Equal(t, money.Fen(120), rsp.TotalFee)
+	assert.Equal(t, 12, rsp.TotalRefundCount)
+	assert.Equal(t, 12, rsp.RefundCount)
+	if assert.Len(t, rsp.Refunds, 12) {
+		assert.Equal(t, "R00", rsp.Refunds[0].OutRefundNo)
+		assert.Equal(t, "R11", rsp.Refunds[11].OutRefundNo)
+	}
+	assert.Empty(t, p.SignType, "should not modify the caller's params")
+
+	// 从指定偏移量开始查询时，返回的订单信息取自第一次查询
+	gock.New(config.DefaultBaseURL).Post(queryGateway).BodyString("<offset>10</offset>").Times(1).Reply(200).
+		BodyString(refundQueryPage(12, 10, 12))
+	rsp, err = NewRefund(cfg).QueryAll(&QueryParams{OutTradeNo: "1415757673", Offset: 10})
+	assert.Nil(t, err)
+	assert.True(t, gock.IsDone())
+	assert.Equal(t, "1415757673", rsp.OutTradeNo)
+	assert.Len(t, rsp.Refunds, 2)
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 
 	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
 	"github.com/kuro-liang/wechat-go/util"
 )
 
-var refundGateway = "https://api.mch.weixin.qq.com/secapi/pay/refund"
+// https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_4
+var refundGateway = "/secapi/pay/refund"
 
 // Refund struct extends context
 type Refund struct {
@@ -26,59 +28,76 @@ type Params struct {
 	TransactionID string
 	OutRefundNo   string
 	OutTradeNo    string
-	TotalFee      string
-	RefundFee     string
+	TotalFee      money.Fen // 订单金额，单位分
+	RefundFee     money.Fen // 退款金额，单位分
 	RefundDesc    string
-	RootCa        string // ca证书
+	RootCa        string // ca证书，Config 中已配置商户证书时可不传
 	NotifyURL     string
 	SignType      string
+	SubAppID      string // 服务商模式下的子商户公众账号ID，为空时使用 Config 中的配置
+	SubMchID      string // 服务商模式下的子商户号，为空时使用 Config 中的配置
 }
 
 // request 接口请求参数
 type request struct {
-	AppID         string `xml:"appid"`
-	MchID         string `xml:"mch_id"`
-	NonceStr      string `xml:"nonce_str"`
-	Sign          string `xml:"sign"`
-	SignType      string `xml:"sign_type,omitempty"`
-	TransactionID string `xml:"transaction_id,omitempty"`
-	OutTradeNo    string `xml:"out_trade_no,omitempty"`
-	OutRefundNo   string `xml:"out_refund_no"`
-	TotalFee      string `xml:"total_fee"`
-	RefundFee     string `xml:"refund_fee"`
-	RefundDesc    string `xml:"refund_desc,omitempty"`
-	NotifyURL     string `xml:"notify_url,omitempty"`
+	AppID         string    `xml:"appid"`
+	MchID         string    `xml:"mch_id"`
+	SubAppID      string    `xml:"sub_appid,omitempty"`
+	SubMchID      string    `xml:"sub_mch_id,omitempty"`
+	NonceStr      string    `xml:"nonce_str"`
+	Sign          string    `xml:"sign"`
+	SignType      string    `xml:"sign_type,omitempty"`
+	TransactionID string    `xml:"transaction_id,omitempty"`
+	OutTradeNo    string    `xml:"out_trade_no,omitempty"`
+	OutRefundNo   string    `xml:"out_refund_no"`
+	TotalFee      money.Fen `xml:"total_fee"`
+	RefundFee     money.Fen `xml:"refund_fee"`
+	RefundDesc    string    `xml:"refund_desc,omitempty"`
+	NotifyURL     string    `xml:"notify_url,omitempty"`
 }
 
 // Response 接口返回
 type Response struct {
-	ReturnCode          string `xml:"return_code"`
-	ReturnMsg           string `xml:"return_msg"`
-	AppID               string `xml:"appid,omitempty"`
-	MchID               string `xml:"mch_id,omitempty"`
-	NonceStr            string `xml:"nonce_str,omitempty"`
-	Sign                string `xml:"sign,omitempty"`
-	ResultCode          string `xml:"result_code,omitempty"`
-	ErrCode             string `xml:"err_code,omitempty"`
-	ErrCodeDes          string `xml:"err_code_des,omitempty"`
-	TransactionID       string `xml:"transaction_id,omitempty"`
-	OutTradeNo          string `xml:"out_trade_no,omitempty"`
-	OutRefundNo         string `xml:"out_refund_no,omitempty"`
-	RefundID            string `xml:"refund_id,omitempty"`
-	RefundFee           string `xml:"refund_fee,omitempty"`
-	SettlementRefundFee string `xml:"settlement_refund_fee,omitempty"`
-	TotalFee            string `xml:"total_fee,omitempty"`
-	SettlementTotalFee  string `xml:"settlement_total_fee,omitempty"`
-	FeeType             string `xml:"fee_type,omitempty"`
-	CashFee             string `xml:"cash_fee,omitempty"`
-	CashFeeType         string `xml:"cash_fee_type,omitempty"`
+	ReturnCode          string    `xml:"return_code"`
+	ReturnMsg           string    `xml:"return_msg"`
+	AppID               string    `xml:"appid,omitempty"`
+	MchID               string    `xml:"mch_id,omitempty"`
+	SubAppID            string    `xml:"sub_appid,omitempty"`
+	SubMchID            string    `xml:"sub_mch_id,omitempty"`
+	NonceStr            string    `xml:"nonce_str,omitempty"`
+	Sign                string    `xml:"sign,omitempty"`
+	ResultCode          string    `xml:"result_code,omitempty"`
+	ErrCode             string    `xml:"err_code,omitempty"`
+	ErrCodeDes          string    `xml:"err_code_des,omitempty"`
+	TransactionID       string    `xml:"transaction_id,omitempty"`
+	OutTradeNo          string    `xml:"out_trade_no,omitempty"`
+	OutRefundNo         string    `xml:"out_refund_no,omitempty"`
+	RefundID            string    `xml:"refund_id,omitempty"`
+	RefundFee           money.Fen `xml:"refund_fee,omitempty"`
+	SettlementRefundFee money.Fen `xml:"settlement_refund_fee,omitempty"`
+	TotalFee            money.Fen `xml:"total_fee,omitempty"`
+	SettlementTotalFee  money.Fen `xml:"settlement_total_fee,omitempty"`
+	FeeType             string    `xml:"fee_type,omitempty"`
+	CashFee             money.Fen `xml:"cash_fee,omitempty"`
+	CashFeeType         string    `xml:"cash_fee_type,omitempty"`
 }
 
 // Refund 退款申请
 func (refund *Refund) Refund(p *Params) (rsp Response, err error) {
+	if err = money.RefundLimit.Check(p.RefundFee); err != nil {
+		return
+	}
+	if p.RefundFee > p.TotalFee {
+		err = fmt.Errorf("refund amount %s yuan exceeds total amount %s yuan", p.RefundFee.Yuan(), p.TotalFee.Yuan())
+		return
+	}
 	param := refund.GetSignParam(p)
 
-	sign, err := util.ParamSign(param, refund.Key)
+	key, err := refund.SignKey()
+	if err != nil {
+		return
+	}
+	sign, err := util.ParamSign(param, key)
 	if err != nil {
 		return
 	}
@@ -86,12 +105,14 @@ func (refund *Refund) Refund(p *Params) (rsp Response, err error) {
 	req := request{
 		AppID:       param["appid"],
 		MchID:       param["mch_id"],
+		SubAppID:    param["sub_appid"],
+		SubMchID:    param["sub_mch_id"],
 		NonceStr:    param["nonce_str"],
 		Sign:        sign,
 		SignType:    param["sign_type"],
 		OutRefundNo: param["out_refund_no"],
-		TotalFee:    param["total_fee"],
-		RefundFee:   param["refund_fee"],
+		TotalFee:    p.TotalFee,
+		RefundFee:   p.RefundFee,
 		RefundDesc:  param["refund_desc"],
 		NotifyURL:   param["notify_url"],
 	}
@@ -102,7 +123,7 @@ func (refund *Refund) Refund(p *Params) (rsp Response, err error) {
 		req.TransactionID = p.TransactionID
 	}
 
-	rawRet, err := util.PostXMLWithTLS(refundGateway, req, p.RootCa, refund.MchID)
+	rawRet, err := refund.PostXMLWithCert(refund.GatewayURL(refundGateway), req, p.RootCa)
 	if err != nil {
 		return
 	}
@@ -131,12 +152,17 @@ func (refund *Refund) GetSignParam(p *Params) (param map[string]string) {
 	param["nonce_str"] = nonceStr
 	param["out_refund_no"] = p.OutRefundNo
 	param["refund_desc"] = p.RefundDesc
-	param["refund_fee"] = p.RefundFee
-	param["total_fee"] = p.TotalFee
-
-	if p.SignType == "" {
-		param["sign_type"] = util.SignTypeMD5
+	param["refund_fee"] = p.RefundFee.String()
+	param["total_fee"] = p.TotalFee.String()
+	subAppID, subMchID := refund.ResolveSubMerchant(p.SubAppID, p.SubMchID)
+	if subAppID != "" {
+		param["sub_appid"] = subAppID
 	}
+	if subMchID != "" {
+		param["sub_mch_id"] = subMchID
+	}
+
+	param["sign_type"] = refund.ResolveSignType(p.SignType)
 	if p.OutTradeNo != "" {
 		param["out_trade_no"] = p.OutTradeNo
 	}
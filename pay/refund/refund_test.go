@@ -0,0 +1,60 @@
+package refund
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/paytest"
+	"github.com/kuro-liang/wechat-go/util"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestRefundPartnerMode(t *testing.T) {
+	defer gock.Off()
+	const key = "ziR0QKsTUfMOuochC9RfCdmfHECorQAP"
+	cert := paytest.NewTestCert(t, "10000100")
+	cfg := &config.Config{
+		AppID:    "wx2421b1c4370ec43b",
+		MchID:    "10000100",
+		Key:      key,
+		SubAppID: "wx8888888888888888",
+		SubMchID: "1900000109",
+		CertPEM:  cert.CertPEM,
+		KeyPEM:   cert.KeyPEM,
+	}
+	client, err := cfg.TLSClient("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gock.InterceptClient(client)
+
+	// 子商户信息参与签名并随请求发送
+	var sent map[string]string
+	gock.New(config.DefaultBaseURL).Post(refundGateway).
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return false, err
+			}
+			if sent, err = util.XMLToMap(body); err != nil {
+				return false, err
+			}
+			sign, err := util.ParamSign(sent, key)
+			assert.Nil(t, err)
+			assert.Equal(t, sign, sent["sign"], "request sign mismatch")
+			return true, nil
+		}).
+		Reply(200).
+		BodyString(`<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><sub_appid>wx8888888888888888</sub_appid><sub_mch_id>1900000109</sub_mch_id><out_refund_no>R001</out_refund_no><refund_fee>1</refund_fee></xml>`)
+
+	rsp, err := NewRefund(cfg).Refund(&Params{OutTradeNo: "1415757673", OutRefundNo: "R001", TotalFee: 1, RefundFee: 1})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "1900000109", rsp.SubMchID)
+	}
+	assert.True(t, gock.IsDone())
+	assert.Equal(t, "wx8888888888888888", sent["sub_appid"])
+	assert.Equal(t, "1900000109", sent["sub_mch_id"])
+}
@@ -0,0 +1,103 @@
+package transfer
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// 获取RSA加密公钥
+// https://pay.weixin.qq.com/wiki/doc/api/tools/mch_pay.php?chapter=24_7&index=4
+var (
+	publicKeyGateway = "https://fraud.mch.weixin.qq.com/risk/getpublickey"
+	publicKeyPath    = "/risk/getpublickey"
+)
+
+// publicKeyRequest 获取公钥请求参数
+type publicKeyRequest struct {
+	MchID    string   `xml:"mch_id"`
+	NonceStr string   `xml:"nonce_str"`
+	Sign     string   `xml:"sign"`
+	SignType string   `xml:"sign_type"`
+	XMLName  struct{} `xml:"xml"`
+}
+
+// PublicKeyResponse 获取公钥返回
+type PublicKeyResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	ResultCode string `xml:"result_code,omitempty"`
+	ErrCode    string `xml:"err_code,omitempty"`
+	ErrCodeDes string `xml:"err_code_des,omitempty"`
+	MchID      string `xml:"mch_id,omitempty"`
+	PubKey     string `xml:"pub_key,omitempty"`
+}
+
+// GetPublicKey 获取用于加密银行卡号和姓名的 RSA 公钥（PKCS#1 格式），获取成功后缓存在配置中
+func (transfer *Transfer) GetPublicKey(rootCa string) (string, error) {
+	if key := transfer.Config.PublicKey(); key != "" {
+		return key, nil
+	}
+	rsp, err := transfer.FetchPublicKey(rootCa)
+	if err != nil {
+		return "", err
+	}
+	transfer.Config.SetPublicKey(rsp.PubKey)
+	return rsp.PubKey, nil
+}
+
+// SetPublicKey 设置已保存的 RSA 公钥，避免每次启动都请求接口
+func (transfer *Transfer) SetPublicKey(publicKey string) {
+	transfer.Config.SetPublicKey(publicKey)
+}
+
+// ResetPublicKey 清除缓存的 RSA 公钥，商户更换公钥后下次付款重新获取
+func (transfer *Transfer) ResetPublicKey() {
+	transfer.Config.SetPublicKey("")
+}
+
+// FetchPublicKey 请求接口获取 RSA 公钥，不使用缓存
+func (transfer *Transfer) FetchPublicKey(rootCa string) (rsp *PublicKeyResponse, err error) {
+	req := publicKeyRequest{
+		MchID:    transfer.MchID,
+		NonceStr: util.RandomStr(32),
+		SignType: util.SignTypeMD5,
+	}
+	key, err := transfer.SignKey()
+	if err != nil {
+		return
+	}
+	req.Sign, err = util.ParamSign(map[string]string{
+		"mch_id":    req.MchID,
+		"nonce_str": req.NonceStr,
+		"sign_type": req.SignType,
+	}, key)
+	if err != nil {
+		return
+	}
+
+	// 该接口域名与其他接口不同，配置了 BaseURL 时使用 BaseURL
+	gateway := publicKeyGateway
+	if transfer.BaseURL != "" {
+		gateway = transfer.GatewayURL(publicKeyPath)
+	}
+	rawRet, err := transfer.PostXMLWithCert(gateway, req, rootCa)
+	if err != nil {
+		return
+	}
+	err = xml.Unmarshal(rawRet, &rsp)
+	if err != nil {
+		return
+	}
+	if rsp.ReturnCode == "SUCCESS" {
+		if rsp.ResultCode == "SUCCESS" && rsp.PubKey != "" {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("get public key error, errcode=%s,errmsg=%s", rsp.ErrCode, rsp.ErrCodeDes)
+		return
+	}
+	err = fmt.Errorf("[msg : xmlUnmarshalError] [rawReturn : %s] [sign : %s]", string(rawRet), req.Sign)
+	return
+}
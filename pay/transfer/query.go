@@ -0,0 +1,165 @@
+package transfer
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+// 查询企业付款到零钱
+// https://pay.weixin.qq.com/wiki/doc/api/tools/mch_pay.php?chapter=14_3
+var getTransferInfoGateway = "/mmpaymkttransfers/gettransferinfo"
+
+// 付款到零钱状态
+const (
+	StatusSuccess    = "SUCCESS"    // 转账成功
+	StatusFailed     = "FAILED"     // 转账失败
+	StatusProcessing = "PROCESSING" // 处理中
+)
+
+// 需要使用原商户订单号查询或重试的错误码
+const (
+	ErrCodeSystemError = "SYSTEMERROR"
+	ErrCodeNotFound    = "NOT_FOUND"
+)
+
+// ErrTransferProcessing 付款仍在处理中，不能更换商户订单号重新付款，应稍后使用原商户订单号查询
+var ErrTransferProcessing = errors.New("transfer is processing")
+
+// QueryParams 查询付款到零钱参数
+type QueryParams struct {
+	PartnerTradeNo string // 商户订单号
+	RootCa         string // ca证书，Config 中已配置商户证书时可不传
+}
+
+// queryRequest 查询付款到零钱请求参数
+type queryRequest struct {
+	NonceStr       string   `xml:"nonce_str"`
+	Sign           string   `xml:"sign"`
+	PartnerTradeNo string   `xml:"partner_trade_no"`
+	MchID          string   `xml:"mch_id"`
+	AppID          string   `xml:"appid"`
+	XMLName        struct{} `xml:"xml"`
+}
+
+// QueryResponse 查询付款到零钱返回
+type QueryResponse struct {
+	ReturnCode     string    `xml:"return_code"`
+	ReturnMsg      string    `xml:"return_msg"`
+	ResultCode     string    `xml:"result_code,omitempty"`
+	ErrCode        string    `xml:"err_code,omitempty"`
+	ErrCodeDes     string    `xml:"err_code_des,omitempty"`
+	PartnerTradeNo string    `xml:"partner_trade_no,omitempty"`
+	AppID          string    `xml:"appid,omitempty"`
+	MchID          string    `xml:"mch_id,omitempty"`
+	DetailID       string    `xml:"detail_id,omitempty"` // 付款单号
+	Status         string    `xml:"status,omitempty"`
+	Reason         string    `xml:"reason,omitempty"`
+	OpenID         string    `xml:"openid,omitempty"`
+	TransferName   string    `xml:"transfer_name,omitempty"`
+	PaymentAmount  money.Fen `xml:"payment_amount,omitempty"`
+	TransferTime   string    `xml:"transfer_time,omitempty"`
+	PaymentTime    string    `xml:"payment_time,omitempty"`
+	Desc           string    `xml:"desc,omitempty"`
+}
+
+// QueryTransfer 查询付款到零钱的结果
+func (transfer *Transfer) QueryTransfer(p *QueryParams) (rsp *QueryResponse, err error) {
+	req := queryRequest{
+		NonceStr:       util.RandomStr(32),
+		PartnerTradeNo: p.PartnerTradeNo,
+		MchID:          transfer.MchID,
+		AppID:          transfer.AppID,
+	}
+	key, err := transfer.SignKey()
+	if err != nil {
+		return
+	}
+	req.Sign, err = util.ParamSign(map[string]string{
+		"nonce_str":        req.NonceStr,
+		"partner_trade_no": req.PartnerTradeNo,
+		"mch_id":           req.MchID,
+		"appid":            req.AppID,
+	}, key)
+	if err != nil {
+		return
+	}
+
+	rawRet, err := transfer.PostXMLWithCert(transfer.GatewayURL(getTransferInfoGateway), req, p.RootCa)
+	if err != nil {
+		return
+	}
+	err = xml.Unmarshal(rawRet, &rsp)
+	if err != nil {
+		return
+	}
+	if rsp.ReturnCode == "SUCCESS" {
+		if rsp.ResultCode == "SUCCESS" {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("query transfer error, errcode=%s,errmsg=%s", rsp.ErrCode, rsp.ErrCodeDes)
+		return
+	}
+	err = fmt.Errorf("[msg : xmlUnmarshalError] [rawReturn : %s] [sign : %s]", string(rawRet), req.Sign)
+	return
+}
+
+// SafeWalletTransfer 可安全重试的付款到零钱
+// 网络错误或返回 SYSTEMERROR 时不会直接重试，而是等待 interval 后先使用原商户订单号查询：
+// 已付款成功则直接返回结果，处理中时返回 ErrTransferProcessing，已失败时返回 error，
+// 查询不到时才使用原商户订单号重试，最多重试 retries 次
+// 每次重试的等待时间加倍；参数校验、签名等本地错误及其他业务错误直接返回
+func (transfer *Transfer) SafeWalletTransfer(p *Params, retries int, interval time.Duration) (rsp *Response, err error) {
+	for i := 0; ; i++ {
+		rsp, err = transfer.WalletTransfer(p)
+		if !needQuery(rsp, err) {
+			return
+		}
+
+		time.Sleep(interval << uint(i))
+
+		query, queryErr := transfer.QueryTransfer(&QueryParams{PartnerTradeNo: p.PartnerTradeNo, RootCa: p.RootCa})
+		switch {
+		case queryErr == nil && query.Status == StatusSuccess:
+			rsp = &Response{
+				ReturnCode:     "SUCCESS",
+				ResultCode:     "SUCCESS",
+				AppID:          query.AppID,
+				MchID:          query.MchID,
+				PartnerTradeNo: query.PartnerTradeNo,
+				PaymentNo:      query.DetailID,
+				PaymentTime:    query.PaymentTime,
+			}
+			err = nil
+			return
+		case queryErr == nil && query.Status == StatusProcessing:
+			err = fmt.Errorf("transfer %s: %w", p.PartnerTradeNo, ErrTransferProcessing)
+			return
+		case queryErr == nil:
+			err = fmt.Errorf("transfer %s is %s, reason=%s", p.PartnerTradeNo, query.Status, query.Reason)
+			return
+		case query == nil || query.ErrCode != ErrCodeNotFound:
+			// 查询本身失败时无法确认付款状态，不能重试
+			err = fmt.Errorf("transfer error: %v, query error: %v", err, queryErr)
+			return
+		}
+		if i >= retries {
+			return
+		}
+	}
+}
+
+// needQuery 付款结果是否未知
+func needQuery(rsp *Response, err error) bool {
+	errCode := ""
+	if rsp != nil {
+		errCode = rsp.ErrCode
+	}
+	return config.NeedQuery(err, errCode, ErrCodeSystemError)
+}
@@ -0,0 +1,74 @@
+package transfer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestSafeWalletTransferQueriesBeforeRetry(t *testing.T) {
+	defer gock.Off()
+	cfg := newTestConfig(t)
+
+	gock.New(config.DefaultBaseURL).Post(walletTransferGateway).Times(1).Reply(200).
+		BodyString(`<xml><return_code>SUCCESS</return_code><result_code>FAIL</result_code><err_code>SYSTEMERROR</err_code><err_code_des>系统繁忙，请稍后再试。</err_code_des></xml>`)
+	gock.New(config.DefaultBaseURL).Post(getTransferInfoGateway).Times(1).Reply(200).
+		BodyString(`<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><partner_trade_no>T001</partner_trade_no><detail_id>1000000000201503283103439304</detail_id><status>SUCCESS</status><payment_amount>100</payment_amount><payment_time>2015-03-28 12:00:00</payment_time></xml>`)
+
+	rsp, err := NewTransfer(cfg).SafeWalletTransfer(&Params{PartnerTradeNo: "T001", OpenID: "o1", Amount: 100, Desc: "reward"}, 3, time.Millisecond)
+	assert.Nil(t, err)
+	assert.Equal(t, "1000000000201503283103439304", rsp.PaymentNo)
+	assert.True(t, gock.IsDone(), "should not retry after query success")
+}
+
+func TestSafeWalletTransferRetriesWhenNotFound(t *testing.T) {
+	defer gock.Off()
+	cfg := newTestConfig(t)
+
+	gock.New(config.DefaultBaseURL).Post(walletTransferGateway).Times(1).ReplyError(errors.New("connection reset"))
+	gock.New(config.DefaultBaseURL).Post(getTransferInfoGateway).Times(1).Reply(200).
+		BodyString(`<xml><return_code>SUCCESS</return_code><result_code>FAIL</result_code><err_code>NOT_FOUND</err_code><err_code_des>指定单号数据不存在</err_code_des></xml>`)
+	gock.New(config.DefaultBaseURL).Post(walletTransferGateway).Times(1).Reply(200).
+		BodyString(`<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><partner_trade_no>T002</partner_trade_no><payment_no>1000018301201505190181489473</payment_no></xml>`)
+
+	rsp, err := NewTransfer(cfg).SafeWalletTransfer(&Params{PartnerTradeNo: "T002", OpenID: "o1", Amount: 100, Desc: "reward"}, 1, time.Millisecond)
+	assert.Nil(t, err)
+	assert.Equal(t, "1000018301201505190181489473", rsp.PaymentNo)
+	assert.True(t, gock.IsDone())
+}
+
+func TestSafeWalletTransferStopsWhenProcessing(t *testing.T) {
+	defer gock.Off()
+	cfg := newTestConfig(t)
+
+	gock.New(config.DefaultBaseURL).Post(walletTransferGateway).Times(1).ReplyError(errors.New("timeout"))
+	gock.New(config.DefaultBaseURL).Post(getTransferInfoGateway).Times(1).Reply(200).
+		BodyString(`<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><status>PROCESSING</status></xml>`)
+
+	_, err := NewTransfer(cfg).SafeWalletTransfer(&Params{PartnerTradeNo: "T003", OpenID: "o1", Amount: 100, Desc: "reward"}, 3, time.Millisecond)
+	assert.True(t, errors.Is(err, ErrTransferProcessing))
+	assert.EqualError(t, err, "transfer T003: transfer is processing")
+	assert.True(t, gock.IsDone())
+}
+
+func TestSafeWalletTransferLocalError(t *testing.T) {
+	defer gock.Off()
+	cfg := newTestConfig(t)
+
+	// 金额校验失败时请求没有发出，不应查询或重试
+	_, err := NewTransfer(cfg).SafeWalletTransfer(&Params{PartnerTradeNo: "T004", OpenID: "o1", Amount: 1, Desc: "reward"}, 3, time.Millisecond)
+	assert.EqualError(t, err, "amount 0.01 yuan out of range [0.30, 20000.00]")
+
+	// 签名密钥获取失败等本地错误同样直接返回
+	cfg.Sandbox = true
+	gock.New(config.DefaultBaseURL).Post("/sandboxnew/pay/getsignkey").Times(1).Reply(200).
+		BodyString(`<xml><return_code>FAIL</return_code><return_msg>签名错误</return_msg></xml>`)
+	_, err = NewTransfer(cfg).SafeWalletTransfer(&Params{PartnerTradeNo: "T005", OpenID: "o1", Amount: 100, Desc: "reward"}, 3, time.Millisecond)
+	assert.Error(t, err)
+	assert.True(t, gock.IsDone())
+	assert.False(t, gock.HasUnmatchedRequest())
+}
@@ -0,0 +1,208 @@
+package transfer
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/util"
+)
+
+var (
+	// 付款到银行卡
+	// https://pay.weixin.qq.com/wiki/doc/api/tools/mch_pay.php?chapter=24_2
+	bankTransferGateway = "/mmpaysptrans/pay_bank"
+	// 查询付款银行卡
+	// https://pay.weixin.qq.com/wiki/doc/api/tools/mch_pay.php?chapter=24_3
+	queryBankGateway = "/mmpaysptrans/query_bank"
+)
+
+// 付款到银行卡状态
+const (
+	BankStatusProcessing = "PROCESSING" // 处理中
+	BankStatusSuccess    = "SUCCESS"    // 付款成功
+	BankStatusFailed     = "FAILED"     // 付款失败，需要替换付款单号重新发起付款
+	BankStatusBankFail   = "BANK_FAIL"  // 银行退票，订单状态由付款成功流转至退票，退票时付款金额和手续费会自动退还
+)
+
+// BankParams 付款到银行卡参数
+type BankParams struct {
+	PartnerTradeNo string    // 商户企业付款单号
+	BankNo         string    // 收款方银行卡号，明文，发送前使用 RSA 公钥加密
+	TrueName       string    // 收款方用户名，明文，发送前使用 RSA 公钥加密
+	BankCode       string    // 收款方开户行，https://pay.weixin.qq.com/wiki/doc/api/tools/mch_pay.php?chapter=24_4
+	Amount         money.Fen // 付款金额，单位分
+	Desc           string    // 付款说明
+	RootCa         string    // ca证书，Config 中已配置商户证书时可不传
+}
+
+// bankRequest 付款到银行卡请求参数
+type bankRequest struct {
+	MchID          string    `xml:"mch_id"`
+	PartnerTradeNo string    `xml:"partner_trade_no"`
+	NonceStr       string    `xml:"nonce_str"`
+	Sign           string    `xml:"sign"`
+	EncBankNo      string    `xml:"enc_bank_no"`
+	EncTrueName    string    `xml:"enc_true_name"`
+	BankCode       string    `xml:"bank_code"`
+	Amount         money.Fen `xml:"amount"`
+	Desc           string    `xml:"desc,omitempty"`
+	XMLName        struct{}  `xml:"xml"`
+}
+
+// BankResponse 付款到银行卡返回
+type BankResponse struct {
+	ReturnCode     string    `xml:"return_code"`
+	ReturnMsg      string    `xml:"return_msg"`
+	ResultCode     string    `xml:"result_code,omitempty"`
+	ErrCode        string    `xml:"err_code,omitempty"`
+	ErrCodeDes     string    `xml:"err_code_des,omitempty"`
+	MchID          string    `xml:"mch_id,omitempty"`
+	PartnerTradeNo string    `xml:"partner_trade_no,omitempty"`
+	Amount         money.Fen `xml:"amount,omitempty"`
+	NonceStr       string    `xml:"nonce_str,omitempty"`
+	Sign           string    `xml:"sign,omitempty"`
+	PaymentNo      string    `xml:"payment_no,omitempty"`
+	CmmsAmt        money.Fen `xml:"cmms_amt,omitempty"` // 手续费金额，单位分
+}
+
+// QueryBankParams 查询付款银行卡参数
+type QueryBankParams struct {
+	PartnerTradeNo string // 商户企业付款单号
+	RootCa         string // ca证书，Config 中已配置商户证书时可不传
+}
+
+// queryBankRequest 查询付款银行卡请求参数
+type queryBankRequest struct {
+	MchID          string   `xml:"mch_id"`
+	PartnerTradeNo string   `xml:"partner_trade_no"`
+	NonceStr       string   `xml:"nonce_str"`
+	Sign           string   `xml:"sign"`
+	XMLName        struct{} `xml:"xml"`
+}
+
+// QueryBankResponse 查询付款银行卡返回
+type QueryBankResponse struct {
+	ReturnCode     string    `xml:"return_code"`
+	ReturnMsg      string    `xml:"return_msg"`
+	ResultCode     string    `xml:"result_code,omitempty"`
+	ErrCode        string    `xml:"err_code,omitempty"`
+	ErrCodeDes     string    `xml:"err_code_des,omitempty"`
+	MchID          string    `xml:"mch_id,omitempty"`
+	PartnerTradeNo string    `xml:"partner_trade_no,omitempty"`
+	PaymentNo      string    `xml:"payment_no,omitempty"`
+	BankNoMd5      string    `xml:"bank_no_md5,omitempty"`
+	TrueNameMd5    string    `xml:"true_name_md5,omitempty"`
+	Amount         money.Fen `xml:"amount,omitempty"`
+	Status         string    `xml:"status,omitempty"`
+	CmmsAmt        money.Fen `xml:"cmms_amt,omitempty"`
+	CreateTime     string    `xml:"create_time,omitempty"`
+	PaySuccTime    string    `xml:"pay_succ_time,omitempty"`
+	Reason         string    `xml:"reason,omitempty"`
+}
+
+// BankTransfer 付款到银行卡，银行卡号与姓名使用 GetPublicKey 获取的公钥加密
+func (transfer *Transfer) BankTransfer(p *BankParams) (rsp *BankResponse, err error) {
+	if err = money.BankTransferLimit.Check(p.Amount); err != nil {
+		return
+	}
+	publicKey, err := transfer.GetPublicKey(p.RootCa)
+	if err != nil {
+		return
+	}
+	encBankNo, err := util.RSAEncryptOAEPBase64(publicKey, []byte(p.BankNo))
+	if err != nil {
+		return
+	}
+	encTrueName, err := util.RSAEncryptOAEPBase64(publicKey, []byte(p.TrueName))
+	if err != nil {
+		return
+	}
+
+	req := bankRequest{
+		MchID:          transfer.MchID,
+		PartnerTradeNo: p.PartnerTradeNo,
+		NonceStr:       util.RandomStr(32),
+		EncBankNo:      encBankNo,
+		EncTrueName:    encTrueName,
+		BankCode:       p.BankCode,
+		Amount:         p.Amount,
+		Desc:           p.Desc,
+	}
+	key, err := transfer.SignKey()
+	if err != nil {
+		return
+	}
+	req.Sign, err = util.ParamSign(map[string]string{
+		"mch_id":           req.MchID,
+		"partner_trade_no": req.PartnerTradeNo,
+		"nonce_str":        req.NonceStr,
+		"enc_bank_no":      req.EncBankNo,
+		"enc_true_name":    req.EncTrueName,
+		"bank_code":        req.BankCode,
+		"amount":           req.Amount.String(),
+		"desc":             req.Desc,
+	}, key)
+	if err != nil {
+		return
+	}
+
+	rawRet, err := transfer.PostXMLWithCert(transfer.GatewayURL(bankTransferGateway), req, p.RootCa)
+	if err != nil {
+		return
+	}
+	err = xml.Unmarshal(rawRet, &rsp)
+	if err != nil {
+		return
+	}
+	if rsp.ReturnCode == "SUCCESS" {
+		if rsp.ResultCode == "SUCCESS" {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("bank transfer error, errcode=%s,errmsg=%s", rsp.ErrCode, rsp.ErrCodeDes)
+		return
+	}
+	err = fmt.Errorf("[msg : xmlUnmarshalError] [rawReturn : %s] [sign : %s]", string(rawRet), req.Sign)
+	return
+}
+
+// QueryBank 查询付款到银行卡的结果
+func (transfer *Transfer) QueryBank(p *QueryBankParams) (rsp *QueryBankResponse, err error) {
+	req := queryBankRequest{
+		MchID:          transfer.MchID,
+		PartnerTradeNo: p.PartnerTradeNo,
+		NonceStr:       util.RandomStr(32),
+	}
+	key, err := transfer.SignKey()
+	if err != nil {
+		return
+	}
+	req.Sign, err = util.ParamSign(map[string]string{
+		"mch_id":           req.MchID,
+		"partner_trade_no": req.PartnerTradeNo,
+		"nonce_str":        req.NonceStr,
+	}, key)
+	if err != nil {
+		return
+	}
+
+	rawRet, err := transfer.PostXMLWithCert(transfer.GatewayURL(queryBankGateway), req, p.RootCa)
+	if err != nil {
+		return
+	}
+	err = xml.Unmarshal(rawRet, &rsp)
+	if err != nil {
+		return
+	}
+	if rsp.ReturnCode == "SUCCESS" {
+		if rsp.ResultCode == "SUCCESS" {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("query bank error, errcode=%s,errmsg=%s", rsp.ErrCode, rsp.ErrCodeDes)
+		return
+	}
+	err = fmt.Errorf("[msg : xmlUnmarshalError] [rawReturn : %s] [sign : %s]", string(rawRet), req.Sign)
+	return
+}
@@ -0,0 +1,105 @@
+package transfer
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
+	"github.com/kuro-liang/wechat-go/pay/paytest"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// newTestConfig 使用本地生成的证书创建配置，并拦截证书 client 的请求
+func newTestConfig(t *testing.T) *config.Config {
+	cert := paytest.NewTestCert(t, "10000100")
+	cfg := &config.Config{
+		AppID:   "wx2421b1c4370ec43b",
+		MchID:   "10000100",
+		Key:     "ziR0QKsTUfMOuochC9RfCdmfHECorQAP",
+		CertPEM: cert.CertPEM,
+		KeyPEM:  cert.KeyPEM,
+	}
+	client, err := cfg.TLSClient("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gock.InterceptClient(client)
+	return cfg
+}
+
+func TestBankTransfer(t *testing.T) {
+	defer gock.Off()
+	cfg := newTestConfig(t)
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&priv.PublicKey)}))
+
+	gock.New(publicKeyGateway).Reply(200).BodyString(
+		`<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><mch_id>10000100</mch_id><pub_key><![CDATA[` + publicKey + `]]></pub_key></xml>`)
+
+	var sent bankRequest
+	gock.New(config.DefaultBaseURL).Post(bankTransferGateway).
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return false, err
+			}
+			return true, xml.Unmarshal(body, &sent)
+		}).
+		Reply(200).
+		BodyString(`<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><partner_trade_no>B001</partner_trade_no><amount>500</amount><payment_no>10000600500852017030900000020006012</payment_no><cmms_amt>100</cmms_amt></xml>`)
+
+	transfer := NewTransfer(cfg)
+	rsp, err := transfer.BankTransfer(&BankParams{
+		PartnerTradeNo: "B001",
+		BankNo:         "6225888888888888",
+		TrueName:       "张三",
+		BankCode:       "1001",
+		Amount:         500,
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, money.Fen(100), rsp.CmmsAmt)
+	assert.True(t, gock.IsDone())
+
+	decrypt := func(s string) string {
+		ciphertext, err := base64.StdEncoding.DecodeString(s)
+		assert.Nil(t, err)
+		plaintext, err := rsa.DecryptOAEP(sha1.New(), rand.Reader, priv, ciphertext, nil)
+		assert.Nil(t, err)
+		return string(plaintext)
+	}
+	assert.Equal(t, "6225888888888888", decrypt(sent.EncBankNo))
+	assert.Equal(t, "张三", decrypt(sent.EncTrueName))
+
+	// 公钥已缓存
+	cached, err := transfer.GetPublicKey("")
+	assert.Nil(t, err)
+	assert.Equal(t, publicKey, cached)
+
+	// 缓存属于配置实例，其他商户配置不受影响
+	assert.Equal(t, "", (&config.Config{MchID: cfg.MchID}).PublicKey())
+
+	// 清除缓存后重新请求接口
+	transfer.ResetPublicKey()
+	gock.New(publicKeyGateway).Reply(200).BodyString(
+		`<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><mch_id>10000100</mch_id><pub_key><![CDATA[new-key]]></pub_key></xml>`)
+	cached, err = NewTransfer(cfg).GetPublicKey("")
+	assert.Nil(t, err)
+	assert.Equal(t, "new-key", cached)
+	assert.True(t, gock.IsDone())
+}
@@ -3,15 +3,15 @@ package transfer
 import (
 	"encoding/xml"
 	"fmt"
-	"strconv"
 
 	"github.com/kuro-liang/wechat-go/pay/config"
+	"github.com/kuro-liang/wechat-go/pay/money"
 	"github.com/kuro-liang/wechat-go/util"
 )
 
 // 付款到零钱
 // https://pay.weixin.qq.com/wiki/doc/api/tools/mch_pay.php?chapter=14_2
-var walletTransferGateway = "https://api.mch.weixin.qq.com/mmpaymkttransfers/promotion/transfers"
+var walletTransferGateway = "/mmpaymkttransfers/promotion/transfers"
 
 // Transfer struct extends context
 type Transfer struct {
@@ -31,26 +31,26 @@ type Params struct {
 	OpenID         string
 	CheckName      bool
 	ReUserName     string
-	Amount         int
+	Amount         money.Fen // 付款金额，单位分
 	Desc           string
 	SpbillCreateIP string
-	RootCa         string // ca证书
+	RootCa         string // ca证书，Config 中已配置商户证书时可不传
 }
 
 // request 接口请求参数
 type request struct {
-	AppID          string `xml:"mch_appid"`
-	MchID          string `xml:"mchid"`
-	NonceStr       string `xml:"nonce_str"`
-	Sign           string `xml:"sign"`
-	DeviceInfo     string `xml:"device_info,omitempty"`
-	PartnerTradeNo string `xml:"partner_trade_no"`
-	OpenID         string `xml:"openid"`
-	CheckName      string `xml:"check_name"`
-	ReUserName     string `xml:"re_user_name,omitempty"`
-	Amount         int    `xml:"amount"`
-	Desc           string `xml:"desc"`
-	SpbillCreateIP string `xml:"spbill_create_ip,omitempty"`
+	AppID          string    `xml:"mch_appid"`
+	MchID          string    `xml:"mchid"`
+	NonceStr       string    `xml:"nonce_str"`
+	Sign           string    `xml:"sign"`
+	DeviceInfo     string    `xml:"device_info,omitempty"`
+	PartnerTradeNo string    `xml:"partner_trade_no"`
+	OpenID         string    `xml:"openid"`
+	CheckName      string    `xml:"check_name"`
+	ReUserName     string    `xml:"re_user_name,omitempty"`
+	Amount         money.Fen `xml:"amount"`
+	Desc           string    `xml:"desc"`
+	SpbillCreateIP string    `xml:"spbill_create_ip,omitempty"`
 }
 
 // Response 接口返回
@@ -71,6 +71,9 @@ type Response struct {
 
 // WalletTransfer 付款到零钱
 func (transfer *Transfer) WalletTransfer(p *Params) (rsp *Response, err error) {
+	if err = money.WalletTransferLimit.Check(p.Amount); err != nil {
+		return
+	}
 	nonceStr := util.RandomStr(32)
 	param := make(map[string]string)
 	param["mch_appid"] = transfer.AppID
@@ -78,7 +81,7 @@ func (transfer *Transfer) WalletTransfer(p *Params) (rsp *Response, err error) {
 	param["nonce_str"] = nonceStr
 	param["partner_trade_no"] = p.PartnerTradeNo
 	param["openid"] = p.OpenID
-	param["amount"] = strconv.Itoa(p.Amount)
+	param["amount"] = p.Amount.String()
 	param["desc"] = p.Desc
 	if p.DeviceInfo != "" {
 		param["device_info"] = p.DeviceInfo
@@ -92,7 +95,11 @@ func (transfer *Transfer) WalletTransfer(p *Params) (rsp *Response, err error) {
 		param["spbill_create_ip"] = p.SpbillCreateIP
 	}
 
-	sign, err := util.ParamSign(param, transfer.Key)
+	key, err := transfer.SignKey()
+	if err != nil {
+		return
+	}
+	sign, err := util.ParamSign(param, key)
 	if err != nil {
 		return
 	}
@@ -114,7 +121,7 @@ func (transfer *Transfer) WalletTransfer(p *Params) (rsp *Response, err error) {
 		req.CheckName = "FORCE_CHECK"
 		req.ReUserName = p.ReUserName
 	}
-	rawRet, err := util.PostXMLWithTLS(walletTransferGateway, req, p.RootCa, transfer.MchID)
+	rawRet, err := transfer.PostXMLWithCert(transfer.GatewayURL(walletTransferGateway), req, p.RootCa)
 	if err != nil {
 		return
 	}
@@ -330,3 +330,16 @@ func PKCS5UnPadding(origData []byte) []byte {
 	unPadding := int(origData[length-1])
 	return origData[:(length - unPadding)]
 }
+
+// AesGCMDecrypt AEAD_AES_256_GCM 解密，用于微信支付 APIv3 回调通知的 resource 解密
+func AesGCMDecrypt(key, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(nonce))
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -10,7 +10,6 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"mime/multipart"
 	"net/http"
 	"os"
@@ -204,33 +203,48 @@ func PostXML(uri string, obj interface{}) ([]byte, error) {
 
 // httpWithTLS CA证书
 func httpWithTLS(rootCa, key string) (*http.Client, error) {
-	var client *http.Client
 	certData, err := ioutil.ReadFile(rootCa)
 	if err != nil {
 		return nil, fmt.Errorf("unable to find cert path=%s, error=%v", rootCa, err)
 	}
-	cert := pkcs12ToPem(certData, key)
+	return NewTLSClientFromP12(certData, key)
+}
+
+// NewTLSClientFromP12 使用 p12 格式的商户证书创建 http.Client，password 一般为商户号
+func NewTLSClientFromP12(p12 []byte, password string) (*http.Client, error) {
+	cert, err := pkcs12ToPem(p12, password)
+	if err != nil {
+		return nil, err
+	}
+	return newTLSClient(cert), nil
+}
+
+// NewTLSClientFromPEM 使用 PEM 格式的商户证书及私钥（apiclient_cert.pem、apiclient_key.pem）创建 http.Client
+func NewTLSClientFromPEM(certPEM, keyPEM []byte) (*http.Client, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("parse pem cert error, err=%v", err)
+	}
+	return newTLSClient(cert), nil
+}
+
+func newTLSClient(cert tls.Certificate) *http.Client {
 	config := &tls.Config{
 		Certificates: []tls.Certificate{cert},
 	}
 	tr := &http.Transport{
+		Proxy:              http.ProxyFromEnvironment,
 		TLSClientConfig:    config,
 		DisableCompression: true,
 	}
-	client = &http.Client{Transport: tr}
-	return client, nil
+	return &http.Client{Transport: tr}
 }
 
 // pkcs12ToPem 将Pkcs12转成Pem
-func pkcs12ToPem(p12 []byte, password string) tls.Certificate {
+func pkcs12ToPem(p12 []byte, password string) (tls.Certificate, error) {
 	blocks, err := pkcs12.ToPEM(p12, password)
-	defer func() {
-		if x := recover(); x != nil {
-			log.Print(x)
-		}
-	}()
 	if err != nil {
-		panic(err)
+		return tls.Certificate{}, fmt.Errorf("decode p12 cert error, err=%v", err)
 	}
 	var pemData []byte
 	for _, b := range blocks {
@@ -238,23 +252,29 @@ func pkcs12ToPem(p12 []byte, password string) tls.Certificate {
 	}
 	cert, err := tls.X509KeyPair(pemData, pemData)
 	if err != nil {
-		panic(err)
+		return tls.Certificate{}, fmt.Errorf("parse p12 cert error, err=%v", err)
 	}
-	return cert
+	return cert, nil
 }
 
 // PostXMLWithTLS perform a HTTP/POST request with XML body and TLS
+// 每次调用都会重新读取证书，需要复用连接时请使用 PostXMLWithClient
 func PostXMLWithTLS(uri string, obj interface{}, ca, key string) ([]byte, error) {
-	xmlData, err := xml.Marshal(obj)
+	client, err := httpWithTLS(ca, key)
 	if err != nil {
 		return nil, err
 	}
+	return PostXMLWithClient(client, uri, obj)
+}
 
-	body := bytes.NewBuffer(xmlData)
-	client, err := httpWithTLS(ca, key)
+// PostXMLWithClient perform a HTTP/POST request with XML body using the given client
+func PostXMLWithClient(client *http.Client, uri string, obj interface{}) ([]byte, error) {
+	xmlData, err := xml.Marshal(obj)
 	if err != nil {
 		return nil, err
 	}
+
+	body := bytes.NewBuffer(xmlData)
 	response, err := client.Post(uri, "application/xml;charset=utf-8", body)
 	if err != nil {
 		return nil, err
@@ -1,8 +1,11 @@
 package util
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
@@ -12,6 +15,15 @@ import (
 
 // RSADecrypt 数据解密
 func RSADecrypt(privateKey string, ciphertext []byte) ([]byte, error) {
+	priv, err := ParseRSAPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return rsa.DecryptPKCS1v15(rand.Reader, priv, ciphertext)
+}
+
+// ParseRSAPrivateKey 解析 PEM 格式的 RSA 私钥，兼容 PKCS1 与 PKCS8 格式
+func ParseRSAPrivateKey(privateKey string) (*rsa.PrivateKey, error) {
 	block, _ := pem.Decode([]byte(privateKey))
 	if block == nil {
 		return nil, errors.New("PrivateKey format error")
@@ -30,7 +42,7 @@ func RSADecrypt(privateKey string, ciphertext []byte) ([]byte, error) {
 			return nil, fmt.Errorf("ParsePKCS1PrivateKey error: %s, ParsePKCS8PrivateKey error: Not supported privatekey format, should be *rsa.PrivateKey, got %T", oldErr.Error(), t)
 		}
 	}
-	return rsa.DecryptPKCS1v15(rand.Reader, priv, ciphertext)
+	return priv, nil
 }
 
 // RSADecryptBase64 Base64解码后再次进行RSA解密
@@ -41,3 +53,70 @@ func RSADecryptBase64(privateKey string, cryptoText string) ([]byte, error) {
 	}
 	return RSADecrypt(privateKey, encryptedData)
 }
+
+// RSAEncryptOAEP 使用 RSA 公钥以 OAEP(SHA1) 填充加密，兼容 PKCS1 与 PKIX 格式的公钥
+func RSAEncryptOAEP(publicKey string, plaintext []byte) ([]byte, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, errors.New("PublicKey format error")
+	}
+	pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+	if err != nil {
+		oldErr := err
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("ParsePKCS1PublicKey error: %s, ParsePKIXPublicKey error: %s", oldErr.Error(), err.Error())
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("ParsePKCS1PublicKey error: %s, ParsePKIXPublicKey error: Not supported publickey format, should be *rsa.PublicKey, got %T", oldErr.Error(), key)
+		}
+		pub = rsaKey
+	}
+	return rsa.EncryptOAEP(sha1.New(), rand.Reader, pub, plaintext, nil)
+}
+
+// RSAEncryptOAEPBase64 RSA 加密后再进行 Base64 编码
+func RSAEncryptOAEPBase64(publicKey string, plaintext []byte) (string, error) {
+	ciphertext, err := RSAEncryptOAEP(publicKey, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// RSASignSHA256 使用 RSA 私钥进行 SHA256withRSA 签名，返回 Base64 编码的签名
+func RSASignSHA256(privateKey string, data []byte) (string, error) {
+	priv, err := ParseRSAPrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	hashed := sha256.Sum256(data)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// RSAVerifySHA256WithCert 使用证书中的公钥校验 Base64 编码的 SHA256withRSA 签名
+func RSAVerifySHA256WithCert(certificate string, data []byte, signature string) error {
+	block, _ := pem.Decode([]byte(certificate))
+	if block == nil {
+		return errors.New("Certificate format error")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("Not supported publickey format, should be *rsa.PublicKey, got %T", cert.PublicKey)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return err
+	}
+	hashed := sha256.Sum256(data)
+	return rsa.VerifyPKCS1v15(pub, crypto.SHA256, hashed[:], sig)
+}
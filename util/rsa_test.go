@@ -0,0 +1,39 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRSAEncryptOAEP(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs1 := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&priv.PublicKey)}))
+	pkixDer, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkix := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkixDer}))
+
+	for _, publicKey := range []string{pkcs1, pkix} {
+		encrypted, err := RSAEncryptOAEPBase64(publicKey, []byte("6225888888888888"))
+		assert.Nil(t, err)
+		ciphertext, err := base64.StdEncoding.DecodeString(encrypted)
+		assert.Nil(t, err)
+		plaintext, err := rsa.DecryptOAEP(sha1.New(), rand.Reader, priv, ciphertext, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "6225888888888888", string(plaintext))
+	}
+
+	_, err = RSAEncryptOAEP("invalid", []byte("data"))
+	assert.NotNil(t, err)
+}
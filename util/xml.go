@@ -0,0 +1,62 @@
+package util
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// XMLToMap 将微信支付返回的扁平 xml 解析为 map
+// 仅解析根节点下的一级子节点，如 <xml><return_code>SUCCESS</return_code></xml>
+func XMLToMap(data []byte) (map[string]string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("empty xml")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := token.(xml.StartElement); ok {
+			return DecodeXMLMap(decoder)
+		}
+	}
+}
+
+// DecodeXMLMap 读取当前节点下的一级子节点直至当前节点结束，可用于实现 xml.Unmarshaler
+func DecodeXMLMap(decoder *xml.Decoder) (map[string]string, error) {
+	result := make(map[string]string)
+	var (
+		depth int
+		key   string
+		value bytes.Buffer
+	)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				key = t.Name.Local
+				value.Reset()
+			}
+		case xml.CharData:
+			if depth == 1 {
+				value.Write(t)
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				return result, nil
+			}
+			if depth == 1 {
+				result[key] = value.String()
+			}
+			depth--
+		}
+	}
+}